@@ -0,0 +1,31 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type AuthenticateUserUseCase struct {
+	repo domain.UserRepository
+}
+
+func NewAuthenticateUserUseCase(repo domain.UserRepository) *AuthenticateUserUseCase {
+	return &AuthenticateUserUseCase{repo: repo}
+}
+
+// Execute returns the user token belongs to, or ErrInvalidToken.
+func (uc *AuthenticateUserUseCase) Execute(ctx context.Context, token string) (_ *domain.User, err error) {
+	ctx, span := startSpan(ctx, "AuthenticateUserUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	if !strings.HasPrefix(token, domain.TokenPrefix) {
+		return nil, domain.ErrInvalidToken
+	}
+	user, err := uc.repo.FindByTokenHash(ctx, domain.HashToken(token))
+	if err == domain.ErrUserNotFound {
+		return nil, domain.ErrInvalidToken
+	}
+	return user, err
+}
@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// BatchUploadItem is one file of a batch. Open is called once, when the file
+// is about to be stored.
+type BatchUploadItem struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Open        func() (io.ReadCloser, error)
+}
+
+type BatchUploadCommand struct {
+	Items []BatchUploadItem
+	// CollectionID optionally files every item in a collection.
+	CollectionID string
+}
+
+// BatchUploadResult is the outcome for the item at the same position in the
+// command: the stored file, or the reason it was rejected.
+type BatchUploadResult struct {
+	Name string
+	File *domain.File
+	Err  error
+}
+
+type BatchUploadUseCase struct {
+	upload      *UploadFileUseCase
+	maxFiles    int
+	concurrency int
+}
+
+func NewBatchUploadUseCase(upload *UploadFileUseCase, maxFiles, concurrency int) *BatchUploadUseCase {
+	return &BatchUploadUseCase{upload: upload, maxFiles: maxFiles, concurrency: concurrency}
+}
+
+// Execute validates and stores every item independently, so one bad file
+// does not fail the batch. Only a batch that is empty or too large is
+// rejected as a whole.
+func (uc *BatchUploadUseCase) Execute(ctx context.Context, command BatchUploadCommand) (_ []BatchUploadResult, err error) {
+	ctx, span := startSpan(ctx, "BatchUploadUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	if len(command.Items) == 0 {
+		return nil, domain.ErrFileRequired.WithFields(domain.FieldError{
+			Field:   "files",
+			Code:    "required",
+			Message: "files is required",
+			Params:  []string{"files"},
+		})
+	}
+	if len(command.Items) > uc.maxFiles {
+		return nil, domain.ErrInvalidRequest.WithFields(domain.FieldError{
+			Field:   "files",
+			Code:    "max_items",
+			Message: fmt.Sprintf("at most %d files are accepted per batch", uc.maxFiles),
+			Params:  []string{strconv.Itoa(uc.maxFiles)},
+		})
+	}
+
+	// A missing collection would fail every item the same way
+	if command.CollectionID != "" {
+		if _, err := uc.upload.collections.FindByID(ctx, command.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]BatchUploadResult, len(command.Items))
+	slots := make(chan struct{}, uc.concurrency)
+	var wg sync.WaitGroup
+	for i, item := range command.Items {
+		results[i].Name = item.Name
+		if err := validateBatchItem(item); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(result *BatchUploadResult, item BatchUploadItem) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			result.File, result.Err = uc.store(ctx, item, command.CollectionID)
+		}(&results[i], item)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func validateBatchItem(item BatchUploadItem) error {
+	if err := domain.ValidateFileName(domain.SanitizeFileName(item.Name)); err != nil {
+		return err
+	}
+	if err := domain.ValidateFileSize(item.Size); err != nil {
+		return err
+	}
+	return domain.ValidateMimeType(item.ContentType)
+}
+
+func (uc *BatchUploadUseCase) store(ctx context.Context, item BatchUploadItem, collectionID string) (*domain.File, error) {
+	content, err := item.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open batch item")
+	}
+	defer content.Close()
+
+	file, err := uc.upload.Execute(ctx, UploadFileCommand{
+		Name:         item.Name,
+		ContentType:  item.ContentType,
+		Content:      content,
+		Size:         item.Size,
+		CollectionID: collectionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// batchItem is an item with content whose Open can be made to fail.
+func batchItem(name, contentType, content string, openErr error) BatchUploadItem {
+	return BatchUploadItem{
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Open: func() (io.ReadCloser, error) {
+			if openErr != nil {
+				return nil, openErr
+			}
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+func TestBatchUploadStoresItemsIndependently(t *testing.T) {
+	files, collections := newMemoryFileRepository(), newMemoryCollectionRepository()
+	batch := NewBatchUploadUseCase(NewUploadFileUseCase(files, collections, newMemoryOutboxRepository()), 10, 2)
+	oversized := batchItem("big.pdf", "application/pdf", "", nil)
+	oversized.Size = int64(domain.MaxFileSize) + 1
+	corrupt := errors.New("archive entry is corrupt")
+
+	tests := []struct {
+		item BatchUploadItem
+		err  error
+	}{
+		{item: batchItem("scan-001.pdf", "application/pdf", "first", nil)},
+		{item: batchItem("notes.txt", "text/plain", "notes", nil), err: domain.ErrFileType},
+		{item: oversized, err: domain.ErrFileTooLarge},
+		{item: batchItem(" ", "application/pdf", "blank", nil), err: domain.ErrInvalidRequest},
+		{item: batchItem("unreadable.pdf", "application/pdf", "", corrupt), err: corrupt},
+		{item: batchItem("scan-002.pdf", "application/pdf", "second", nil)},
+	}
+	var items []BatchUploadItem
+	for _, tt := range tests {
+		items = append(items, tt.item)
+	}
+
+	results, err := batch.Execute(context.Background(), BatchUploadCommand{Items: items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("%d results for %d items", len(results), len(tests))
+	}
+	for i, tt := range tests {
+		result := results[i]
+		if result.Name != tt.item.Name {
+			t.Errorf("result %d is for %q, want %q", i, result.Name, tt.item.Name)
+		}
+		if tt.err != nil {
+			if !errors.Is(result.Err, tt.err) || result.File != nil {
+				t.Errorf("%s: err = %v, want %v", tt.item.Name, result.Err, tt.err)
+			}
+		} else if result.Err != nil || result.File == nil || result.File.Name != tt.item.Name {
+			t.Errorf("%s: got %+v, want it stored", tt.item.Name, result)
+		}
+	}
+	if len(files.files) != 2 {
+		t.Errorf("%d files stored, want 2", len(files.files))
+	}
+}
+
+func TestBatchUploadRejectsWholeBatch(t *testing.T) {
+	ctx := context.Background()
+	pdf := batchItem("scan.pdf", "application/pdf", "content", nil)
+
+	tests := []struct {
+		name    string
+		command BatchUploadCommand
+		err     error
+		code    string
+	}{
+		{name: "empty", command: BatchUploadCommand{}, err: domain.ErrFileRequired, code: "required"},
+		{name: "too many files", command: BatchUploadCommand{Items: []BatchUploadItem{pdf, pdf, pdf, pdf}}, err: domain.ErrInvalidRequest, code: "max_items"},
+		{name: "unknown collection", command: BatchUploadCommand{Items: []BatchUploadItem{pdf}, CollectionID: "c999"}, err: domain.ErrCollectionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := newMemoryFileRepository()
+			batch := NewBatchUploadUseCase(NewUploadFileUseCase(files, newMemoryCollectionRepository(), newMemoryOutboxRepository()), 3, 2)
+
+			results, err := batch.Execute(ctx, tt.command)
+			if !errors.Is(err, tt.err) || results != nil {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if domainErr, _ := domain.AsError(err); tt.code != "" && (len(domainErr.Fields) != 1 || domainErr.Fields[0].Code != tt.code) {
+				t.Errorf("fields = %+v, want code %s", domainErr.Fields, tt.code)
+			}
+			if len(files.files) != 0 {
+				t.Errorf("%d files stored", len(files.files))
+			}
+		})
+	}
+}
+
+func TestBatchUploadFilesItemsInCollection(t *testing.T) {
+	files, collections := newMemoryFileRepository(), newMemoryCollectionRepository()
+	ctx := context.Background()
+	series := &domain.Collection{Name: "Series"}
+	if err := collections.Save(ctx, series); err != nil {
+		t.Fatal(err)
+	}
+	batch := NewBatchUploadUseCase(NewUploadFileUseCase(files, collections, newMemoryOutboxRepository()), 10, 2)
+
+	results, err := batch.Execute(ctx, BatchUploadCommand{
+		Items: []BatchUploadItem{
+			batchItem("scan-001.pdf", "application/pdf", "first", nil),
+			batchItem("scan-002.pdf", "application/pdf", "second", nil),
+		},
+		CollectionID: series.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err != nil || result.File.CollectionID != series.ID {
+			t.Errorf("%s: %+v", result.Name, result)
+		}
+	}
+}
+
+func TestUploadsKeepWithinStorageQuota(t *testing.T) {
+	defer func(previous int64) { domain.StorageQuota = previous }(domain.StorageQuota)
+	domain.StorageQuota = 10
+	files := newMemoryFileRepository()
+	upload := NewUploadFileUseCase(files, newMemoryCollectionRepository(), newMemoryOutboxRepository())
+	ctx := context.Background()
+
+	if _, err := upload.Execute(ctx, UploadFileCommand{Name: "first.pdf", Content: strings.NewReader("123456"), Size: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := upload.Execute(ctx, UploadFileCommand{Name: "second.pdf", Content: strings.NewReader("123456"), Size: 6}); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Fatalf("upload over the quota: %v", err)
+	}
+
+	// Items that no longer fit are rejected, the others are stored
+	results, err := NewBatchUploadUseCase(upload, 10, 1).Execute(ctx, BatchUploadCommand{Items: []BatchUploadItem{
+		batchItem("big.pdf", "application/pdf", "12345", nil),
+		batchItem("small.pdf", "application/pdf", "1234", nil),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, domain.ErrQuotaExceeded) || results[1].Err != nil {
+		t.Fatalf("results: %+v", results)
+	}
+	if len(files.files) != 2 {
+		t.Errorf("%d files stored, want 2", len(files.files))
+	}
+}
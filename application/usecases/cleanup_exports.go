@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type CleanupExportsUseCase struct {
+	exports domain.ExportRepository
+}
+
+func NewCleanupExportsUseCase(exports domain.ExportRepository) *CleanupExportsUseCase {
+	return &CleanupExportsUseCase{exports: exports}
+}
+
+// Execute deletes expired exports with their archives and returns how many
+// were deleted.
+func (uc *CleanupExportsUseCase) Execute(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CleanupExportsUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	expired, err := uc.exports.FindExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, export := range expired {
+		if err := uc.exports.Delete(ctx, export.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
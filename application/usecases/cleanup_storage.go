@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type CleanupStorageCommand struct {
+	// DeleteOrphans removes chunk sets that have no files document and are
+	// older than the stale threshold.
+	DeleteOrphans bool
+}
+
+type CleanupStorageUseCase struct {
+	inspector  domain.StorageInspector
+	staleAfter time.Duration
+}
+
+// NewCleanupStorageUseCase creates the storage check. Orphan chunks newer
+// than staleAfter may belong to an upload still being written, so it should
+// be longer than the upload timeout. Incomplete files are only reported;
+// deleting them is left to the caller, which audits each delete.
+func NewCleanupStorageUseCase(inspector domain.StorageInspector, staleAfter time.Duration) *CleanupStorageUseCase {
+	return &CleanupStorageUseCase{inspector: inspector, staleAfter: staleAfter}
+}
+
+func (uc *CleanupStorageUseCase) Execute(ctx context.Context, cmd CleanupStorageCommand) (_ *domain.StorageReport, err error) {
+	ctx, span := startSpan(ctx, "CleanupStorageUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	report := &domain.StorageReport{CheckedAt: time.Now().UTC()}
+
+	orphans, err := uc.inspector.FindOrphanChunks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	staleBefore := report.CheckedAt.Add(-uc.staleAfter)
+	for _, orphan := range orphans {
+		if orphan.CreatedAt.After(staleBefore) {
+			report.InProgress++
+			continue
+		}
+		report.Orphans = append(report.Orphans, orphan)
+		report.OrphanBytes += orphan.Bytes
+	}
+
+	report.Incomplete, err = uc.inspector.FindIncompleteFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.DeleteOrphans {
+		for _, orphan := range report.Orphans {
+			deleted, err := uc.inspector.DeleteOrphanChunks(ctx, orphan.FileID)
+			if err != nil {
+				return report, err
+			}
+			if deleted > 0 {
+				report.DeletedOrphans++
+				report.ReclaimedBytes += orphan.Bytes
+			}
+		}
+	}
+
+	return report, nil
+}
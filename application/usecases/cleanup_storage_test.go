@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func orphan(fileID string, age time.Duration, bytes int64) *domain.StorageIssue {
+	return &domain.StorageIssue{
+		Kind:      domain.StorageIssueOrphanChunks,
+		FileID:    fileID,
+		Chunks:    1,
+		Bytes:     bytes,
+		CreatedAt: time.Now().UTC().Add(-age),
+	}
+}
+
+func TestCleanupStorage(t *testing.T) {
+	const staleAfter = 20 * time.Minute
+	newInspector := func() *memoryStorageInspector {
+		return &memoryStorageInspector{
+			orphans: []*domain.StorageIssue{
+				orphan("f001", time.Hour, 100),
+				orphan("f002", 2*time.Hour, 200),
+				orphan("f003", time.Hour, 400),
+				// Possibly an upload still being written
+				orphan("f004", time.Minute, 800),
+			},
+			incomplete: []*domain.StorageIssue{{Kind: domain.StorageIssueIncompleteFile, FileID: "f005", Length: 10}},
+			finalized:  map[string]bool{"f003": true},
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		delete    bool
+		deleted   string
+		reclaimed int64
+	}{
+		{name: "report only"},
+		{name: "delete orphans", delete: true, deleted: "f001,f002", reclaimed: 300},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inspector := newInspector()
+			report, err := NewCleanupStorageUseCase(inspector, staleAfter).Execute(context.Background(), CleanupStorageCommand{DeleteOrphans: tc.delete})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Orphans) != 3 || report.OrphanBytes != 700 || report.InProgress != 1 || len(report.Incomplete) != 1 {
+				t.Errorf("report: %+v", report)
+			}
+			if strings.Join(inspector.deleted, ",") != tc.deleted || report.DeletedOrphans != len(inspector.deleted) ||
+				report.ReclaimedBytes != tc.reclaimed || report.DeletedFiles != 0 {
+				t.Errorf("deleted %v: %+v", inspector.deleted, report)
+			}
+		})
+	}
+}
+
+func TestCleanupExportsDeletesExpiredExports(t *testing.T) {
+	exports := newMemoryExportRepository()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, expires := range []time.Time{now.Add(-time.Hour), now.Add(-time.Minute), now.Add(time.Hour)} {
+		if err := exports.Save(ctx, &domain.Export{Status: domain.ExportCompleted, ExpiresAt: expires}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := NewCleanupExportsUseCase(exports).Execute(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("deleted %d: %v", deleted, err)
+	}
+	if _, err := exports.FindByID(ctx, "e003"); err != nil || len(exports.exports) != 1 {
+		t.Errorf("left %d exports: %v", len(exports.exports), err)
+	}
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// collectionFixture wires the collection and file use cases to in-memory
+// repositories.
+type collectionFixture struct {
+	collections *memoryCollectionRepository
+	files       *memoryFileRepository
+	outbox      *memoryOutboxRepository
+	create      *CreateCollectionUseCase
+	update      *UpdateCollectionUseCase
+	delete      *DeleteCollectionUseCase
+	upload      *UploadFileUseCase
+}
+
+func newCollectionFixture() *collectionFixture {
+	f := &collectionFixture{
+		collections: newMemoryCollectionRepository(),
+		files:       newMemoryFileRepository(),
+		outbox:      newMemoryOutboxRepository(),
+	}
+	f.create = NewCreateCollectionUseCase(f.collections, inlineTransactor{})
+	f.update = NewUpdateCollectionUseCase(f.collections, inlineTransactor{})
+	f.delete = NewDeleteCollectionUseCase(f.collections, f.files, inlineTransactor{})
+	f.upload = NewUploadFileUseCase(f.files, f.collections, f.outbox)
+	return f
+}
+
+func (f *collectionFixture) mustCreate(t *testing.T, name, parentID string) *domain.Collection {
+	t.Helper()
+	collection, err := f.create.Execute(context.Background(), CreateCollectionCommand{Name: name, ParentID: parentID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return collection
+}
+
+func TestUpdateCollectionMovesDescendants(t *testing.T) {
+	f := newCollectionFixture()
+	ctx := context.Background()
+	fonds := f.mustCreate(t, "Fonds", "")
+	series := f.mustCreate(t, "Series", fonds.ID)
+	file := f.mustCreate(t, "File", series.ID)
+	other := f.mustCreate(t, "Other fonds", "")
+
+	moved, changes, err := f.update.Execute(ctx, UpdateCollectionCommand{ID: series.ID, ParentID: &other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0] != (domain.AuditChange{Field: "parent_id", From: fonds.ID, To: other.ID}) {
+		t.Errorf("changes = %+v", changes)
+	}
+	if len(moved.Path) != 2 || moved.Path[0].Name != "Other fonds" {
+		t.Errorf("path = %+v", moved.Path)
+	}
+	below, _ := f.collections.FindByID(ctx, file.ID)
+	if want := []string{other.ID, series.ID}; strings.Join(below.Ancestors, ",") != strings.Join(want, ",") {
+		t.Errorf("descendant ancestors = %v, want %v", below.Ancestors, want)
+	}
+
+	if _, _, err := f.update.Execute(ctx, UpdateCollectionCommand{ID: series.ID, ParentID: &file.ID}); err != domain.ErrCollectionCycle {
+		t.Errorf("moving below itself: got %v", err)
+	}
+}
+
+func TestDeleteCollectionRefusesNonEmpty(t *testing.T) {
+	f := newCollectionFixture()
+	ctx := context.Background()
+	fonds := f.mustCreate(t, "Fonds", "")
+	series := f.mustCreate(t, "Series", fonds.ID)
+
+	if _, err := f.delete.Execute(ctx, fonds.ID); err != domain.ErrCollectionNotEmpty {
+		t.Fatalf("collection with a child: got %v", err)
+	}
+	if _, err := f.upload.Execute(ctx, UploadFileCommand{Name: "a.pdf", Content: strings.NewReader("a"), CollectionID: series.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.delete.Execute(ctx, series.ID); err != domain.ErrCollectionNotEmpty {
+		t.Fatalf("collection with a file: got %v", err)
+	}
+}
+
+// hookReader calls hook once, when an upload starts reading it.
+type hookReader struct {
+	*strings.Reader
+	hook func()
+}
+
+func (r *hookReader) Read(p []byte) (int, error) {
+	if r.hook != nil {
+		r.hook()
+		r.hook = nil
+	}
+	return r.Reader.Read(p)
+}
+
+func TestUploadIntoDeletedCollectionIsUndone(t *testing.T) {
+	f := newCollectionFixture()
+	ctx := context.Background()
+	series := f.mustCreate(t, "Series", "")
+
+	content := &hookReader{Reader: strings.NewReader("a"), hook: func() {
+		if _, err := f.delete.Execute(ctx, series.ID); err != nil {
+			t.Errorf("delete during the upload: %v", err)
+		}
+	}}
+	if _, err := f.upload.Execute(ctx, UploadFileCommand{Name: "a.pdf", Content: content, CollectionID: series.ID}); err != domain.ErrCollectionNotFound {
+		t.Fatalf("upload: got %v", err)
+	}
+	if count, _ := f.files.Count(ctx, domain.FileFilter{}); count != 0 {
+		t.Errorf("%d files left in the deleted collection", count)
+	}
+	if len(f.outbox.events(domain.OutboxStaged)) != 0 || len(f.outbox.events(domain.OutboxReady)) != 0 {
+		t.Errorf("events left: %d staged, %d committed", len(f.outbox.events(domain.OutboxStaged)), len(f.outbox.events(domain.OutboxReady)))
+	}
+}
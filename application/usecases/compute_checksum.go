@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// ChecksumJobType is the job that records the checksum of an uploaded file.
+const ChecksumJobType = "file.checksum"
+
+type ChecksumJobPayload struct {
+	FileID string `json:"file_id"`
+}
+
+type ComputeChecksumUseCase struct {
+	repo domain.FileRepository
+}
+
+func NewComputeChecksumUseCase(repo domain.FileRepository) *ComputeChecksumUseCase {
+	return &ComputeChecksumUseCase{repo: repo}
+}
+
+// Handler returns the job handler of ChecksumJobType.
+func (uc *ComputeChecksumUseCase) Handler() domain.JobHandler {
+	return NewJobHandler(ChecksumJobType, uc.Execute)
+}
+
+// Execute reads the stored content of the file and records its SHA-256
+// checksum. Files deleted in the meantime are skipped.
+func (uc *ComputeChecksumUseCase) Execute(ctx context.Context, payload ChecksumJobPayload) (err error) {
+	ctx, span := startSpan(ctx, "ComputeChecksumUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	_, content, err := uc.repo.FindByID(ctx, payload.FileID)
+	if err == domain.ErrFileNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return errors.Wrap(err, "failed to read file content")
+	}
+	err = uc.repo.SetChecksum(ctx, payload.FileID, hex.EncodeToString(hash.Sum(nil)))
+	if err == domain.ErrFileNotFound {
+		return nil
+	}
+	return err
+}
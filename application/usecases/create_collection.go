@@ -0,0 +1,63 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type CreateCollectionCommand struct {
+	Name string
+	// ParentID is empty for a top-level collection.
+	ParentID string
+}
+
+type CreateCollectionUseCase struct {
+	repo domain.CollectionRepository
+	tx   domain.Transactor
+}
+
+func NewCreateCollectionUseCase(repo domain.CollectionRepository, tx domain.Transactor) *CreateCollectionUseCase {
+	return &CreateCollectionUseCase{repo: repo, tx: tx}
+}
+
+// Execute creates the collection. The parent is locked while the collection
+// is saved, so it cannot be deleted at the same time.
+func (uc *CreateCollectionUseCase) Execute(ctx context.Context, command CreateCollectionCommand) (_ *domain.Collection, err error) {
+	ctx, span := startSpan(ctx, "CreateCollectionUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	name := strings.TrimSpace(command.Name)
+	if err := domain.ValidateCollectionName(name); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	collection := &domain.Collection{Name: name, CreatedAt: now, UpdatedAt: now}
+	if command.ParentID != "" {
+		parent, err := uc.repo.FindByID(ctx, command.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		collection.ParentID = parent.ID
+		collection.Ancestors = append(append([]string{}, parent.Ancestors...), parent.ID)
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if collection.ParentID != "" {
+			if err := uc.repo.Lock(ctx, collection.ParentID); err != nil {
+				return err
+			}
+		}
+		return uc.repo.Save(ctx, collection)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := withPaths(ctx, uc.repo, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
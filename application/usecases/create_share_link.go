@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type CreateShareLinkCommand struct {
+	FileID       string
+	CreatedBy    string
+	TTL          time.Duration
+	MaxDownloads int64
+	Password     string
+}
+
+type SignedShareLink struct {
+	Link *domain.ShareLink
+	// Token identifies the link in its public download URL.
+	Token string
+}
+
+type CreateShareLinkUseCase struct {
+	fileRepo  domain.FileRepository
+	shareRepo domain.ShareLinkRepository
+	signer    *ShareLinkSigner
+}
+
+func NewCreateShareLinkUseCase(
+	fileRepo domain.FileRepository,
+	shareRepo domain.ShareLinkRepository,
+	signer *ShareLinkSigner,
+) *CreateShareLinkUseCase {
+	return &CreateShareLinkUseCase{fileRepo: fileRepo, shareRepo: shareRepo, signer: signer}
+}
+
+func (uc *CreateShareLinkUseCase) Execute(ctx context.Context, command CreateShareLinkCommand) (*SignedShareLink, error) {
+	if command.TTL <= 0 {
+		command.TTL = domain.DefaultShareLinkTTL
+	}
+	if command.TTL > domain.MaxShareLinkTTL {
+		return nil, domain.ErrShareLinkInvalidTTL
+	}
+	if command.MaxDownloads < 0 {
+		command.MaxDownloads = 0
+	}
+
+	// Make sure the file exists before handing out a link to it
+	_, content, err := uc.fileRepo.FindByID(ctx, command.FileID)
+	if err != nil {
+		return nil, err
+	}
+	content.Close()
+
+	now := time.Now().UTC()
+	link := &domain.ShareLink{
+		FileID:       command.FileID,
+		CreatedBy:    command.CreatedBy,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(command.TTL).Truncate(time.Second),
+		MaxDownloads: command.MaxDownloads,
+	}
+
+	if command.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(command.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to hash share link password")
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if err := uc.shareRepo.Save(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return &SignedShareLink{
+		Link:  link,
+		Token: uc.signer.Token(link.ID, link.FileID, link.ExpiresAt.Unix()),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type CreateUserUseCase struct {
+	repo domain.UserRepository
+}
+
+func NewCreateUserUseCase(repo domain.UserRepository) *CreateUserUseCase {
+	return &CreateUserUseCase{repo: repo}
+}
+
+// Execute creates a user and returns it with its bearer token. The token is
+// not stored and cannot be shown again.
+func (uc *CreateUserUseCase) Execute(ctx context.Context, name string) (_ *domain.User, token string, err error) {
+	ctx, span := startSpan(ctx, "CreateUserUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	if err := domain.ValidateUserName(name); err != nil {
+		return nil, "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", errors.Wrap(err, "failed to generate token")
+	}
+	token = domain.TokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	user := &domain.User{
+		Name:      name,
+		TokenHash: domain.HashToken(token),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := uc.repo.Save(ctx, user); err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type DeleteCollectionUseCase struct {
+	repo  domain.CollectionRepository
+	files domain.FileRepository
+	tx    domain.Transactor
+}
+
+func NewDeleteCollectionUseCase(repo domain.CollectionRepository, files domain.FileRepository, tx domain.Transactor) *DeleteCollectionUseCase {
+	return &DeleteCollectionUseCase{repo: repo, files: files, tx: tx}
+}
+
+// Execute deletes the collection if it holds neither files nor collections.
+// The collection is locked while it is checked, so files and collections
+// added to it at the same time either make it non-empty or fail. It returns
+// the deleted collection.
+func (uc *DeleteCollectionUseCase) Execute(ctx context.Context, id string) (_ *domain.Collection, err error) {
+	ctx, span := startSpan(ctx, "DeleteCollectionUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	collection, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Lock(ctx, collection.ID); err != nil {
+			return err
+		}
+		children, err := uc.repo.FindChildren(ctx, collection.ID)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return domain.ErrCollectionNotEmpty
+		}
+		files, err := uc.files.FindPage(ctx, domain.FilePageQuery{
+			Filter: domain.FileFilter{CollectionIDs: []string{collection.ID}},
+			Limit:  1,
+		})
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			return domain.ErrCollectionNotEmpty
+		}
+		return uc.repo.Delete(ctx, collection.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
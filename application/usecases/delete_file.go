@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type DeleteFileUseCase struct {
+	repo   domain.FileRepository
+	outbox domain.OutboxRepository
+}
+
+func NewDeleteFileUseCase(repo domain.FileRepository, outbox domain.OutboxRepository) *DeleteFileUseCase {
+	return &DeleteFileUseCase{repo: repo, outbox: outbox}
+}
+
+func (uc *DeleteFileUseCase) Execute(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteFileUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	event := domain.NewEvent(domain.EventFileDeleted, id, nil)
+	if err := uc.outbox.Stage(ctx, event); err != nil {
+		return err
+	}
+
+	settleCtx := context.WithoutCancel(ctx)
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		_ = uc.outbox.Discard(settleCtx, event.ID)
+		return err
+	}
+
+	commitEvent(settleCtx, uc.outbox, event)
+	return nil
+}
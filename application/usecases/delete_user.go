@@ -0,0 +1,23 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type DeleteUserUseCase struct {
+	repo domain.UserRepository
+}
+
+func NewDeleteUserUseCase(repo domain.UserRepository) *DeleteUserUseCase {
+	return &DeleteUserUseCase{repo: repo}
+}
+
+// Execute deletes the named user; its token stops working immediately.
+func (uc *DeleteUserUseCase) Execute(ctx context.Context, name string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteUserUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	return uc.repo.DeleteByName(ctx, name)
+}
@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type DeleteWebhookUseCase struct {
+	repo domain.WebhookRepository
+}
+
+func NewDeleteWebhookUseCase(repo domain.WebhookRepository) *DeleteWebhookUseCase {
+	return &DeleteWebhookUseCase{repo: repo}
+}
+
+func (uc *DeleteWebhookUseCase) Execute(ctx context.Context, id string) error {
+	return uc.repo.Delete(ctx, id)
+}
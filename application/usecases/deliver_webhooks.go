@@ -0,0 +1,76 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// webhookClaimLease is how long a claimed delivery is hidden from other
+// workers; it must comfortably exceed the HTTP timeout of the sender.
+const webhookClaimLease = 2 * time.Minute
+
+type DeliverWebhooksUseCase struct {
+	webhookRepo  domain.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	sender       domain.WebhookSender
+}
+
+func NewDeliverWebhooksUseCase(
+	webhookRepo domain.WebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+	sender domain.WebhookSender,
+) *DeliverWebhooksUseCase {
+	return &DeliverWebhooksUseCase{webhookRepo: webhookRepo, deliveryRepo: deliveryRepo, sender: sender}
+}
+
+// Execute attempts up to batchSize due deliveries and returns how many were
+// attempted. Failed attempts are rescheduled with exponential backoff until
+// MaxWebhookAttempts is reached.
+func (uc *DeliverWebhooksUseCase) Execute(ctx context.Context, batchSize int) (int, error) {
+	deliveries, err := uc.deliveryRepo.ClaimDue(ctx, time.Now().UTC(), webhookClaimLease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		uc.attempt(ctx, delivery)
+		delivery.UpdatedAt = time.Now().UTC()
+		if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (uc *DeliverWebhooksUseCase) attempt(ctx context.Context, delivery *domain.WebhookDelivery) {
+	webhook, err := uc.webhookRepo.FindByID(ctx, delivery.WebhookID)
+	if err != nil || !webhook.Active {
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = "webhook no longer active"
+		return
+	}
+
+	delivery.Attempts++
+	status, err := uc.sender.Send(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil && status >= 200 && status < 300 {
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		return
+	}
+
+	if err != nil {
+		delivery.LastError = err.Error()
+	} else {
+		delivery.LastError = fmt.Sprintf("unexpected response status %d", status)
+	}
+
+	if delivery.Attempts >= domain.MaxWebhookAttempts {
+		delivery.Status = domain.DeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = time.Now().UTC().Add(domain.WebhookBackoff(delivery.Attempts))
+}
@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// outboxClaimLease hides a claimed event from other dispatchers while its
+// sinks are being called.
+const outboxClaimLease = time.Minute
+
+type DispatchEventsUseCase struct {
+	outbox domain.OutboxRepository
+	sinks  []domain.EventSink
+}
+
+func NewDispatchEventsUseCase(outbox domain.OutboxRepository, sinks ...domain.EventSink) *DispatchEventsUseCase {
+	return &DispatchEventsUseCase{outbox: outbox, sinks: sinks}
+}
+
+// Execute publishes up to batchSize ready events to every sink that has not
+// received them yet and returns how many events were processed. Events with
+// failing sinks are retried with exponential backoff.
+func (uc *DispatchEventsUseCase) Execute(ctx context.Context, batchSize int) (int, error) {
+	entries, err := uc.outbox.ClaimReady(ctx, time.Now().UTC(), outboxClaimLease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		var failures []string
+		for _, sink := range uc.sinks {
+			if entry.Delivered(sink.Name()) {
+				continue
+			}
+			if err := sink.Publish(ctx, entry.Event); err != nil {
+				failures = append(failures, sink.Name()+": "+err.Error())
+				continue
+			}
+			if err := uc.outbox.MarkDelivered(ctx, entry.Event.ID, sink.Name()); err != nil {
+				return len(entries), err
+			}
+		}
+
+		if len(failures) == 0 {
+			err = uc.outbox.MarkPublished(ctx, entry.Event.ID)
+		} else {
+			attempts := entry.Attempts + 1
+			next := time.Now().UTC().Add(domain.OutboxBackoff(attempts))
+			err = uc.outbox.Reschedule(ctx, entry.Event.ID, attempts, next, strings.Join(failures, "; "))
+		}
+		if err != nil {
+			return len(entries), err
+		}
+	}
+	return len(entries), nil
+}
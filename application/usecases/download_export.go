@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"io"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type DownloadExportUseCase struct {
+	getExport *GetExportUseCase
+	exports   domain.ExportRepository
+}
+
+func NewDownloadExportUseCase(getExport *GetExportUseCase, exports domain.ExportRepository) *DownloadExportUseCase {
+	return &DownloadExportUseCase{getExport: getExport, exports: exports}
+}
+
+// Execute opens the archive of a completed export.
+func (uc *DownloadExportUseCase) Execute(ctx context.Context, id string) (_ *domain.Export, _ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "DownloadExportUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	export, err := uc.getExport.Execute(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch export.Status {
+	case domain.ExportCompleted:
+	case domain.ExportFailed:
+		return nil, nil, domain.ErrExportFailed
+	default:
+		return nil, nil, domain.ErrExportNotReady
+	}
+
+	content, err := uc.exports.OpenContent(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, content, nil
+}
@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type EnqueueJobCommand struct {
+	Type string
+	// Payload is anything that encodes to a JSON object; it is handed to the
+	// handler of Type.
+	Payload interface{}
+	// RunAt delays the job; the zero time runs it as soon as possible.
+	RunAt time.Time
+}
+
+type EnqueueJobUseCase struct {
+	repo        domain.JobRepository
+	maxAttempts int
+}
+
+func NewEnqueueJobUseCase(repo domain.JobRepository, maxAttempts int) *EnqueueJobUseCase {
+	return &EnqueueJobUseCase{repo: repo, maxAttempts: maxAttempts}
+}
+
+// Execute adds a job to the queue. It is run by whichever worker has a
+// handler for its type.
+func (uc *EnqueueJobUseCase) Execute(ctx context.Context, command EnqueueJobCommand) (_ *domain.Job, err error) {
+	ctx, span := startSpan(ctx, "EnqueueJobUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	var payload map[string]interface{}
+	if command.Payload != nil {
+		data, err := json.Marshal(command.Payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode job payload")
+		}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, errors.Wrap(err, "job payload must be an object")
+		}
+	}
+
+	now := time.Now().UTC()
+	job := &domain.Job{
+		Type:        command.Type,
+		Payload:     payload,
+		Status:      domain.JobPending,
+		MaxAttempts: uc.maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if command.RunAt.After(now) {
+		job.RunAt = command.RunAt.UTC()
+	}
+	if err := uc.repo.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type webhookPayload struct {
+	ID         string                 `json:"id"`
+	Type       domain.EventType       `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	FileID     string                 `json:"file_id,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type EnqueueWebhookDeliveriesUseCase struct {
+	webhookRepo  domain.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+}
+
+func NewEnqueueWebhookDeliveriesUseCase(
+	webhookRepo domain.WebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+) *EnqueueWebhookDeliveriesUseCase {
+	return &EnqueueWebhookDeliveriesUseCase{webhookRepo: webhookRepo, deliveryRepo: deliveryRepo}
+}
+
+// Execute persists one pending delivery per subscribed webhook.
+func (uc *EnqueueWebhookDeliveriesUseCase) Execute(ctx context.Context, event domain.Event) error {
+	webhooks, err := uc.webhookRepo.FindByEvent(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		FileID:     event.FileID,
+		Data:       event.Data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode webhook payload")
+	}
+
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		delivery := &domain.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := uc.deliveryRepo.Save(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package usecases
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+const (
+	archiveListBatch    = 500
+	archiveFilesDir     = "files/"
+	archiveManifestName = "manifest.json"
+	archiveChecksumName = "SHA256SUMS"
+)
+
+// ArchivePlan is the list of files an archive will contain, resolved before
+// anything is written so that an empty or oversized selection can still be
+// rejected with a proper error.
+type ArchivePlan struct {
+	Files   []*domain.File
+	Missing []string
+}
+
+type ExportArchiveUseCase struct {
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
+}
+
+func NewExportArchiveUseCase(repo domain.FileRepository, collections domain.CollectionRepository) *ExportArchiveUseCase {
+	return &ExportArchiveUseCase{repo: repo, collections: collections}
+}
+
+// ScopeToCollection limits filter to the files in a collection and, with
+// recursive, the collections below it, as file listings do. The scope is
+// resolved now, so an export started later keeps to the collections that
+// existed when it was requested.
+func (uc *ExportArchiveUseCase) ScopeToCollection(ctx context.Context, filter domain.FileFilter, collectionID string, recursive bool) (_ domain.FileFilter, err error) {
+	ctx, span := startSpan(ctx, "ExportArchiveUseCase.ScopeToCollection")
+	defer func() { endSpan(span, err) }()
+
+	filter.CollectionIDs, err = collectionScope(ctx, uc.collections, collectionID, recursive)
+	return filter, err
+}
+
+// Plan lists the files matching filter, newest first. With a positive
+// maxFiles, selections of more files are rejected.
+func (uc *ExportArchiveUseCase) Plan(ctx context.Context, filter domain.FileFilter, maxFiles int) (_ *ArchivePlan, err error) {
+	ctx, span := startSpan(ctx, "ExportArchiveUseCase.Plan")
+	defer func() { endSpan(span, err) }()
+
+	plan := &ArchivePlan{}
+	query := domain.FilePageQuery{Filter: filter, Limit: archiveListBatch}
+	for {
+		files, err := uc.repo.FindPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		plan.Files = append(plan.Files, files...)
+		if maxFiles > 0 && len(plan.Files) > maxFiles {
+			return nil, domain.ErrArchiveTooLarge
+		}
+		if len(files) < archiveListBatch {
+			break
+		}
+		last := files[len(files)-1]
+		query.After = &domain.FileCursor{UploadDate: last.UploadDate, ID: last.ID}
+	}
+
+	if filter.IDs != nil {
+		found := make(map[string]bool, len(plan.Files))
+		for _, file := range plan.Files {
+			found[file.ID] = true
+		}
+		for _, id := range filter.IDs {
+			if !found[id] {
+				plan.Missing = append(plan.Missing, id)
+				found[id] = true
+			}
+		}
+	}
+
+	if len(plan.Files) == 0 {
+		return nil, domain.ErrArchiveEmpty
+	}
+	return plan, nil
+}
+
+// Write streams a ZIP archive of the planned files to w, straight from
+// storage, followed by a manifest and a SHA256SUMS file. Files deleted since
+// the plan was made are listed as missing.
+func (uc *ExportArchiveUseCase) Write(ctx context.Context, plan *ArchivePlan, w io.Writer) (_ *domain.ArchiveManifest, err error) {
+	ctx, span := startSpan(ctx, "ExportArchiveUseCase.Write")
+	defer func() { endSpan(span, err) }()
+
+	manifest := &domain.ArchiveManifest{
+		CreatedAt: time.Now().UTC(),
+		Missing:   append([]string(nil), plan.Missing...),
+	}
+	archive := zip.NewWriter(w)
+	names := map[string]bool{}
+
+	for _, planned := range plan.Files {
+		file, content, err := uc.repo.FindByID(ctx, planned.ID)
+		if err == domain.ErrFileNotFound {
+			manifest.Missing = append(manifest.Missing, planned.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := domain.ArchiveEntry{
+			ID:          file.ID,
+			Name:        file.Name,
+			Path:        archiveFilesDir + uniqueArchiveName(names, file.Name),
+			ContentType: file.ContentType,
+			UploadDate:  file.UploadDate,
+		}
+		entry.Size, entry.SHA256, err = writeArchiveEntry(archive, entry, content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	if err := writeManifest(archive, manifest); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to finish archive")
+	}
+	return manifest, nil
+}
+
+func writeArchiveEntry(archive *zip.Writer, entry domain.ArchiveEntry, content io.Reader) (int64, string, error) {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     entry.Path,
+		Method:   zip.Deflate,
+		Modified: entry.UploadDate,
+	})
+	if err != nil {
+		return 0, "", errors.Wrap(err, "failed to add file to archive")
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(w, hash), content)
+	if err != nil {
+		return 0, "", errors.Wrap(err, "failed to write file to archive")
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uniqueArchiveName returns name, or name with a " (n)" suffix when an
+// earlier file already took it. Names are compared case-insensitively, as
+// most file systems the archive is unpacked on do.
+func uniqueArchiveName(used map[string]bool, name string) string {
+	name = strings.TrimLeft(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "" {
+		name = "file"
+	}
+	candidate := name
+	ext := path.Ext(name)
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+type manifestEntry struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadDate  time.Time `json:"upload_date"`
+	SHA256      string    `json:"sha256"`
+}
+
+type manifestDocument struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Files     []manifestEntry `json:"files"`
+	Missing   []string        `json:"missing,omitempty"`
+}
+
+func writeManifest(archive *zip.Writer, manifest *domain.ArchiveManifest) error {
+	doc := manifestDocument{CreatedAt: manifest.CreatedAt, Missing: manifest.Missing}
+	var sums strings.Builder
+	for _, e := range manifest.Files {
+		doc.Files = append(doc.Files, manifestEntry{
+			ID:          e.ID,
+			Name:        e.Name,
+			Path:        e.Path,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			UploadDate:  e.UploadDate,
+			SHA256:      e.SHA256,
+		})
+		fmt.Fprintf(&sums, "%s  %s\n", e.SHA256, e.Path)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode archive manifest")
+	}
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{archiveManifestName, data},
+		{archiveChecksumName, []byte(sums.String())},
+	} {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: manifest.CreatedAt})
+		if err != nil {
+			return errors.Wrap(err, "failed to add manifest to archive")
+		}
+		if _, err := w.Write(f.content); err != nil {
+			return errors.Wrap(err, "failed to write manifest to archive")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// saveFiles stores a file for each name, uploaded a minute apart, oldest
+// first, with the name as content.
+func saveFiles(t *testing.T, files *memoryFileRepository, collectionID string, names ...string) []*domain.File {
+	t.Helper()
+	var saved []*domain.File
+	for _, name := range names {
+		file := &domain.File{
+			Name:         name,
+			ContentType:  "application/pdf",
+			CollectionID: collectionID,
+			UploadDate:   time.Date(2024, 1, 1, 0, len(files.files), 0, 0, time.UTC),
+		}
+		if err := files.Save(context.Background(), file, strings.NewReader(name)); err != nil {
+			t.Fatal(err)
+		}
+		saved = append(saved, file)
+	}
+	return saved
+}
+
+// readArchive returns the content of every entry in a ZIP archive by name.
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(content)
+	}
+	return entries
+}
+
+func TestExportArchiveWritesFilesManifestAndChecksums(t *testing.T) {
+	files := newMemoryFileRepository()
+	saved := saveFiles(t, files, "", "report.pdf", "Report.PDF", "../minutes.pdf", "gone.pdf")
+	archive := NewExportArchiveUseCase(files, newMemoryCollectionRepository())
+	ctx := context.Background()
+
+	ids := []string{saved[0].ID, saved[1].ID, saved[2].ID, saved[3].ID, "f999"}
+	plan, err := archive.Plan(ctx, domain.FileFilter{IDs: ids}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Files) != 4 || strings.Join(plan.Missing, ",") != "f999" {
+		t.Fatalf("plan: %d files, missing %v", len(plan.Files), plan.Missing)
+	}
+	// A file deleted between planning and writing is reported, not fatal
+	if err := files.Delete(ctx, saved[3].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	manifest, err := archive.Write(ctx, plan, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"f999", saved[3].ID}; strings.Join(manifest.Missing, ",") != strings.Join(want, ",") {
+		t.Errorf("missing = %v, want %v", manifest.Missing, want)
+	}
+
+	entries := readArchive(t, buf.Bytes())
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Newest first, so the later upload keeps the plain name
+	want := []string{"SHA256SUMS", "files/Report.PDF", "files/minutes.pdf", "files/report (2).pdf", "manifest.json"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	if entries["files/report (2).pdf"] != "report.pdf" {
+		t.Errorf("files/report (2).pdf holds %q", entries["files/report (2).pdf"])
+	}
+
+	var doc manifestDocument
+	if err := json.Unmarshal([]byte(entries[archiveManifestName]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Files) != 3 || len(doc.Missing) != 2 {
+		t.Fatalf("manifest: %+v", doc)
+	}
+	var sums strings.Builder
+	for _, entry := range doc.Files {
+		hash := sha256.Sum256([]byte(entries[entry.Path]))
+		if entry.SHA256 != hex.EncodeToString(hash[:]) || entry.Size != int64(len(entries[entry.Path])) {
+			t.Errorf("manifest entry %s does not match its content", entry.Path)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", entry.SHA256, entry.Path)
+	}
+	if entries[archiveChecksumName] != sums.String() {
+		t.Errorf("SHA256SUMS = %q, want %q", entries[archiveChecksumName], sums.String())
+	}
+}
+
+func TestExportArchivePlan(t *testing.T) {
+	files := newMemoryFileRepository()
+	var names []string
+	for i := 0; i < archiveListBatch+5; i++ {
+		names = append(names, fmt.Sprintf("scan-%d.pdf", i))
+	}
+	saveFiles(t, files, "", names...)
+	archive := NewExportArchiveUseCase(files, newMemoryCollectionRepository())
+
+	tests := []struct {
+		name     string
+		filter   domain.FileFilter
+		maxFiles int
+		files    int
+		err      error
+	}{
+		{name: "every page", files: archiveListBatch + 5},
+		{name: "within the limit", maxFiles: archiveListBatch + 5, files: archiveListBatch + 5},
+		{name: "over the limit", maxFiles: 10, err: domain.ErrArchiveTooLarge},
+		{name: "nothing matches", filter: domain.FileFilter{ContentType: "image/png"}, err: domain.ErrArchiveEmpty},
+		{name: "only missing IDs", filter: domain.FileFilter{IDs: []string{"f999"}}, err: domain.ErrArchiveEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := archive.Plan(context.Background(), tt.filter, tt.maxFiles)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && len(plan.Files) != tt.files {
+				t.Errorf("%d files, want %d", len(plan.Files), tt.files)
+			}
+		})
+	}
+}
+
+func TestExportArchiveScopesToCollection(t *testing.T) {
+	files, collections := newMemoryFileRepository(), newMemoryCollectionRepository()
+	ctx := context.Background()
+	fonds := &domain.Collection{Name: "Fonds"}
+	if err := collections.Save(ctx, fonds); err != nil {
+		t.Fatal(err)
+	}
+	series := &domain.Collection{Name: "Series", ParentID: fonds.ID, Ancestors: []string{fonds.ID}}
+	if err := collections.Save(ctx, series); err != nil {
+		t.Fatal(err)
+	}
+	saveFiles(t, files, fonds.ID, "fonds.pdf")
+	saveFiles(t, files, series.ID, "series.pdf")
+	saveFiles(t, files, "", "elsewhere.pdf")
+	archive := NewExportArchiveUseCase(files, collections)
+
+	for _, tt := range []struct {
+		recursive bool
+		want      string
+	}{
+		{recursive: false, want: "fonds.pdf"},
+		{recursive: true, want: "series.pdf,fonds.pdf"},
+	} {
+		filter, err := archive.ScopeToCollection(ctx, domain.FileFilter{}, fonds.ID, tt.recursive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := archive.Plan(ctx, filter, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, file := range plan.Files {
+			got = append(got, file.Name)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("recursive %v: files = %v, want %s", tt.recursive, got, tt.want)
+		}
+	}
+
+	if _, err := archive.ScopeToCollection(ctx, domain.FileFilter{}, "c999", true); err != domain.ErrCollectionNotFound {
+		t.Errorf("unknown collection: %v", err)
+	}
+}
+
+// newExportFixture wires the export use cases with exports run inline.
+func newExportFixture(files *memoryFileRepository) (*memoryExportRepository, *StartExportUseCase, *DownloadExportUseCase) {
+	exports := newMemoryExportRepository()
+	archive := NewExportArchiveUseCase(files, newMemoryCollectionRepository())
+	inline := func(fn func(ctx context.Context)) { fn(context.Background()) }
+	start := NewStartExportUseCase(exports, archive, time.Hour, inline)
+	download := NewDownloadExportUseCase(NewGetExportUseCase(exports), exports)
+	return exports, start, download
+}
+
+func TestStartExportBuildsArchive(t *testing.T) {
+	files := newMemoryFileRepository()
+	saveFiles(t, files, "", "report.pdf", "minutes.pdf")
+	exports, start, download := newExportFixture(files)
+	ctx := context.Background()
+
+	export, err := start.Execute(ctx, StartExportCommand{CreatedBy: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Status != domain.ExportPending {
+		t.Errorf("returned as %s", export.Status)
+	}
+
+	done, content, err := download.Execute(ctx, export.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	if done.Status != domain.ExportCompleted || done.Files != 2 || done.CreatedBy != "alice" || done.FinishedAt.IsZero() {
+		t.Fatalf("export: %+v", done)
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Size != int64(len(data)) || int64(len(exports.contents[export.ID])) != done.Size {
+		t.Errorf("size %d, archive has %d bytes", done.Size, len(data))
+	}
+	entries := readArchive(t, data)
+	if entries["files/report.pdf"] != "report.pdf" || entries["files/minutes.pdf"] != "minutes.pdf" {
+		t.Errorf("entries: %v", entries)
+	}
+}
+
+func TestStartExportRecordsFailure(t *testing.T) {
+	_, start, download := newExportFixture(newMemoryFileRepository())
+	ctx := context.Background()
+
+	export, err := start.Execute(ctx, StartExportCommand{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := download.Execute(ctx, export.ID); err != domain.ErrExportFailed {
+		t.Fatalf("download: %v", err)
+	}
+	failed, err := NewGetExportUseCase(start.exports).Execute(ctx, export.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != domain.ExportFailed || failed.Error != domain.ErrArchiveEmpty.Error() {
+		t.Errorf("export: %+v", failed)
+	}
+}
+
+func TestDownloadExport(t *testing.T) {
+	exports := newMemoryExportRepository()
+	download := NewDownloadExportUseCase(NewGetExportUseCase(exports), exports)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	tests := []struct {
+		status  domain.ExportStatus
+		expires time.Time
+		err     error
+	}{
+		{status: domain.ExportPending, expires: now.Add(time.Hour), err: domain.ErrExportNotReady},
+		{status: domain.ExportRunning, expires: now.Add(time.Hour), err: domain.ErrExportNotReady},
+		{status: domain.ExportFailed, expires: now.Add(time.Hour), err: domain.ErrExportFailed},
+		{status: domain.ExportCompleted, expires: now.Add(-time.Minute), err: domain.ErrExportNotFound},
+		{status: domain.ExportCompleted, expires: now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		export := &domain.Export{Status: tt.status, ExpiresAt: tt.expires}
+		if err := exports.Save(ctx, export); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := exports.WriteContent(ctx, export.ID, strings.NewReader("archive")); err != nil {
+			t.Fatal(err)
+		}
+
+		_, content, err := download.Execute(ctx, export.ID)
+		if err != tt.err {
+			t.Errorf("%s export expiring at %s: err = %v, want %v", tt.status, tt.expires, err, tt.err)
+			continue
+		}
+		if err == nil {
+			content.Close()
+		}
+	}
+	if _, _, err := download.Execute(ctx, "e999"); err != domain.ErrExportNotFound {
+		t.Errorf("unknown export: %v", err)
+	}
+}
@@ -0,0 +1,642 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// memoryFileRepository is a domain.FileRepository kept in memory.
+type memoryFileRepository struct {
+	mu       sync.Mutex
+	files    map[string]*domain.File
+	contents map[string][]byte
+	nextID   int
+}
+
+func newMemoryFileRepository() *memoryFileRepository {
+	return &memoryFileRepository{files: map[string]*domain.File{}, contents: map[string][]byte{}}
+}
+
+func (r *memoryFileRepository) NextID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	return fmt.Sprintf("f%03d", r.nextID)
+}
+
+func (r *memoryFileRepository) Save(ctx context.Context, file *domain.File, content io.Reader) error {
+	if file.ID == "" {
+		file.ID = r.NextID()
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	file.Size = int64(len(data))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *file
+	r.files[file.ID] = &stored
+	r.contents[file.ID] = data
+	return nil
+}
+
+func (r *memoryFileRepository) FindByID(ctx context.Context, id string) (*domain.File, io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, ok := r.files[id]
+	if !ok {
+		return nil, nil, domain.ErrFileNotFound
+	}
+	found := *file
+	return &found, io.NopCloser(bytes.NewReader(r.contents[id])), nil
+}
+
+// sorted returns the files matching filter in listing order, newest first.
+func (r *memoryFileRepository) sorted(filter domain.FileFilter) []*domain.File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files []*domain.File
+	for _, file := range r.files {
+		if matchesFilter(file, filter) {
+			found := *file
+			files = append(files, &found)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return listedBefore(files[i], files[j]) })
+	return files
+}
+
+func listedBefore(a, b *domain.File) bool {
+	if !a.UploadDate.Equal(b.UploadDate) {
+		return a.UploadDate.After(b.UploadDate)
+	}
+	return a.ID > b.ID
+}
+
+func matchesFilter(file *domain.File, filter domain.FileFilter) bool {
+	if filter.IDs != nil && !contains(filter.IDs, file.ID) {
+		return false
+	}
+	if filter.CollectionIDs != nil && !contains(filter.CollectionIDs, file.CollectionID) {
+		return false
+	}
+	if filter.ContentType != "" && file.ContentType != filter.ContentType {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryFileRepository) FindAll(ctx context.Context, filter domain.FileFilter, skip, limit int64) ([]*domain.File, error) {
+	files := r.sorted(filter)
+	if skip >= int64(len(files)) {
+		return nil, nil
+	}
+	files = files[skip:]
+	if limit < int64(len(files)) {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+func (r *memoryFileRepository) FindPage(ctx context.Context, query domain.FilePageQuery) ([]*domain.File, error) {
+	var page []*domain.File
+	files := r.sorted(query.Filter)
+	if query.Before != nil {
+		// Walk backwards from the cursor, then restore listing order
+		before := &domain.File{UploadDate: query.Before.UploadDate, ID: query.Before.ID}
+		for i := len(files) - 1; i >= 0 && int64(len(page)) < query.Limit; i-- {
+			if listedBefore(files[i], before) {
+				page = append([]*domain.File{files[i]}, page...)
+			}
+		}
+		return page, nil
+	}
+	for _, file := range files {
+		if int64(len(page)) == query.Limit {
+			break
+		}
+		if query.After != nil && !listedBefore(&domain.File{UploadDate: query.After.UploadDate, ID: query.After.ID}, file) {
+			continue
+		}
+		page = append(page, file)
+	}
+	return page, nil
+}
+
+func (r *memoryFileRepository) Count(ctx context.Context, filter domain.FileFilter) (int64, error) {
+	return int64(len(r.sorted(filter))), nil
+}
+
+func (r *memoryFileRepository) UpdateMetadata(ctx context.Context, file *domain.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.files[file.ID]
+	if !ok {
+		return domain.ErrFileNotFound
+	}
+	if stored.Version != file.Version {
+		return domain.ErrFileModified
+	}
+	file.Version++
+	updated := *file
+	updated.Path = nil
+	r.files[file.ID] = &updated
+	return nil
+}
+
+func (r *memoryFileRepository) SetChecksum(ctx context.Context, id, sha256 string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, ok := r.files[id]
+	if !ok {
+		return domain.ErrFileNotFound
+	}
+	file.SHA256 = sha256
+	return nil
+}
+
+func (r *memoryFileRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.files[id]; !ok {
+		return domain.ErrFileNotFound
+	}
+	delete(r.files, id)
+	delete(r.contents, id)
+	return nil
+}
+
+func (r *memoryFileRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.files[id]
+	return ok, nil
+}
+
+func (r *memoryFileRepository) Stats(ctx context.Context) (*domain.ArchiveStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := &domain.ArchiveStats{}
+	for id := range r.files {
+		stats.Files++
+		stats.Bytes += int64(len(r.contents[id]))
+	}
+	return stats, nil
+}
+
+// memoryCollectionRepository is a domain.CollectionRepository kept in
+// memory.
+type memoryCollectionRepository struct {
+	mu          sync.Mutex
+	collections map[string]*domain.Collection
+	nextID      int
+}
+
+func newMemoryCollectionRepository() *memoryCollectionRepository {
+	return &memoryCollectionRepository{collections: map[string]*domain.Collection{}}
+}
+
+func (r *memoryCollectionRepository) nameTaken(c *domain.Collection) bool {
+	for _, other := range r.collections {
+		if other.ID != c.ID && other.ParentID == c.ParentID && strings.EqualFold(other.Name, c.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryCollectionRepository) Save(ctx context.Context, collection *domain.Collection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTaken(collection) {
+		return domain.ErrCollectionNameTaken
+	}
+	r.nextID++
+	collection.ID = fmt.Sprintf("c%03d", r.nextID)
+	stored := *collection
+	r.collections[collection.ID] = &stored
+	return nil
+}
+
+func (r *memoryCollectionRepository) Update(ctx context.Context, collection *domain.Collection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collections[collection.ID]; !ok {
+		return domain.ErrCollectionNotFound
+	}
+	if r.nameTaken(collection) {
+		return domain.ErrCollectionNameTaken
+	}
+	stored := *collection
+	r.collections[collection.ID] = &stored
+	return nil
+}
+
+func (r *memoryCollectionRepository) FindByID(ctx context.Context, id string) (*domain.Collection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	collection, ok := r.collections[id]
+	if !ok {
+		return nil, domain.ErrCollectionNotFound
+	}
+	found := *collection
+	return &found, nil
+}
+
+func (r *memoryCollectionRepository) FindByIDs(ctx context.Context, ids []string) ([]*domain.Collection, error) {
+	var collections []*domain.Collection
+	for _, id := range ids {
+		if collection, err := r.FindByID(ctx, id); err == nil {
+			collections = append(collections, collection)
+		}
+	}
+	return collections, nil
+}
+
+func (r *memoryCollectionRepository) FindChildren(ctx context.Context, parentID string) ([]*domain.Collection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var children []*domain.Collection
+	for _, collection := range r.collections {
+		if collection.ParentID == parentID {
+			found := *collection
+			children = append(children, &found)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return strings.ToLower(children[i].Name) < strings.ToLower(children[j].Name)
+	})
+	return children, nil
+}
+
+func (r *memoryCollectionRepository) FindDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for _, collection := range r.collections {
+		if collection.ID != id && collection.Contains(id) {
+			ids = append(ids, collection.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryCollectionRepository) MoveDescendants(ctx context.Context, id string, oldAncestors, newAncestors []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, collection := range r.collections {
+		if collection.ID != id && collection.Contains(id) {
+			collection.Ancestors = append(append([]string{}, newAncestors...), collection.Ancestors[len(oldAncestors):]...)
+		}
+	}
+	return nil
+}
+
+func (r *memoryCollectionRepository) Lock(ctx context.Context, id string) error {
+	_, err := r.FindByID(ctx, id)
+	return err
+}
+
+func (r *memoryCollectionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collections[id]; !ok {
+		return domain.ErrCollectionNotFound
+	}
+	delete(r.collections, id)
+	return nil
+}
+
+// inlineTransactor runs functions without a transaction.
+type inlineTransactor struct{}
+
+func (inlineTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// memoryOutboxRepository follows the outbox semantics of the MongoDB
+// repository.
+type memoryOutboxRepository struct {
+	mu      sync.Mutex
+	entries map[string]*domain.OutboxEntry
+	// ids lists the entries in the order they were added
+	ids []string
+	// failCommit makes Commit fail, as if MongoDB was unreachable
+	failCommit bool
+}
+
+func newMemoryOutboxRepository() *memoryOutboxRepository {
+	return &memoryOutboxRepository{entries: map[string]*domain.OutboxEntry{}}
+}
+
+func (r *memoryOutboxRepository) add(event domain.Event, status domain.OutboxStatus) *domain.OutboxEntry {
+	now := time.Now().UTC()
+	entry := &domain.OutboxEntry{Event: event, Status: status, DeliveredTo: []string{}, NextAttemptAt: now, CreatedAt: now}
+	r.entries[event.ID] = entry
+	r.ids = append(r.ids, event.ID)
+	return entry
+}
+
+func (r *memoryOutboxRepository) Stage(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(event, domain.OutboxStaged)
+	return nil
+}
+
+func (r *memoryOutboxRepository) Commit(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failCommit {
+		return errors.New("connection refused")
+	}
+	entry, ok := r.entries[event.ID]
+	switch {
+	case !ok:
+		entry = r.add(event, domain.OutboxReady)
+	case entry.Status != domain.OutboxStaged:
+		return nil
+	}
+	entry.Status = domain.OutboxReady
+	entry.Event.Data = event.Data
+	entry.NextAttemptAt = time.Now().UTC()
+	return nil
+}
+
+func (r *memoryOutboxRepository) Discard(ctx context.Context, eventID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.entries[eventID]; ok && entry.Status == domain.OutboxStaged {
+		delete(r.entries, eventID)
+		for i, id := range r.ids {
+			if id == eventID {
+				r.ids = append(r.ids[:i], r.ids[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// list returns copies of the entries matching keep, in the order they were
+// added.
+func (r *memoryOutboxRepository) list(keep func(entry *domain.OutboxEntry) bool) []*domain.OutboxEntry {
+	var entries []*domain.OutboxEntry
+	for _, id := range r.ids {
+		if entry, ok := r.entries[id]; ok && keep(entry) {
+			found := *entry
+			found.DeliveredTo = append([]string{}, entry.DeliveredTo...)
+			entries = append(entries, &found)
+		}
+	}
+	return entries
+}
+
+func (r *memoryOutboxRepository) FindStaged(ctx context.Context, olderThan time.Time, limit int64) ([]*domain.OutboxEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.list(func(entry *domain.OutboxEntry) bool {
+		return entry.Status == domain.OutboxStaged && entry.CreatedAt.Before(olderThan)
+	})
+	return entries[:min(len(entries), int(limit))], nil
+}
+
+func (r *memoryOutboxRepository) ClaimReady(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.OutboxEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.list(func(entry *domain.OutboxEntry) bool {
+		return entry.Status == domain.OutboxReady && !entry.NextAttemptAt.After(now)
+	})
+	entries = entries[:min(len(entries), limit)]
+	for _, entry := range entries {
+		r.entries[entry.Event.ID].NextAttemptAt = now.Add(lease)
+	}
+	return entries, nil
+}
+
+func (r *memoryOutboxRepository) MarkDelivered(ctx context.Context, eventID, sink string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry := r.entries[eventID]; !entry.Delivered(sink) {
+		entry.DeliveredTo = append(entry.DeliveredTo, sink)
+	}
+	return nil
+}
+
+func (r *memoryOutboxRepository) MarkPublished(ctx context.Context, eventID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[eventID].Status = domain.OutboxPublished
+	return nil
+}
+
+func (r *memoryOutboxRepository) Reschedule(ctx context.Context, eventID string, attempts int, next time.Time, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := r.entries[eventID]
+	entry.Attempts, entry.NextAttemptAt, entry.LastError = attempts, next, lastError
+	return nil
+}
+
+// events returns the events in status, in the order they were added.
+func (r *memoryOutboxRepository) events(status domain.OutboxStatus) []domain.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.Event
+	for _, entry := range r.list(func(entry *domain.OutboxEntry) bool { return entry.Status == status }) {
+		events = append(events, entry.Event)
+	}
+	return events
+}
+
+// age backdates every entry by d, as if it had been added d earlier.
+func (r *memoryOutboxRepository) age(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries {
+		entry.CreatedAt = entry.CreatedAt.Add(-d)
+		entry.NextAttemptAt = entry.NextAttemptAt.Add(-d)
+	}
+}
+
+// memoryUserRepository is a domain.UserRepository kept in memory.
+type memoryUserRepository struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{users: map[string]*domain.User{}}
+}
+
+func (r *memoryUserRepository) Save(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.Name]; ok {
+		return domain.ErrUserNameTaken
+	}
+	user.ID = fmt.Sprintf("u%03d", len(r.users)+1)
+	stored := *user
+	r.users[user.Name] = &stored
+	return nil
+}
+
+func (r *memoryUserRepository) FindByTokenHash(ctx context.Context, hash string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.TokenHash == hash {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *memoryUserRepository) DeleteByName(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[name]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(r.users, name)
+	return nil
+}
+
+// memoryExportRepository is a domain.ExportRepository kept in memory.
+type memoryExportRepository struct {
+	mu       sync.Mutex
+	exports  map[string]*domain.Export
+	contents map[string][]byte
+}
+
+func newMemoryExportRepository() *memoryExportRepository {
+	return &memoryExportRepository{exports: map[string]*domain.Export{}, contents: map[string][]byte{}}
+}
+
+func (r *memoryExportRepository) Save(ctx context.Context, export *domain.Export) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export.ID = fmt.Sprintf("e%03d", len(r.exports)+1)
+	stored := *export
+	r.exports[export.ID] = &stored
+	return nil
+}
+
+func (r *memoryExportRepository) Update(ctx context.Context, export *domain.Export) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.exports[export.ID]; !ok {
+		return domain.ErrExportNotFound
+	}
+	stored := *export
+	r.exports[export.ID] = &stored
+	return nil
+}
+
+func (r *memoryExportRepository) FindByID(ctx context.Context, id string) (*domain.Export, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export, ok := r.exports[id]
+	if !ok {
+		return nil, domain.ErrExportNotFound
+	}
+	found := *export
+	return &found, nil
+}
+
+func (r *memoryExportRepository) FindExpired(ctx context.Context, now time.Time) ([]*domain.Export, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []*domain.Export
+	for _, export := range r.exports {
+		if export.Expired(now) {
+			found := *export
+			expired = append(expired, &found)
+		}
+	}
+	return expired, nil
+}
+
+func (r *memoryExportRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.exports[id]; !ok {
+		return domain.ErrExportNotFound
+	}
+	delete(r.exports, id)
+	delete(r.contents, id)
+	return nil
+}
+
+func (r *memoryExportRepository) WriteContent(ctx context.Context, id string, content io.Reader) (int64, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contents[id] = data
+	return int64(len(data)), nil
+}
+
+func (r *memoryExportRepository) OpenContent(ctx context.Context, id string) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.contents[id]
+	if !ok {
+		return nil, domain.ErrExportNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// memoryStorageInspector is a domain.StorageInspector over fixed issues.
+// Orphans listed in finalized got their files document after the check
+// listed them, so their chunks are kept.
+type memoryStorageInspector struct {
+	orphans    []*domain.StorageIssue
+	incomplete []*domain.StorageIssue
+	finalized  map[string]bool
+	deleted    []string
+}
+
+func (i *memoryStorageInspector) FindOrphanChunks(ctx context.Context) ([]*domain.StorageIssue, error) {
+	return i.orphans, nil
+}
+
+func (i *memoryStorageInspector) FindIncompleteFiles(ctx context.Context) ([]*domain.StorageIssue, error) {
+	return i.incomplete, nil
+}
+
+func (i *memoryStorageInspector) DeleteOrphanChunks(ctx context.Context, fileID string) (int64, error) {
+	if i.finalized[fileID] {
+		return 0, nil
+	}
+	for _, orphan := range i.orphans {
+		if orphan.FileID == fileID {
+			i.deleted = append(i.deleted, fileID)
+			return orphan.Chunks, nil
+		}
+	}
+	return 0, nil
+}
@@ -1,10 +1,30 @@
 package usecases
 
-import "github.com/yhartanto178dev/api-archiven-v2/domain"
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
 
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// GetAllFilesQuery lists files either by page number or, in cursor mode, from
+// an opaque cursor returned by a previous call. An empty cursor starts at the
+// newest file.
 type GetAllFilesQuery struct {
 	Page    int
 	PerPage int
+
+	CursorMode bool
+	Cursor     string
+	// IncludeTotal counts all files in cursor mode; page mode always does.
+	IncludeTotal bool
+
+	// CollectionID limits the listing to one collection and, with
+	// Recursive, the collections below it.
+	CollectionID string
+	Recursive    bool
 }
 
 type PaginatedFiles struct {
@@ -13,17 +33,26 @@ type PaginatedFiles struct {
 	Page       int
 	PerPage    int
 	TotalPages int64
+
+	// NextCursor and PrevCursor are set in cursor mode when there are more
+	// files in that direction.
+	NextCursor string
+	PrevCursor string
 }
 
 type GetAllFilesUseCase struct {
-	repo domain.FileRepository
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
 }
 
-func NewGetAllFilesUseCase(repo domain.FileRepository) *GetAllFilesUseCase {
-	return &GetAllFilesUseCase{repo: repo}
+func NewGetAllFilesUseCase(repo domain.FileRepository, collections domain.CollectionRepository) *GetAllFilesUseCase {
+	return &GetAllFilesUseCase{repo: repo, collections: collections}
 }
 
-func (uc *GetAllFilesUseCase) Execute(query GetAllFilesQuery) (*PaginatedFiles, error) {
+func (uc *GetAllFilesUseCase) Execute(ctx context.Context, query GetAllFilesQuery) (_ *PaginatedFiles, err error) {
+	ctx, span := startSpan(ctx, "GetAllFilesUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
 	// Validate pagination parameters
 	if query.Page < 1 {
 		query.Page = 1
@@ -32,15 +61,48 @@ func (uc *GetAllFilesUseCase) Execute(query GetAllFilesQuery) (*PaginatedFiles,
 		query.PerPage = 10
 	}
 
+	filter, err := uc.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *PaginatedFiles
+	if query.CursorMode {
+		result, err = uc.executeCursor(ctx, query, filter)
+	} else {
+		result, err = uc.executePage(ctx, query, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := withFilePaths(ctx, uc.collections, result.Files...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// filter scopes the listing to the requested collection, if any.
+func (uc *GetAllFilesUseCase) filter(ctx context.Context, query GetAllFilesQuery) (domain.FileFilter, error) {
+	if query.CollectionID == "" {
+		return domain.FileFilter{}, nil
+	}
+	ids, err := collectionScope(ctx, uc.collections, query.CollectionID, query.Recursive)
+	if err != nil {
+		return domain.FileFilter{}, err
+	}
+	return domain.FileFilter{CollectionIDs: ids}, nil
+}
+
+func (uc *GetAllFilesUseCase) executePage(ctx context.Context, query GetAllFilesQuery, filter domain.FileFilter) (*PaginatedFiles, error) {
 	skip := int64((query.Page - 1) * query.PerPage)
 	limit := int64(query.PerPage)
 
-	files, err := uc.repo.FindAll(skip, limit)
+	files, err := uc.repo.FindAll(ctx, filter, skip, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := uc.repo.Count()
+	total, err := uc.repo.Count(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -58,3 +120,89 @@ func (uc *GetAllFilesUseCase) Execute(query GetAllFilesQuery) (*PaginatedFiles,
 		TotalPages: totalPages,
 	}, nil
 }
+
+// executeCursor fetches one file more than requested to learn whether the
+// listing continues in the direction of travel. Coming from a cursor means
+// the listing also continues in the other direction.
+func (uc *GetAllFilesUseCase) executeCursor(ctx context.Context, query GetAllFilesQuery, filter domain.FileFilter) (*PaginatedFiles, error) {
+	pageQuery := domain.FilePageQuery{Filter: filter, Limit: int64(query.PerPage) + 1}
+	var position *domain.FileCursor
+	backwards := false
+	if query.Cursor != "" {
+		token, err := decodeFileCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		position = &domain.FileCursor{UploadDate: time.Unix(0, token.Time).UTC(), ID: token.ID}
+		if token.Before {
+			pageQuery.Before, backwards = position, true
+		} else {
+			pageQuery.After = position
+		}
+	}
+
+	files, err := uc.repo.FindPage(ctx, pageQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(files) > query.PerPage
+	if more {
+		if backwards {
+			files = files[1:]
+		} else {
+			files = files[:query.PerPage]
+		}
+	}
+	hasNext, hasPrev := more, query.Cursor != ""
+	if backwards {
+		hasNext, hasPrev = true, more
+	}
+
+	// An empty page past the end still leads back to where it came from
+	first, last := position, position
+	if len(files) > 0 {
+		first = &domain.FileCursor{UploadDate: files[0].UploadDate, ID: files[0].ID}
+		last = &domain.FileCursor{UploadDate: files[len(files)-1].UploadDate, ID: files[len(files)-1].ID}
+	}
+
+	result := &PaginatedFiles{Files: files, PerPage: query.PerPage}
+	if hasNext && last != nil {
+		result.NextCursor = encodeFileCursor(last, false)
+	}
+	if hasPrev && first != nil {
+		result.PrevCursor = encodeFileCursor(first, true)
+	}
+
+	if query.IncludeTotal {
+		if result.Total, err = uc.repo.Count(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// fileCursorToken is the content of a cursor. Clients treat cursors as
+// opaque, so the format may change between releases.
+type fileCursorToken struct {
+	Time   int64  `json:"t"`
+	ID     string `json:"i"`
+	Before bool   `json:"b,omitempty"`
+}
+
+func encodeFileCursor(position *domain.FileCursor, before bool) string {
+	data, _ := json.Marshal(fileCursorToken{Time: position.UploadDate.UnixNano(), ID: position.ID, Before: before})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFileCursor(cursor string) (*fileCursorToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var token fileCursorToken
+	if err := json.Unmarshal(data, &token); err != nil || token.ID == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &token, nil
+}
@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// listedFiles stores n files, three per upload second so that the listing
+// has to break ties by ID, and returns their IDs in listing order.
+func listedFiles(t *testing.T, n int) (*memoryFileRepository, []string) {
+	t.Helper()
+	repo := newMemoryFileRepository()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		file := &domain.File{Name: "file.pdf", UploadDate: start.Add(time.Duration(i/3) * time.Second)}
+		if err := repo.Save(context.Background(), file, strings.NewReader("")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []string
+	for _, file := range repo.sorted(domain.FileFilter{}) {
+		ids = append(ids, file.ID)
+	}
+	return repo, ids
+}
+
+func pageIDs(result *PaginatedFiles) []string {
+	ids := make([]string, len(result.Files))
+	for i, file := range result.Files {
+		ids[i] = file.ID
+	}
+	return ids
+}
+
+func TestCursorPagingVisitsEveryFileOnce(t *testing.T) {
+	repo, want := listedFiles(t, 25)
+	uc := NewGetAllFilesUseCase(repo, nil)
+	ctx := context.Background()
+
+	var pages []*PaginatedFiles
+	query := GetAllFilesQuery{PerPage: 10, CursorMode: true}
+	for {
+		result, err := uc.Execute(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, result)
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+
+	var got []string
+	for _, page := range pages {
+		got = append(got, pageIDs(page)...)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("forward pages listed %v, want %v", got, want)
+	}
+	if len(pages) != 3 || pages[0].PrevCursor != "" {
+		t.Fatalf("got %d pages, first with previous cursor %q", len(pages), pages[0].PrevCursor)
+	}
+
+	// Walking back from the last page returns the same pages
+	for i := len(pages) - 1; i > 0; i-- {
+		result, err := uc.Execute(ctx, GetAllFilesQuery{PerPage: 10, CursorMode: true, Cursor: pages[i].PrevCursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := pageIDs(result), pageIDs(pages[i-1]); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("page %d backwards: got %v, want %v", i-1, got, want)
+		}
+		if (result.PrevCursor == "") != (i-1 == 0) {
+			t.Errorf("page %d backwards: previous cursor %q", i-1, result.PrevCursor)
+		}
+	}
+}
+
+func TestCursorPagingIgnoresNewUploads(t *testing.T) {
+	repo, want := listedFiles(t, 6)
+	uc := NewGetAllFilesUseCase(repo, nil)
+	ctx := context.Background()
+
+	first, err := uc.Execute(ctx, GetAllFilesQuery{PerPage: 3, CursorMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An upload between pages would shift offset-based pages by one
+	repo.Save(ctx, &domain.File{Name: "new.pdf", UploadDate: time.Now()}, strings.NewReader(""))
+
+	second, err := uc.Execute(ctx, GetAllFilesQuery{PerPage: 3, CursorMode: true, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pageIDs(second); strings.Join(got, ",") != strings.Join(want[3:], ",") {
+		t.Fatalf("second page: got %v, want %v", got, want[3:])
+	}
+}
+
+func TestCursorPagingRejectsInvalidCursors(t *testing.T) {
+	repo, _ := listedFiles(t, 1)
+	uc := NewGetAllFilesUseCase(repo, nil)
+
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		_, err := uc.Execute(context.Background(), GetAllFilesQuery{PerPage: 10, CursorMode: true, Cursor: cursor})
+		if err != domain.ErrInvalidCursor {
+			t.Errorf("cursor %q: got %v, want %v", cursor, err, domain.ErrInvalidCursor)
+		}
+	}
+}
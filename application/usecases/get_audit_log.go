@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetAuditLogQuery struct {
+	Filter  domain.AuditFilter
+	Page    int
+	PerPage int
+}
+
+type PaginatedAuditEntries struct {
+	Entries    []*domain.AuditEntry
+	Total      int64
+	Page       int
+	PerPage    int
+	TotalPages int64
+}
+
+type GetAuditLogUseCase struct {
+	repo domain.AuditRepository
+}
+
+func NewGetAuditLogUseCase(repo domain.AuditRepository) *GetAuditLogUseCase {
+	return &GetAuditLogUseCase{repo: repo}
+}
+
+func (uc *GetAuditLogUseCase) Execute(ctx context.Context, query GetAuditLogQuery) (*PaginatedAuditEntries, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 || query.PerPage > 100 {
+		query.PerPage = 10
+	}
+
+	skip := int64((query.Page - 1) * query.PerPage)
+	limit := int64(query.PerPage)
+
+	entries, err := uc.repo.FindAll(ctx, query.Filter, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.repo.Count(ctx, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := total / int64(query.PerPage)
+	if total%int64(query.PerPage) != 0 {
+		totalPages++
+	}
+
+	return &PaginatedAuditEntries{
+		Entries:    entries,
+		Total:      total,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+		TotalPages: totalPages,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetCollectionsUseCase struct {
+	repo domain.CollectionRepository
+}
+
+func NewGetCollectionsUseCase(repo domain.CollectionRepository) *GetCollectionsUseCase {
+	return &GetCollectionsUseCase{repo: repo}
+}
+
+// Get returns the collection with its path.
+func (uc *GetCollectionsUseCase) Get(ctx context.Context, id string) (_ *domain.Collection, err error) {
+	ctx, span := startSpan(ctx, "GetCollectionsUseCase.Get")
+	defer func() { endSpan(span, err) }()
+
+	collection, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := withPaths(ctx, uc.repo, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// Children returns the collections directly below parentID, or the top-level
+// collections when parentID is empty, with their paths.
+func (uc *GetCollectionsUseCase) Children(ctx context.Context, parentID string) (_ []*domain.Collection, err error) {
+	ctx, span := startSpan(ctx, "GetCollectionsUseCase.Children")
+	defer func() { endSpan(span, err) }()
+
+	if parentID != "" {
+		if _, err := uc.repo.FindByID(ctx, parentID); err != nil {
+			return nil, err
+		}
+	}
+	children, err := uc.repo.FindChildren(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := withPaths(ctx, uc.repo, children...); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// collectionScope returns the IDs of the collection and, with recursive, of
+// the collections below it, for filtering files by collection.
+func collectionScope(ctx context.Context, repo domain.CollectionRepository, id string, recursive bool) ([]string, error) {
+	collection, err := repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{collection.ID}
+	if recursive {
+		descendants, err := repo.FindDescendantIDs(ctx, collection.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, descendants...)
+	}
+	return ids, nil
+}
+
+// withPaths fills in the path of each collection, looking up the names of
+// ancestors that are not among them.
+func withPaths(ctx context.Context, repo domain.CollectionRepository, collections ...*domain.Collection) error {
+	names := make(map[string]string, len(collections))
+	for _, c := range collections {
+		names[c.ID] = c.Name
+	}
+	var missing []string
+	for _, c := range collections {
+		for _, id := range c.Ancestors {
+			if _, ok := names[id]; !ok {
+				names[id] = ""
+				missing = append(missing, id)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		ancestors, err := repo.FindByIDs(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for _, a := range ancestors {
+			names[a.ID] = a.Name
+		}
+	}
+
+	for _, c := range collections {
+		c.Path = make([]domain.Breadcrumb, 0, len(c.Ancestors)+1)
+		for _, id := range c.Ancestors {
+			c.Path = append(c.Path, domain.Breadcrumb{ID: id, Name: names[id]})
+		}
+		c.Path = append(c.Path, domain.Breadcrumb{ID: c.ID, Name: c.Name})
+	}
+	return nil
+}
+
+// withFilePaths fills in the path of the files that are in a collection.
+func withFilePaths(ctx context.Context, repo domain.CollectionRepository, files ...*domain.File) error {
+	seen := map[string]bool{}
+	var ids []string
+	for _, f := range files {
+		if f.CollectionID != "" && !seen[f.CollectionID] {
+			seen[f.CollectionID] = true
+			ids = append(ids, f.CollectionID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	collections, err := repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if err := withPaths(ctx, repo, collections...); err != nil {
+		return err
+	}
+	byID := make(map[string]*domain.Collection, len(collections))
+	for _, c := range collections {
+		byID[c.ID] = c
+	}
+	for _, f := range files {
+		if c := byID[f.CollectionID]; c != nil {
+			f.Path = c.Path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetExportUseCase struct {
+	exports domain.ExportRepository
+}
+
+func NewGetExportUseCase(exports domain.ExportRepository) *GetExportUseCase {
+	return &GetExportUseCase{exports: exports}
+}
+
+// Execute returns the export; expired exports are reported as not found even
+// before the cleanup has removed them.
+func (uc *GetExportUseCase) Execute(ctx context.Context, id string) (_ *domain.Export, err error) {
+	ctx, span := startSpan(ctx, "GetExportUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	export, err := uc.exports.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export.Expired(time.Now()) {
+		return nil, domain.ErrExportNotFound
+	}
+	return export, nil
+}
@@ -1,19 +1,32 @@
 package usecases
 
 import (
+	"context"
 	"io"
 
 	"github.com/yhartanto178dev/api-archiven-v2/domain"
 )
 
 type GetFileUseCase struct {
-	repo domain.FileRepository
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
 }
 
-func NewGetFileUseCase(repo domain.FileRepository) *GetFileUseCase {
-	return &GetFileUseCase{repo: repo}
+func NewGetFileUseCase(repo domain.FileRepository, collections domain.CollectionRepository) *GetFileUseCase {
+	return &GetFileUseCase{repo: repo, collections: collections}
 }
 
-func (uc *GetFileUseCase) Execute(id string) (*domain.File, io.ReadCloser, error) {
-	return uc.repo.FindByID(id)
+func (uc *GetFileUseCase) Execute(ctx context.Context, id string) (_ *domain.File, _ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "GetFileUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	file, content, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := withFilePaths(ctx, uc.collections, file); err != nil {
+		content.Close()
+		return nil, nil, err
+	}
+	return file, content, nil
 }
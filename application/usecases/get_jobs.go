@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+var jobStatuses = []domain.JobStatus{domain.JobPending, domain.JobRunning, domain.JobSucceeded, domain.JobDead}
+
+type GetJobsQuery struct {
+	Filter  domain.JobFilter
+	Page    int
+	PerPage int
+}
+
+type PaginatedJobs struct {
+	Jobs       []*domain.Job
+	Total      int64
+	Page       int
+	PerPage    int
+	TotalPages int64
+}
+
+type GetJobsUseCase struct {
+	repo domain.JobRepository
+}
+
+func NewGetJobsUseCase(repo domain.JobRepository) *GetJobsUseCase {
+	return &GetJobsUseCase{repo: repo}
+}
+
+// Execute lists jobs, newest first.
+func (uc *GetJobsUseCase) Execute(ctx context.Context, query GetJobsQuery) (*PaginatedJobs, error) {
+	if err := validateJobStatus(query.Filter.Status); err != nil {
+		return nil, err
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 || query.PerPage > 100 {
+		query.PerPage = 10
+	}
+
+	skip := int64((query.Page - 1) * query.PerPage)
+	limit := int64(query.PerPage)
+
+	jobs, err := uc.repo.FindAll(ctx, query.Filter, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.repo.Count(ctx, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := total / int64(query.PerPage)
+	if total%int64(query.PerPage) != 0 {
+		totalPages++
+	}
+
+	return &PaginatedJobs{
+		Jobs:       jobs,
+		Total:      total,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (uc *GetJobsUseCase) Get(ctx context.Context, id string) (*domain.Job, error) {
+	return uc.repo.FindByID(ctx, id)
+}
+
+func validateJobStatus(status domain.JobStatus) error {
+	if status == "" {
+		return nil
+	}
+	names := make([]string, len(jobStatuses))
+	for i, known := range jobStatuses {
+		if status == known {
+			return nil
+		}
+		names[i] = string(known)
+	}
+	return domain.ErrInvalidRequest.WithFields(domain.FieldError{
+		Field:   "status",
+		Code:    "one_of",
+		Message: "status must be one of: " + strings.Join(names, ", "),
+		Params:  []string{"status", strings.Join(names, ", ")},
+	})
+}
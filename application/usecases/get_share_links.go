@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetShareLinksUseCase struct {
+	shareRepo domain.ShareLinkRepository
+}
+
+func NewGetShareLinksUseCase(shareRepo domain.ShareLinkRepository) *GetShareLinksUseCase {
+	return &GetShareLinksUseCase{shareRepo: shareRepo}
+}
+
+func (uc *GetShareLinksUseCase) Execute(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	return uc.shareRepo.FindByFileID(ctx, fileID)
+}
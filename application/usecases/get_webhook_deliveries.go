@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetWebhookDeliveriesQuery struct {
+	WebhookID string
+	Page      int
+	PerPage   int
+}
+
+type PaginatedWebhookDeliveries struct {
+	Deliveries []*domain.WebhookDelivery
+	Total      int64
+	Page       int
+	PerPage    int
+	TotalPages int64
+}
+
+type GetWebhookDeliveriesUseCase struct {
+	webhookRepo  domain.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+}
+
+func NewGetWebhookDeliveriesUseCase(
+	webhookRepo domain.WebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+) *GetWebhookDeliveriesUseCase {
+	return &GetWebhookDeliveriesUseCase{webhookRepo: webhookRepo, deliveryRepo: deliveryRepo}
+}
+
+func (uc *GetWebhookDeliveriesUseCase) Execute(ctx context.Context, query GetWebhookDeliveriesQuery) (*PaginatedWebhookDeliveries, error) {
+	if _, err := uc.webhookRepo.FindByID(ctx, query.WebhookID); err != nil {
+		return nil, err
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 || query.PerPage > 100 {
+		query.PerPage = 10
+	}
+
+	skip := int64((query.Page - 1) * query.PerPage)
+	limit := int64(query.PerPage)
+
+	deliveries, err := uc.deliveryRepo.FindByWebhookID(ctx, query.WebhookID, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := uc.deliveryRepo.CountByWebhookID(ctx, query.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := total / int64(query.PerPage)
+	if total%int64(query.PerPage) != 0 {
+		totalPages++
+	}
+
+	return &PaginatedWebhookDeliveries{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       query.Page,
+		PerPage:    query.PerPage,
+		TotalPages: totalPages,
+	}, nil
+}
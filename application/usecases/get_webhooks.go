@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type GetWebhooksUseCase struct {
+	repo domain.WebhookRepository
+}
+
+func NewGetWebhooksUseCase(repo domain.WebhookRepository) *GetWebhooksUseCase {
+	return &GetWebhooksUseCase{repo: repo}
+}
+
+func (uc *GetWebhooksUseCase) Execute(ctx context.Context) ([]*domain.Webhook, error) {
+	return uc.repo.FindAll(ctx)
+}
@@ -0,0 +1,86 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// IdempotentResponse is the response of a request made with an idempotency
+// key, kept so that retries of the request get it again.
+type IdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+type IdempotencyUseCase struct {
+	repo  domain.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewIdempotencyUseCase keeps completed responses for ttl. A key reserved by
+// a request that never completes, e.g. because the instance crashed, is free
+// again after lease.
+func NewIdempotencyUseCase(repo domain.IdempotencyRepository, ttl, lease time.Duration) *IdempotencyUseCase {
+	return &IdempotencyUseCase{repo: repo, ttl: ttl, lease: lease}
+}
+
+// Begin reserves key for the request with the given fingerprint. It returns
+// nil when the request should be processed, or the stored response when the
+// request was already completed. A key used for a different request, or for
+// one still being processed, is rejected.
+func (uc *IdempotencyUseCase) Begin(ctx context.Context, key, fingerprint string) (_ *IdempotentResponse, err error) {
+	ctx, span := startSpan(ctx, "IdempotencyUseCase.Begin")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	existing, err := uc.repo.Reserve(ctx, &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uc.lease),
+	})
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if !existing.Completed {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return &IdempotentResponse{Status: existing.Status, Header: existing.Header, Body: existing.Body}, nil
+}
+
+// Complete stores the response of the request that reserved key, to be
+// replayed for the configured TTL. It returns ErrIdempotencyInProgress when
+// the reservation was lost, i.e. its lease ran out and another request
+// reserved the key.
+func (uc *IdempotencyUseCase) Complete(ctx context.Context, key, fingerprint string, response IdempotentResponse) (err error) {
+	ctx, span := startSpan(ctx, "IdempotencyUseCase.Complete")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return uc.repo.Complete(ctx, &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Completed:   true,
+		Status:      response.Status,
+		Header:      response.Header,
+		Body:        response.Body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uc.ttl),
+	})
+}
+
+// Release frees key after a request that should not be replayed, so that the
+// client can retry it.
+func (uc *IdempotencyUseCase) Release(ctx context.Context, key string) (err error) {
+	ctx, span := startSpan(ctx, "IdempotencyUseCase.Release")
+	defer func() { endSpan(span, err) }()
+
+	return uc.repo.Release(ctx, key)
+}
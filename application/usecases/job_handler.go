@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// jobHandlerFunc is a domain.JobHandler that decodes the payload into a T.
+type jobHandlerFunc[T any] struct {
+	jobType string
+	handle  func(ctx context.Context, payload T) error
+}
+
+// NewJobHandler returns the handler of jobType that calls handle with the job
+// payload decoded into a T, the type it was enqueued with.
+func NewJobHandler[T any](jobType string, handle func(ctx context.Context, payload T) error) domain.JobHandler {
+	return &jobHandlerFunc[T]{jobType: jobType, handle: handle}
+}
+
+func (h *jobHandlerFunc[T]) Type() string {
+	return h.jobType
+}
+
+func (h *jobHandlerFunc[T]) Handle(ctx context.Context, job *domain.Job) error {
+	var payload T
+	data, err := json.Marshal(job.Payload)
+	if err == nil {
+		err = json.Unmarshal(data, &payload)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %s job payload", h.jobType)
+	}
+	return h.handle(ctx, payload)
+}
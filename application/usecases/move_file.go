@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type MoveFileCommand struct {
+	FileID string
+	// CollectionID is empty to take the file out of its collection.
+	CollectionID string
+	// IfMatch is checked as in UpdateFileCommand.
+	IfMatch []int64
+}
+
+type MoveFileUseCase struct {
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
+	outbox      domain.OutboxRepository
+	tx          domain.Transactor
+}
+
+func NewMoveFileUseCase(repo domain.FileRepository, collections domain.CollectionRepository, outbox domain.OutboxRepository, tx domain.Transactor) *MoveFileUseCase {
+	return &MoveFileUseCase{repo: repo, collections: collections, outbox: outbox, tx: tx}
+}
+
+// Execute assigns the file to a collection and records a file.updated event.
+// The collection is locked while the file is saved, so it cannot be deleted
+// at the same time. It returns the file and the fields that changed.
+func (uc *MoveFileUseCase) Execute(ctx context.Context, command MoveFileCommand) (_ *domain.File, _ []domain.AuditChange, err error) {
+	ctx, span := startSpan(ctx, "MoveFileUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	file, content, err := uc.repo.FindByID(ctx, command.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content.Close()
+	if err := checkVersion(file, command.IfMatch); err != nil {
+		return nil, nil, err
+	}
+
+	if command.CollectionID != "" {
+		if _, err := uc.collections.FindByID(ctx, command.CollectionID); err != nil {
+			return nil, nil, err
+		}
+	}
+	moved := *file
+	moved.CollectionID = command.CollectionID
+	changes := domain.FileChanges(file, &moved)
+	if len(changes) > 0 {
+		saved := moved
+		err := uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			// A retried transaction starts over from the unsaved file
+			saved = moved
+			if saved.CollectionID != "" {
+				if err := uc.collections.Lock(ctx, saved.CollectionID); err != nil {
+					return err
+				}
+			}
+			return saveFileChanges(ctx, uc.repo, uc.outbox, &saved)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		moved = saved
+	}
+
+	if err := withFilePaths(ctx, uc.collections, &moved); err != nil {
+		return nil, nil, err
+	}
+	return &moved, changes, nil
+}
@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commitEvent marks event ready for dispatch once the change it describes
+// has been made. The change cannot be undone at that point, so a failure is
+// recorded on the span instead of being returned; the event stays staged and
+// RecoverStagedEventsUseCase commits it later.
+func commitEvent(ctx context.Context, outbox domain.OutboxRepository, event domain.Event) {
+	if err := outbox.Commit(ctx, event); err != nil {
+		trace.SpanFromContext(ctx).RecordError(errors.Wrapf(err, "failed to commit %s event", event.Type))
+	}
+}
@@ -0,0 +1,254 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+const testGrace = 20 * time.Minute
+
+func TestUploadStagesEventBeforeSaving(t *testing.T) {
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	upload := NewUploadFileUseCase(files, newMemoryCollectionRepository(), outbox)
+
+	var staged []domain.Event
+	content := &hookReader{Reader: strings.NewReader("content"), hook: func() {
+		staged = outbox.events(domain.OutboxStaged)
+	}}
+	file, err := upload.Execute(context.Background(), UploadFileCommand{Name: "report.pdf", Content: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staged) != 1 || staged[0].Type != domain.EventFileUploaded || staged[0].FileID != file.ID {
+		t.Fatalf("staged while saving: %+v", staged)
+	}
+	ready := outbox.events(domain.OutboxReady)
+	if len(ready) != 1 || ready[0].Data["name"] != "report.pdf" || ready[0].Data["size"] != int64(7) {
+		t.Fatalf("committed: %+v", ready)
+	}
+}
+
+func TestFailedUploadDiscardsEvent(t *testing.T) {
+	outbox := newMemoryOutboxRepository()
+	upload := NewUploadFileUseCase(newMemoryFileRepository(), newMemoryCollectionRepository(), outbox)
+
+	_, err := upload.Execute(context.Background(), UploadFileCommand{Name: "report.pdf", Content: failingReader{}})
+	if err == nil {
+		t.Fatal("upload succeeded")
+	}
+	if len(outbox.entries) != 0 {
+		t.Fatalf("entries left: %+v", outbox.entries)
+	}
+}
+
+func TestDeleteCommitsEvent(t *testing.T) {
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	ctx := context.Background()
+	file := &domain.File{Name: "report.pdf"}
+	if err := files.Save(ctx, file, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+	remove := NewDeleteFileUseCase(files, outbox)
+
+	if err := remove.Execute(ctx, file.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := remove.Execute(ctx, file.ID); err != domain.ErrFileNotFound {
+		t.Fatalf("second delete: %v", err)
+	}
+	ready := outbox.events(domain.OutboxReady)
+	if len(ready) != 1 || ready[0].Type != domain.EventFileDeleted || len(outbox.events(domain.OutboxStaged)) != 0 {
+		t.Fatalf("events: %+v", outbox.entries)
+	}
+}
+
+func TestUploadKeepsFileWhenCommitFails(t *testing.T) {
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	upload := NewUploadFileUseCase(files, newMemoryCollectionRepository(), outbox)
+	ctx := context.Background()
+
+	outbox.failCommit = true
+	file, err := upload.Execute(ctx, UploadFileCommand{Name: "report.pdf", Content: strings.NewReader("content")})
+	if err != nil {
+		t.Fatalf("the upload was stored but failed with %v", err)
+	}
+	if staged := outbox.events(domain.OutboxStaged); len(staged) != 1 {
+		t.Fatalf("staged: %+v", staged)
+	}
+
+	// Recovery commits the event once the upload can no longer be running
+	outbox.failCommit = false
+	outbox.age(testGrace + time.Minute)
+	if _, err := NewRecoverStagedEventsUseCase(outbox, files, testGrace).Execute(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	ready := outbox.events(domain.OutboxReady)
+	if len(ready) != 1 || ready[0].FileID != file.ID || ready[0].Data["name"] != "report.pdf" {
+		t.Fatalf("recovered: %+v", ready)
+	}
+}
+
+func TestSlowUploadKeepsEventDiscardedByRecovery(t *testing.T) {
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	upload := NewUploadFileUseCase(files, newMemoryCollectionRepository(), outbox)
+	recovery := NewRecoverStagedEventsUseCase(outbox, files, testGrace)
+	ctx := context.Background()
+
+	content := &hookReader{Reader: strings.NewReader("content"), hook: func() {
+		// Recovery leaves the event alone while the upload may be running
+		if _, err := recovery.Execute(ctx, 10); err != nil || len(outbox.events(domain.OutboxStaged)) != 1 {
+			t.Errorf("recovery during the upload: %v, %+v", err, outbox.entries)
+		}
+		// and discards it once the upload took longer than it may
+		outbox.age(testGrace + time.Minute)
+		if _, err := recovery.Execute(ctx, 10); err != nil || len(outbox.entries) != 0 {
+			t.Errorf("recovery after the grace period: %v, %+v", err, outbox.entries)
+		}
+	}}
+	file, err := upload.Execute(ctx, UploadFileCommand{Name: "report.pdf", Content: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := outbox.events(domain.OutboxReady)
+	if len(ready) != 1 || ready[0].FileID != file.ID {
+		t.Fatalf("the upload's event was lost: %+v", ready)
+	}
+}
+
+func TestRecoverStagedEvents(t *testing.T) {
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	ctx := context.Background()
+	kept := &domain.File{Name: "kept.pdf"}
+	if err := files.Save(ctx, kept, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writers that died before or after making their change
+	events := map[string]domain.Event{
+		"upload stored":     domain.NewEvent(domain.EventFileUploaded, kept.ID, nil),
+		"upload not stored": domain.NewEvent(domain.EventFileUploaded, "f200", nil),
+		"delete done":       domain.NewEvent(domain.EventFileDeleted, "f100", nil),
+		"delete not done":   domain.NewEvent(domain.EventFileDeleted, kept.ID, nil),
+		"update stored":     domain.NewEvent(domain.EventFileUpdated, kept.ID, map[string]interface{}{"name": "stale.pdf"}),
+	}
+	for _, event := range events {
+		if err := outbox.Stage(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outbox.age(testGrace + time.Minute)
+	recent := domain.NewEvent(domain.EventFileUploaded, "f300", nil)
+	if err := outbox.Stage(ctx, recent); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewRecoverStagedEventsUseCase(outbox, files, testGrace).Execute(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(events) {
+		t.Errorf("recovered %d events, want %d", n, len(events))
+	}
+	for name, want := range map[string]bool{
+		"upload stored":     true,
+		"upload not stored": false,
+		"delete done":       true,
+		"delete not done":   false,
+		"update stored":     true,
+	} {
+		entry, ok := outbox.entries[events[name].ID]
+		if committed := ok && entry.Status == domain.OutboxReady; committed != want {
+			t.Errorf("%s: committed = %v, want %v", name, committed, want)
+		} else if !want && ok {
+			t.Errorf("%s: left as %s", name, entry.Status)
+		}
+	}
+	// Recovered events describe the file as it is now
+	if data := outbox.entries[events["update stored"].ID].Event.Data; data["name"] != "kept.pdf" {
+		t.Errorf("recovered update data = %+v", data)
+	}
+	if entry := outbox.entries[recent.ID]; entry.Status != domain.OutboxStaged {
+		t.Errorf("recent event was %s", entry.Status)
+	}
+}
+
+// recordingSink records published events and fails while err is set.
+type recordingSink struct {
+	name      string
+	err       error
+	published []domain.Event
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event domain.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.published = append(s.published, event)
+	return nil
+}
+
+func TestDispatchEventsRetriesFailingSinks(t *testing.T) {
+	outbox := newMemoryOutboxRepository()
+	ctx := context.Background()
+	event := domain.NewEvent(domain.EventFileUploaded, "f001", nil)
+	if err := outbox.Commit(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	healthy, flaky := &recordingSink{name: "healthy"}, &recordingSink{name: "flaky", err: errors.New("broker unavailable")}
+	dispatch := NewDispatchEventsUseCase(outbox, healthy, flaky)
+
+	if n, err := dispatch.Execute(ctx, 10); n != 1 || err != nil {
+		t.Fatalf("first dispatch: %d, %v", n, err)
+	}
+	entry := outbox.entries[event.ID]
+	if entry.Status != domain.OutboxReady || entry.Attempts != 1 || entry.LastError != "flaky: broker unavailable" ||
+		!entry.Delivered("healthy") || entry.Delivered("flaky") {
+		t.Fatalf("after a failed sink: %+v", entry)
+	}
+	if entry.NextAttemptAt.Before(time.Now().Add(domain.OutboxInitialBackoff - time.Second)) {
+		t.Errorf("retry at %s is before the backoff", entry.NextAttemptAt)
+	}
+	if n, _ := dispatch.Execute(ctx, 10); n != 0 {
+		t.Fatal("event dispatched again before its backoff")
+	}
+
+	flaky.err = nil
+	entry.NextAttemptAt = time.Now()
+	if n, err := dispatch.Execute(ctx, 10); n != 1 || err != nil {
+		t.Fatalf("retry: %d, %v", n, err)
+	}
+	if len(healthy.published) != 1 || len(flaky.published) != 1 || outbox.entries[event.ID].Status != domain.OutboxPublished {
+		t.Fatalf("healthy got %d, flaky got %d events, status %s",
+			len(healthy.published), len(flaky.published), outbox.entries[event.ID].Status)
+	}
+}
+
+func TestDispatchEventsOnlyClaimsReadyEvents(t *testing.T) {
+	outbox := newMemoryOutboxRepository()
+	ctx := context.Background()
+	staged := domain.NewEvent(domain.EventFileUploaded, "f001", nil)
+	if err := outbox.Stage(ctx, staged); err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{name: "sink"}
+
+	if n, err := NewDispatchEventsUseCase(outbox, sink).Execute(ctx, 10); n != 0 || err != nil || len(sink.published) != 0 {
+		t.Fatalf("dispatched a staged event: %d, %v", n, err)
+	}
+}
+
+// failingReader fails like a client that disconnects mid-upload.
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("unexpected EOF")
+}
@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type ProcessJobUseCase struct {
+	repo      domain.JobRepository
+	handlers  map[string]domain.JobHandler
+	lease     time.Duration
+	retention time.Duration
+}
+
+// NewProcessJobUseCase runs jobs with the given handlers. Jobs are leased for
+// lease and the lease is renewed while the handler runs; succeeded jobs are
+// kept for retention.
+func NewProcessJobUseCase(repo domain.JobRepository, lease, retention time.Duration, handlers ...domain.JobHandler) *ProcessJobUseCase {
+	byType := make(map[string]domain.JobHandler, len(handlers))
+	for _, handler := range handlers {
+		byType[handler.Type()] = handler
+	}
+	return &ProcessJobUseCase{repo: repo, handlers: byType, lease: lease, retention: retention}
+}
+
+// Types lists the job types there are handlers for.
+func (uc *ProcessJobUseCase) Types() []string {
+	types := make([]string, 0, len(uc.handlers))
+	for jobType := range uc.handlers {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+// Execute claims the next due job of the given types, or of any type with a
+// handler when types is empty, runs it as worker and records the outcome.
+// Failed jobs are retried with exponential backoff until they run out of
+// attempts and are dead. It returns the job in its new state, or nil when no
+// job was due.
+func (uc *ProcessJobUseCase) Execute(ctx context.Context, worker string, types ...string) (_ *domain.Job, err error) {
+	if len(types) == 0 {
+		types = uc.Types()
+	}
+	job, err := uc.repo.Claim(ctx, types, worker, time.Now().UTC(), uc.lease)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	// Only polls that found a job are traced
+	ctx, span := startSpan(ctx, "ProcessJobUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	// The outcome is recorded even when the worker is stopping
+	settleCtx := context.WithoutCancel(ctx)
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+
+	// A worker died holding the job on its last attempt
+	if job.Attempts > job.MaxAttempts {
+		job.Attempts = job.MaxAttempts
+		job.Status = domain.JobDead
+		job.LastError = "lease expired during the last attempt"
+		job.FinishedAt = now
+		return job, uc.repo.Finish(settleCtx, job, worker)
+	}
+
+	leaseLost, runErr := uc.run(ctx, job, worker)
+	if leaseLost {
+		return job, domain.ErrJobLeaseLost
+	}
+
+	now = time.Now().UTC()
+	job.UpdatedAt = now
+	switch {
+	case runErr == nil:
+		job.Status = domain.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = now
+		job.ExpiresAt = now.Add(uc.retention)
+	case ctx.Err() != nil:
+		// Interrupted by shutdown, which does not count as an attempt
+		job.Status = domain.JobPending
+		job.Attempts--
+		job.RunAt = now
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = domain.JobDead
+		job.LastError = runErr.Error()
+		job.FinishedAt = now
+	default:
+		job.Status = domain.JobPending
+		job.LastError = runErr.Error()
+		job.RunAt = now.Add(domain.JobBackoff(job.Attempts))
+	}
+	return job, uc.repo.Finish(settleCtx, job, worker)
+}
+
+// run calls the job's handler while renewing its lease. The handler is
+// cancelled when the lease is lost to another worker.
+func (uc *ProcessJobUseCase) run(ctx context.Context, job *domain.Job, worker string) (leaseLost bool, err error) {
+	handler, ok := uc.handlers[job.Type]
+	if !ok {
+		return false, fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lost := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(uc.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Other errors are retried on the next tick, well before the
+			// lease runs out
+			if uc.repo.Heartbeat(runCtx, job.ID, worker, time.Now().UTC().Add(uc.lease)) == domain.ErrJobLeaseLost {
+				close(lost)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job handler panicked: %v", r)
+			}
+		}()
+		err = handler.Handle(runCtx, job)
+	}()
+	cancel()
+	<-stopped
+
+	select {
+	case <-lost:
+		return true, err
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,315 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// memoryJobRepository follows the leasing semantics of the MongoDB
+// repository.
+type memoryJobRepository struct {
+	mu     sync.Mutex
+	jobs   map[string]*domain.Job
+	nextID int
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: map[string]*domain.Job{}}
+}
+
+func (r *memoryJobRepository) Save(ctx context.Context, job *domain.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	job.ID = fmt.Sprintf("j%03d", r.nextID)
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+func (r *memoryJobRepository) FindByID(ctx context.Context, id string) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+func (r *memoryJobRepository) FindAll(ctx context.Context, filter domain.JobFilter, skip, limit int64) ([]*domain.Job, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *memoryJobRepository) Count(ctx context.Context, filter domain.JobFilter) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (r *memoryJobRepository) Claim(ctx context.Context, types []string, worker string, now time.Time, lease time.Duration) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*domain.Job
+	for _, job := range r.jobs {
+		known := false
+		for _, jobType := range types {
+			known = known || job.Type == jobType
+		}
+		pending := job.Status == domain.JobPending && !job.RunAt.After(now)
+		expired := job.Status == domain.JobRunning && !job.LockedUntil.After(now)
+		if known && (pending || expired) {
+			due = append(due, job)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+
+	job := due[0]
+	job.Status = domain.JobRunning
+	job.LockedBy = worker
+	job.LockedUntil = now.Add(lease)
+	job.Attempts++
+	claimed := *job
+	return &claimed, nil
+}
+
+func (r *memoryJobRepository) leased(id, worker string) (*domain.Job, error) {
+	job, ok := r.jobs[id]
+	if !ok || job.Status != domain.JobRunning || job.LockedBy != worker {
+		return nil, domain.ErrJobLeaseLost
+	}
+	return job, nil
+}
+
+func (r *memoryJobRepository) Heartbeat(ctx context.Context, id, worker string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := r.leased(id, worker)
+	if err != nil {
+		return err
+	}
+	job.LockedUntil = until
+	return nil
+}
+
+func (r *memoryJobRepository) Finish(ctx context.Context, job *domain.Job, worker string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.leased(job.ID, worker); err != nil {
+		return err
+	}
+	finished := *job
+	finished.LockedBy = ""
+	finished.LockedUntil = time.Time{}
+	r.jobs[job.ID] = &finished
+	return nil
+}
+
+func (r *memoryJobRepository) Retry(ctx context.Context, id string, now time.Time) error {
+	return errors.New("not implemented")
+}
+
+// steal hands the lease of a job to another worker, as if it had expired
+// and been claimed again.
+func (r *memoryJobRepository) steal(id, worker string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[id].LockedBy = worker
+}
+
+// expire ends the lease of a job, as if its worker had died.
+func (r *memoryJobRepository) expire(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[id].LockedUntil = time.Now().Add(-time.Second)
+}
+
+type testPayload struct {
+	FileID string `json:"file_id"`
+}
+
+func enqueueTestJob(t *testing.T, repo *memoryJobRepository, maxAttempts int) *domain.Job {
+	t.Helper()
+	job, err := NewEnqueueJobUseCase(repo, maxAttempts).Execute(context.Background(), EnqueueJobCommand{
+		Type:    "test",
+		Payload: testPayload{FileID: "f001"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestProcessJobRunsTypedHandler(t *testing.T) {
+	repo := newMemoryJobRepository()
+	enqueueTestJob(t, repo, 3)
+	var got testPayload
+	uc := NewProcessJobUseCase(repo, time.Minute, time.Hour, NewJobHandler("test", func(ctx context.Context, payload testPayload) error {
+		got = payload
+		return nil
+	}))
+
+	job, err := uc.Execute(context.Background(), "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FileID != "f001" {
+		t.Errorf("handler got payload %+v", got)
+	}
+	stored, _ := repo.FindByID(context.Background(), job.ID)
+	if stored.Status != domain.JobSucceeded || stored.Attempts != 1 || stored.LockedBy != "" || stored.ExpiresAt.IsZero() {
+		t.Fatalf("stored job %+v", stored)
+	}
+	if job, err := uc.Execute(context.Background(), "w1"); job != nil || err != nil {
+		t.Fatalf("second run got %v, %v", job, err)
+	}
+}
+
+func TestProcessJobRetriesUntilDead(t *testing.T) {
+	repo := newMemoryJobRepository()
+	queued := enqueueTestJob(t, repo, 2)
+	uc := NewProcessJobUseCase(repo, time.Minute, time.Hour, NewJobHandler("test", func(ctx context.Context, payload testPayload) error {
+		return errors.New("scanner unavailable")
+	}))
+	ctx := context.Background()
+
+	job, err := uc.Execute(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != domain.JobPending || job.RunAt.Before(time.Now().Add(domain.JobInitialBackoff-time.Second)) {
+		t.Fatalf("after the first failure: %s, next run %s", job.Status, job.RunAt)
+	}
+	if job, _ := uc.Execute(ctx, "w1"); job != nil {
+		t.Fatal("job ran again before its backoff")
+	}
+
+	// Make the retry due now
+	repo.jobs[queued.ID].RunAt = time.Now()
+	job, err = uc.Execute(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != domain.JobDead || job.Attempts != 2 || job.LastError != "scanner unavailable" {
+		t.Fatalf("after the last attempt: %+v", job)
+	}
+}
+
+func TestProcessJobReclaimsExpiredLeases(t *testing.T) {
+	repo := newMemoryJobRepository()
+	queued := enqueueTestJob(t, repo, 3)
+	runs := 0
+	uc := NewProcessJobUseCase(repo, time.Minute, time.Hour, NewJobHandler("test", func(ctx context.Context, payload testPayload) error {
+		runs++
+		return nil
+	}))
+	ctx := context.Background()
+
+	// A worker claimed the job and died; its lease ran out a while ago
+	if _, err := repo.Claim(ctx, []string{"test"}, "crashed", time.Now(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	repo.expire(queued.ID)
+
+	job, err := uc.Execute(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || job.ID != queued.ID || job.Status != domain.JobSucceeded || job.Attempts != 2 || runs != 1 {
+		t.Fatalf("reclaimed job %+v after %d runs", job, runs)
+	}
+	// The crashed worker cannot record an outcome any more
+	if err := repo.Finish(ctx, job, "crashed"); err != domain.ErrJobLeaseLost {
+		t.Fatalf("finish by the old worker: got %v", err)
+	}
+}
+
+func TestProcessJobDeadWhenLastLeaseExpired(t *testing.T) {
+	repo := newMemoryJobRepository()
+	queued := enqueueTestJob(t, repo, 1)
+	uc := NewProcessJobUseCase(repo, time.Minute, time.Hour, NewJobHandler("test", func(ctx context.Context, payload testPayload) error {
+		t.Error("handler ran after the last attempt")
+		return nil
+	}))
+
+	if _, err := repo.Claim(context.Background(), []string{"test"}, "crashed", time.Now(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	repo.expire(queued.ID)
+	job, err := uc.Execute(context.Background(), "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != domain.JobDead || job.Attempts != 1 {
+		t.Fatalf("got %+v", job)
+	}
+}
+
+func TestProcessJobStopsHandlerWhenLeaseIsLost(t *testing.T) {
+	repo := newMemoryJobRepository()
+	queued := enqueueTestJob(t, repo, 3)
+	uc := NewProcessJobUseCase(repo, 30*time.Millisecond, time.Hour, NewJobHandler("test", func(ctx context.Context, payload testPayload) error {
+		repo.steal(queued.ID, "w2")
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := uc.Execute(context.Background(), "w1")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != domain.ErrJobLeaseLost {
+			t.Fatalf("got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not cancelled after the lease was lost")
+	}
+	if stored, _ := repo.FindByID(context.Background(), queued.ID); stored.LockedBy != "w2" || stored.Status != domain.JobRunning {
+		t.Fatalf("the new holder's lease was changed: %+v", stored)
+	}
+}
+
+func TestChecksumJobRunsForUploads(t *testing.T) {
+	files, jobs := newMemoryFileRepository(), newMemoryJobRepository()
+	ctx := context.Background()
+	file := &domain.File{Name: "report.pdf"}
+	if err := files.Save(ctx, file, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule := NewScheduleFileJobsUseCase(NewEnqueueJobUseCase(jobs, 3))
+	if err := schedule.Execute(ctx, domain.NewEvent(domain.EventFileUploaded, file.ID, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := schedule.Execute(ctx, domain.NewEvent(domain.EventFileDeleted, file.ID, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("%d jobs enqueued, want 1", len(jobs.jobs))
+	}
+
+	uc := NewProcessJobUseCase(jobs, time.Minute, time.Hour, NewComputeChecksumUseCase(files).Handler())
+	job, err := uc.Execute(ctx, "w1")
+	if err != nil || job.Status != domain.JobSucceeded {
+		t.Fatalf("checksum job: %+v, %v", job, err)
+	}
+	sum := sha256.Sum256([]byte("content"))
+	if stored, _, _ := files.FindByID(ctx, file.ID); stored.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("checksum = %q", stored.SHA256)
+	}
+}
@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// maxAuditAppendAttempts bounds the retries when another instance appended
+// the same sequence number first.
+const maxAuditAppendAttempts = 5
+
+type RecordAuditCommand struct {
+	Actor  string
+	Action domain.AuditAction
+	FileID string
+	// CollectionID is set for actions on collections.
+	CollectionID string
+	IP           string
+	Outcome      domain.AuditOutcome
+	Status       int
+	Changes      []domain.AuditChange
+}
+
+type RecordAuditUseCase struct {
+	repo domain.AuditRepository
+	mu   sync.Mutex
+}
+
+func NewRecordAuditUseCase(repo domain.AuditRepository) *RecordAuditUseCase {
+	return &RecordAuditUseCase{repo: repo}
+}
+
+func (uc *RecordAuditUseCase) Execute(ctx context.Context, command RecordAuditCommand) (*domain.AuditEntry, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	entry := &domain.AuditEntry{
+		Actor:        command.Actor,
+		Action:       command.Action,
+		FileID:       command.FileID,
+		CollectionID: command.CollectionID,
+		IP:           command.IP,
+		Timestamp:    time.Now().UTC().Truncate(time.Millisecond),
+		Outcome:      command.Outcome,
+		Status:       command.Status,
+		Changes:      command.Changes,
+		HashVersion:  domain.AuditHashVersion,
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAuditAppendAttempts; attempt++ {
+		var last *domain.AuditEntry
+		last, err = uc.repo.Last(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Sequence = 1
+		entry.PrevHash = ""
+		if last != nil {
+			entry.Sequence = last.Sequence + 1
+			entry.PrevHash = last.Hash
+		}
+		entry.Hash = entry.ComputeHash()
+
+		err = uc.repo.Append(ctx, entry)
+		if err != domain.ErrAuditSequenceConflict {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
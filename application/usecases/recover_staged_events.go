@@ -0,0 +1,65 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type RecoverStagedEventsUseCase struct {
+	outbox   domain.OutboxRepository
+	fileRepo domain.FileRepository
+	grace    time.Duration
+}
+
+// NewRecoverStagedEventsUseCase creates the recovery of events left staged
+// for longer than grace. A writer may still be running until then, so grace
+// must be longer than the slowest upload.
+func NewRecoverStagedEventsUseCase(outbox domain.OutboxRepository, fileRepo domain.FileRepository, grace time.Duration) *RecoverStagedEventsUseCase {
+	return &RecoverStagedEventsUseCase{outbox: outbox, fileRepo: fileRepo, grace: grace}
+}
+
+// Execute resolves events left staged by a writer that never committed or
+// discarded them. Whether the change happened is decided from the current
+// state of the file: an upload happened if the file exists, a delete
+// happened if it does not. Updates of existing files are published with the
+// file's current state, whether or not that particular update was stored.
+func (uc *RecoverStagedEventsUseCase) Execute(ctx context.Context, limit int64) (int, error) {
+	entries, err := uc.outbox.FindStaged(ctx, time.Now().UTC().Add(-uc.grace), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		event := entry.Event
+		exists, err := uc.fileRepo.Exists(ctx, event.FileID)
+		if err != nil {
+			return 0, err
+		}
+
+		happened := exists
+		if event.Type == domain.EventFileDeleted {
+			happened = !exists
+		}
+		if !happened {
+			if err := uc.outbox.Discard(ctx, event.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if event.Type != domain.EventFileDeleted {
+			file, content, err := uc.fileRepo.FindByID(ctx, event.FileID)
+			if err != nil {
+				return 0, err
+			}
+			content.Close()
+			event.Data = domain.FileEventData(file)
+		}
+		if err := uc.outbox.Commit(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type RedeliverWebhookUseCase struct {
+	deliveryRepo domain.WebhookDeliveryRepository
+}
+
+func NewRedeliverWebhookUseCase(deliveryRepo domain.WebhookDeliveryRepository) *RedeliverWebhookUseCase {
+	return &RedeliverWebhookUseCase{deliveryRepo: deliveryRepo}
+}
+
+// Execute queues a fresh delivery of the same payload. The original delivery
+// is kept unchanged in the log.
+func (uc *RedeliverWebhookUseCase) Execute(ctx context.Context, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	original, err := uc.deliveryRepo.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.WebhookID != webhookID {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+
+	now := time.Now().UTC()
+	delivery := &domain.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		RedeliveryOf:  original.ID,
+		Payload:       original.Payload,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := uc.deliveryRepo.Save(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type RegisterWebhookCommand struct {
+	URL       string
+	Events    []string
+	Secret    string
+	CreatedBy string
+}
+
+type RegisterWebhookUseCase struct {
+	repo        domain.WebhookRepository
+	hostChecker domain.WebhookHostChecker
+}
+
+func NewRegisterWebhookUseCase(repo domain.WebhookRepository, hostChecker domain.WebhookHostChecker) *RegisterWebhookUseCase {
+	return &RegisterWebhookUseCase{repo: repo, hostChecker: hostChecker}
+}
+
+func (uc *RegisterWebhookUseCase) Execute(ctx context.Context, command RegisterWebhookCommand) (*domain.Webhook, error) {
+	target, err := url.Parse(command.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, domain.ErrWebhookInvalidURL
+	}
+	if err := uc.hostChecker.CheckHost(ctx, target.Hostname()); err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.EventType, 0, len(command.Events))
+	for _, name := range command.Events {
+		eventType := domain.EventType(name)
+		if !isKnownEventType(eventType) {
+			return nil, domain.ErrWebhookUnknownEvent
+		}
+		events = append(events, eventType)
+	}
+
+	secret := command.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(raw)
+	}
+
+	webhook := &domain.Webhook{
+		URL:       target.String(),
+		Events:    events,
+		Secret:    secret,
+		Active:    true,
+		CreatedBy: command.CreatedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := uc.repo.Save(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func isKnownEventType(eventType domain.EventType) bool {
+	for _, known := range domain.KnownEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ResolveShareLinkCommand struct {
+	Token    string
+	Password string
+}
+
+type ResolveShareLinkUseCase struct {
+	shareRepo domain.ShareLinkRepository
+	fileRepo  domain.FileRepository
+	signer    *ShareLinkSigner
+}
+
+func NewResolveShareLinkUseCase(shareRepo domain.ShareLinkRepository, fileRepo domain.FileRepository, signer *ShareLinkSigner) *ResolveShareLinkUseCase {
+	return &ResolveShareLinkUseCase{shareRepo: shareRepo, fileRepo: fileRepo, signer: signer}
+}
+
+// Execute validates a share link token and opens the shared file. The
+// download is counted against the link's limit only once the file has been
+// found. Unknown links are reported as invalid, so that probing does not
+// reveal which links exist.
+func (uc *ResolveShareLinkUseCase) Execute(ctx context.Context, command ResolveShareLinkCommand) (_ *domain.ShareLink, _ *domain.File, _ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "ResolveShareLinkUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	linkID, expires, signature, ok := ParseToken(command.Token)
+	if !ok {
+		return nil, nil, nil, domain.ErrShareLinkInvalidSignature
+	}
+
+	link, err := uc.shareRepo.FindByID(ctx, linkID)
+	if err == domain.ErrShareLinkNotFound {
+		return nil, nil, nil, domain.ErrShareLinkInvalidSignature
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !uc.signer.Verify(link.ID, link.FileID, expires, signature) || expires != link.ExpiresAt.Unix() {
+		return nil, nil, nil, domain.ErrShareLinkInvalidSignature
+	}
+
+	now := time.Now()
+	if link.Revoked {
+		return nil, nil, nil, domain.ErrShareLinkRevoked
+	}
+	if link.Expired(now) {
+		return nil, nil, nil, domain.ErrShareLinkExpired
+	}
+	if link.Exhausted() {
+		return nil, nil, nil, domain.ErrShareLinkExhausted
+	}
+
+	if link.PasswordProtected() {
+		if command.Password == "" {
+			return nil, nil, nil, domain.ErrShareLinkPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(command.Password)) != nil {
+			return nil, nil, nil, domain.ErrShareLinkPasswordInvalid
+		}
+	}
+
+	file, content, err := uc.fileRepo.FindByID(ctx, link.FileID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := uc.shareRepo.IncrementDownloads(ctx, link.ID); err != nil {
+		content.Close()
+		return nil, nil, nil, err
+	}
+	link.Downloads++
+	return link, file, content, nil
+}
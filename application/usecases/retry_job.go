@@ -0,0 +1,25 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type RetryJobUseCase struct {
+	repo domain.JobRepository
+}
+
+func NewRetryJobUseCase(repo domain.JobRepository) *RetryJobUseCase {
+	return &RetryJobUseCase{repo: repo}
+}
+
+// Execute puts a dead job back in the queue with all its attempts, to run as
+// soon as a worker is free.
+func (uc *RetryJobUseCase) Execute(ctx context.Context, id string) (*domain.Job, error) {
+	if err := uc.repo.Retry(ctx, id, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return uc.repo.FindByID(ctx, id)
+}
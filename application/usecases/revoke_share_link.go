@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type RevokeShareLinkUseCase struct {
+	shareRepo domain.ShareLinkRepository
+}
+
+func NewRevokeShareLinkUseCase(shareRepo domain.ShareLinkRepository) *RevokeShareLinkUseCase {
+	return &RevokeShareLinkUseCase{shareRepo: shareRepo}
+}
+
+func (uc *RevokeShareLinkUseCase) Execute(ctx context.Context, id string) (*domain.ShareLink, error) {
+	link, err := uc.shareRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.shareRepo.Revoke(ctx, id); err != nil {
+		return nil, err
+	}
+	link.Revoked = true
+	return link, nil
+}
@@ -0,0 +1,29 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type ScheduleFileJobsUseCase struct {
+	enqueue *EnqueueJobUseCase
+}
+
+func NewScheduleFileJobsUseCase(enqueueUC *EnqueueJobUseCase) *ScheduleFileJobsUseCase {
+	return &ScheduleFileJobsUseCase{enqueue: enqueueUC}
+}
+
+// Execute enqueues the background work an event calls for: uploaded files
+// get their checksum computed. Events are dispatched at least once, so the
+// jobs may be enqueued twice; their handlers are idempotent.
+func (uc *ScheduleFileJobsUseCase) Execute(ctx context.Context, event domain.Event) error {
+	if event.Type != domain.EventFileUploaded {
+		return nil
+	}
+	_, err := uc.enqueue.Execute(ctx, EnqueueJobCommand{
+		Type:    ChecksumJobType,
+		Payload: ChecksumJobPayload{FileID: event.FileID},
+	})
+	return err
+}
@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type memoryShareLinkRepository struct {
+	links map[string]*domain.ShareLink
+}
+
+func (r *memoryShareLinkRepository) Save(ctx context.Context, link *domain.ShareLink) error {
+	link.ID = fmt.Sprintf("s%d", len(r.links)+1)
+	stored := *link
+	r.links[link.ID] = &stored
+	return nil
+}
+
+func (r *memoryShareLinkRepository) FindByID(ctx context.Context, id string) (*domain.ShareLink, error) {
+	link, ok := r.links[id]
+	if !ok {
+		return nil, domain.ErrShareLinkNotFound
+	}
+	found := *link
+	return &found, nil
+}
+
+func (r *memoryShareLinkRepository) FindByFileID(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	return nil, nil
+}
+
+func (r *memoryShareLinkRepository) IncrementDownloads(ctx context.Context, id string) error {
+	link := r.links[id]
+	if link.Exhausted() {
+		return domain.ErrShareLinkExhausted
+	}
+	link.Downloads++
+	return nil
+}
+
+func (r *memoryShareLinkRepository) Revoke(ctx context.Context, id string) error {
+	r.links[id].Revoked = true
+	return nil
+}
+
+type shareFixture struct {
+	files  *memoryFileRepository
+	shares *memoryShareLinkRepository
+	create *CreateShareLinkUseCase
+	open   *ResolveShareLinkUseCase
+	fileID string
+}
+
+func newShareFixture(t *testing.T) *shareFixture {
+	t.Helper()
+	files := newMemoryFileRepository()
+	file := &domain.File{Name: "letter.pdf", ContentType: "application/pdf"}
+	if err := files.Save(context.Background(), file, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+	shares := &memoryShareLinkRepository{links: map[string]*domain.ShareLink{}}
+	signer := NewShareLinkSigner([]byte("secret"))
+	return &shareFixture{
+		files:  files,
+		shares: shares,
+		create: NewCreateShareLinkUseCase(files, shares, signer),
+		open:   NewResolveShareLinkUseCase(shares, files, signer),
+		fileID: file.ID,
+	}
+}
+
+func (f *shareFixture) share(t *testing.T, command CreateShareLinkCommand) *SignedShareLink {
+	t.Helper()
+	command.FileID = f.fileID
+	signed, err := f.create.Execute(context.Background(), command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (f *shareFixture) download(token, password string) error {
+	_, _, content, err := f.open.Execute(context.Background(), ResolveShareLinkCommand{Token: token, Password: password})
+	if err == nil {
+		content.Close()
+	}
+	return err
+}
+
+func TestShareLinkSignerRejectsAlteredParameters(t *testing.T) {
+	signer := NewShareLinkSigner([]byte("secret"))
+	signature := signer.Sign("link", "file", 1700000000)
+
+	if !signer.Verify("link", "file", 1700000000, signature) {
+		t.Fatal("valid signature rejected")
+	}
+	if signer.Verify("link", "file", 1700003600, signature) {
+		t.Error("extended expiry accepted")
+	}
+	if signer.Verify("link", "other", 1700000000, signature) {
+		t.Error("other file accepted")
+	}
+	if NewShareLinkSigner([]byte("other")).Verify("link", "file", 1700000000, signature) {
+		t.Error("signature accepted under another secret")
+	}
+}
+
+func TestResolveShareLinkServesFile(t *testing.T) {
+	f := newShareFixture(t)
+	signed := f.share(t, CreateShareLinkCommand{})
+
+	link, file, content, err := f.open.Execute(context.Background(), ResolveShareLinkCommand{Token: signed.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	data, _ := io.ReadAll(content)
+	if file.ID != f.fileID || string(data) != "content" || link.Downloads != 1 {
+		t.Fatalf("got file %s %q after %d downloads", file.ID, data, link.Downloads)
+	}
+}
+
+func TestResolveShareLinkRejectsForgedTokens(t *testing.T) {
+	f := newShareFixture(t)
+	signed := f.share(t, CreateShareLinkCommand{})
+	linkID, expires, signature, _ := ParseToken(signed.Token)
+	later := time.Unix(expires, 0).Add(time.Hour).Unix()
+
+	for name, token := range map[string]string{
+		"malformed":       "garbage",
+		"extended expiry": linkID + "." + strconv.FormatInt(later, 10) + "." + signature,
+		"unknown link":    "unknown." + strconv.FormatInt(expires, 10) + "." + signature,
+		"wrong secret":    NewShareLinkSigner([]byte("other")).Token(linkID, f.fileID, expires),
+	} {
+		if err := f.download(token, ""); err != domain.ErrShareLinkInvalidSignature {
+			t.Errorf("%s: got %v, want %v", name, err, domain.ErrShareLinkInvalidSignature)
+		}
+	}
+}
+
+func TestResolveShareLinkEnforcesLimits(t *testing.T) {
+	f := newShareFixture(t)
+
+	limited := f.share(t, CreateShareLinkCommand{MaxDownloads: 1})
+	if err := f.download(limited.Token, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.download(limited.Token, ""); err != domain.ErrShareLinkExhausted {
+		t.Errorf("second download: got %v, want %v", err, domain.ErrShareLinkExhausted)
+	}
+
+	protected := f.share(t, CreateShareLinkCommand{Password: "hunter2"})
+	if err := f.download(protected.Token, ""); err != domain.ErrShareLinkPasswordRequired {
+		t.Errorf("no password: got %v", err)
+	}
+	if err := f.download(protected.Token, "wrong"); err != domain.ErrShareLinkPasswordInvalid {
+		t.Errorf("wrong password: got %v", err)
+	}
+	if err := f.download(protected.Token, "hunter2"); err != nil {
+		t.Errorf("right password: got %v", err)
+	}
+
+	revoked := f.share(t, CreateShareLinkCommand{})
+	f.shares.Revoke(context.Background(), revoked.Link.ID)
+	if err := f.download(revoked.Token, ""); err != domain.ErrShareLinkRevoked {
+		t.Errorf("revoked: got %v", err)
+	}
+}
+
+func TestResolveShareLinkCountsOnlyServedDownloads(t *testing.T) {
+	f := newShareFixture(t)
+	signed := f.share(t, CreateShareLinkCommand{MaxDownloads: 1})
+	f.files.Delete(context.Background(), f.fileID)
+
+	if err := f.download(signed.Token, ""); err != domain.ErrFileNotFound {
+		t.Fatalf("got %v, want %v", err, domain.ErrFileNotFound)
+	}
+	if downloads := f.shares.links[signed.Link.ID].Downloads; downloads != 0 {
+		t.Fatalf("missing file used up %d downloads", downloads)
+	}
+}
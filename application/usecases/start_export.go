@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type StartExportCommand struct {
+	Filter    domain.FileFilter
+	CreatedBy string
+}
+
+type StartExportUseCase struct {
+	exports   domain.ExportRepository
+	archive   *ExportArchiveUseCase
+	retention time.Duration
+	spawn     func(func(ctx context.Context))
+}
+
+// NewStartExportUseCase creates the use case; spawn runs the export in the
+// background and cancels its context on shutdown.
+func NewStartExportUseCase(
+	exports domain.ExportRepository,
+	archive *ExportArchiveUseCase,
+	retention time.Duration,
+	spawn func(func(ctx context.Context)),
+) *StartExportUseCase {
+	return &StartExportUseCase{exports: exports, archive: archive, retention: retention, spawn: spawn}
+}
+
+// Execute records a pending export and builds its archive in the background.
+func (uc *StartExportUseCase) Execute(ctx context.Context, command StartExportCommand) (_ *domain.Export, err error) {
+	ctx, span := startSpan(ctx, "StartExportUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	now := time.Now().UTC()
+	export := &domain.Export{
+		Filter:    command.Filter,
+		Status:    domain.ExportPending,
+		CreatedBy: command.CreatedBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(uc.retention),
+	}
+	if err := uc.exports.Save(ctx, export); err != nil {
+		return nil, err
+	}
+
+	job := *export
+	uc.spawn(func(ctx context.Context) { uc.run(ctx, &job) })
+	return export, nil
+}
+
+// run builds the archive and records the outcome. The outcome is recorded
+// even when shutdown interrupts the export, so it does not stay running.
+func (uc *StartExportUseCase) run(ctx context.Context, export *domain.Export) {
+	settleCtx := context.WithoutCancel(ctx)
+	finish := func(err error) {
+		export.FinishedAt = time.Now().UTC()
+		export.Status = domain.ExportCompleted
+		if err != nil {
+			export.Status = domain.ExportFailed
+			export.Error = err.Error()
+		}
+		_ = uc.exports.Update(settleCtx, export)
+	}
+
+	export.Status = domain.ExportRunning
+	if err := uc.exports.Update(ctx, export); err != nil {
+		finish(err)
+		return
+	}
+
+	plan, err := uc.archive.Plan(ctx, export.Filter, 0)
+	if err != nil {
+		finish(err)
+		return
+	}
+
+	// The archive is written into a pipe that storage reads from, so it is
+	// never held in memory or on disk
+	reader, writer := io.Pipe()
+	manifests := make(chan *domain.ArchiveManifest, 1)
+	go func() {
+		manifest, err := uc.archive.Write(ctx, plan, writer)
+		manifests <- manifest
+		writer.CloseWithError(err)
+	}()
+
+	size, err := uc.exports.WriteContent(ctx, export.ID, reader)
+	reader.CloseWithError(err)
+	manifest := <-manifests
+	if err != nil {
+		finish(err)
+		return
+	}
+
+	export.Files = len(manifest.Files)
+	export.Missing = manifest.Missing
+	export.Size = size
+	finish(nil)
+}
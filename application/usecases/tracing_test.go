@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorder    = tracetest.NewSpanRecorder()
+	installRecorder sync.Once
+)
+
+// recordSpans returns a function finding a span ended since the call by
+// name. The package tracer delegates to the first provider installed
+// globally, so every test shares one recorder.
+func recordSpans() func(name string) sdktrace.ReadOnlySpan {
+	installRecorder.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	before := len(spanRecorder.Ended())
+	return func(name string) sdktrace.ReadOnlySpan {
+		for _, span := range spanRecorder.Ended()[before:] {
+			if span.Name() == name {
+				return span
+			}
+		}
+		return nil
+	}
+}
+
+func TestUseCaseSpansRecordErrors(t *testing.T) {
+	find := recordSpans()
+	exports := newMemoryExportRepository()
+
+	if _, err := NewGetExportUseCase(exports).Execute(context.Background(), "e999"); err != domain.ErrExportNotFound {
+		t.Fatal(err)
+	}
+	span := find("GetExportUseCase.Execute")
+	if span == nil || span.Status().Code != codes.Error || span.Status().Description != domain.ErrExportNotFound.Message {
+		t.Fatalf("span: %+v", span)
+	}
+	if events := span.Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("events: %+v", events)
+	}
+}
+
+func TestUploadSpanRecordsCommitFailure(t *testing.T) {
+	find := recordSpans()
+	outbox := newMemoryOutboxRepository()
+	outbox.failCommit = true
+	upload := NewUploadFileUseCase(newMemoryFileRepository(), newMemoryCollectionRepository(), outbox)
+
+	if _, err := upload.Execute(context.Background(), UploadFileCommand{Name: "report.pdf", Content: strings.NewReader("content")}); err != nil {
+		t.Fatal(err)
+	}
+	// The upload succeeded, so only the event records the failed commit
+	span := find("UploadFileUseCase.Execute")
+	if span == nil || span.Status().Code == codes.Error {
+		t.Fatalf("span: %+v", span)
+	}
+	for _, event := range span.Events() {
+		for _, attr := range event.Attributes {
+			if attr.Key == "exception.message" && strings.Contains(attr.Value.AsString(), "failed to commit file.uploaded event") {
+				return
+			}
+		}
+	}
+	t.Errorf("events %+v lack the commit failure", span.Events())
+}
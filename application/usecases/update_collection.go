@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// UpdateCollectionCommand renames and/or moves a collection. Nil fields are
+// left unchanged; an empty ParentID moves the collection to the top level.
+type UpdateCollectionCommand struct {
+	ID       string
+	Name     *string
+	ParentID *string
+}
+
+type UpdateCollectionUseCase struct {
+	repo domain.CollectionRepository
+	tx   domain.Transactor
+}
+
+func NewUpdateCollectionUseCase(repo domain.CollectionRepository, tx domain.Transactor) *UpdateCollectionUseCase {
+	return &UpdateCollectionUseCase{repo: repo, tx: tx}
+}
+
+// Execute applies the changes and returns the collection and the fields that
+// changed. A moved collection takes everything below it along, in the same
+// transaction; files keep their collection and so move with it.
+func (uc *UpdateCollectionUseCase) Execute(ctx context.Context, command UpdateCollectionCommand) (_ *domain.Collection, _ []domain.AuditChange, err error) {
+	ctx, span := startSpan(ctx, "UpdateCollectionUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	var collection *domain.Collection
+	var changes []domain.AuditChange
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := uc.repo.FindByID(ctx, command.ID)
+		if err != nil {
+			return err
+		}
+		updated := *before
+
+		if command.Name != nil {
+			name := strings.TrimSpace(*command.Name)
+			if err := domain.ValidateCollectionName(name); err != nil {
+				return err
+			}
+			updated.Name = name
+		}
+
+		moved := command.ParentID != nil && *command.ParentID != before.ParentID
+		if moved {
+			updated.ParentID = *command.ParentID
+			updated.Ancestors = nil
+			if updated.ParentID != "" {
+				// Locked so that the new parent is not deleted meanwhile
+				if err := uc.repo.Lock(ctx, updated.ParentID); err != nil {
+					return err
+				}
+				parent, err := uc.repo.FindByID(ctx, updated.ParentID)
+				if err != nil {
+					return err
+				}
+				if parent.Contains(updated.ID) {
+					return domain.ErrCollectionCycle
+				}
+				updated.Ancestors = append(append([]string{}, parent.Ancestors...), parent.ID)
+			}
+		}
+
+		updated.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+		if err := uc.repo.Update(ctx, &updated); err != nil {
+			return err
+		}
+		if moved {
+			if err := uc.repo.MoveDescendants(ctx, updated.ID, before.Ancestors, updated.Ancestors); err != nil {
+				return err
+			}
+		}
+		collection, changes = &updated, domain.CollectionChanges(before, &updated)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := withPaths(ctx, uc.repo, collection); err != nil {
+		return nil, nil, err
+	}
+	return collection, changes, nil
+}
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// UpdateFileCommand changes the fields that are set and leaves the others as
+// they are. An empty Tags slice removes all tags.
+type UpdateFileCommand struct {
+	FileID      string
+	Name        *string
+	Description *string
+	Tags        *[]string
+	Category    *string
+	// IfMatch lists the versions the client expects the file to be at. Nil
+	// accepts any version, an empty list none.
+	IfMatch []int64
+}
+
+type UpdateFileUseCase struct {
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
+	outbox      domain.OutboxRepository
+}
+
+func NewUpdateFileUseCase(repo domain.FileRepository, collections domain.CollectionRepository, outbox domain.OutboxRepository) *UpdateFileUseCase {
+	return &UpdateFileUseCase{repo: repo, collections: collections, outbox: outbox}
+}
+
+// Execute changes the name and descriptive metadata of a file and records a
+// file.updated event. It returns the file and the fields that changed.
+func (uc *UpdateFileUseCase) Execute(ctx context.Context, command UpdateFileCommand) (_ *domain.File, _ []domain.AuditChange, err error) {
+	ctx, span := startSpan(ctx, "UpdateFileUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	file, content, err := uc.repo.FindByID(ctx, command.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content.Close()
+	if err := checkVersion(file, command.IfMatch); err != nil {
+		return nil, nil, err
+	}
+
+	updated := *file
+	if command.Name != nil {
+		name := domain.NormalizeFileName(*command.Name)
+		if err := domain.ValidateFileName(name); err != nil {
+			return nil, nil, err
+		}
+		updated.Name = name
+	}
+	if command.Description != nil {
+		if err := domain.ValidateDescription(*command.Description); err != nil {
+			return nil, nil, err
+		}
+		updated.Description = *command.Description
+	}
+	if command.Tags != nil {
+		if updated.Tags, err = domain.NormalizeTags(*command.Tags); err != nil {
+			return nil, nil, err
+		}
+	}
+	if command.Category != nil {
+		if err := domain.ValidateCategory(*command.Category); err != nil {
+			return nil, nil, err
+		}
+		updated.Category = *command.Category
+	}
+
+	changes := domain.FileChanges(file, &updated)
+	if len(changes) > 0 {
+		if err := saveFileChanges(ctx, uc.repo, uc.outbox, &updated); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := withFilePaths(ctx, uc.collections, &updated); err != nil {
+		return nil, nil, err
+	}
+	return &updated, changes, nil
+}
+
+// checkVersion returns ErrFileModified unless the file is at one of the
+// versions in ifMatch, or ifMatch is nil.
+func checkVersion(file *domain.File, ifMatch []int64) error {
+	if ifMatch == nil {
+		return nil
+	}
+	for _, version := range ifMatch {
+		if version == file.Version {
+			return nil
+		}
+	}
+	return domain.ErrFileModified
+}
+
+// saveFileChanges stores the metadata of file and records a file.updated
+// event for it.
+func saveFileChanges(ctx context.Context, repo domain.FileRepository, outbox domain.OutboxRepository, file *domain.File) error {
+	event := domain.NewEvent(domain.EventFileUpdated, file.ID, domain.FileEventData(file))
+	if err := outbox.Stage(ctx, event); err != nil {
+		return err
+	}
+	settleCtx := context.WithoutCancel(ctx)
+	if err := repo.UpdateMetadata(ctx, file); err != nil {
+		_ = outbox.Discard(settleCtx, event.ID)
+		return err
+	}
+	commitEvent(settleCtx, outbox, event)
+	return nil
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func newUpdateFixture(t *testing.T) (*UpdateFileUseCase, *memoryFileRepository, *memoryOutboxRepository, *domain.File) {
+	t.Helper()
+	files, outbox := newMemoryFileRepository(), newMemoryOutboxRepository()
+	file := &domain.File{Name: "report.pdf", ContentType: "application/pdf"}
+	if err := files.Save(context.Background(), file, strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+	return NewUpdateFileUseCase(files, newMemoryCollectionRepository(), outbox), files, outbox, file
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestUpdateFileChecksIfMatch(t *testing.T) {
+	uc, files, outbox, file := newUpdateFixture(t)
+	ctx := context.Background()
+
+	updated, changes, err := uc.Execute(ctx, UpdateFileCommand{FileID: file.ID, Name: stringPtr("annual report.pdf"), IfMatch: []int64{file.Version}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version != file.Version+1 || len(changes) != 1 || changes[0].Field != "name" {
+		t.Fatalf("got version %d, changes %+v", updated.Version, changes)
+	}
+	if len(outbox.events(domain.OutboxReady)) != 1 || outbox.events(domain.OutboxReady)[0].Type != domain.EventFileUpdated {
+		t.Fatalf("events: %+v", outbox.events(domain.OutboxReady))
+	}
+
+	// The client still holds the version it read before the update
+	for _, ifMatch := range [][]int64{{file.Version}, {}} {
+		_, _, err := uc.Execute(ctx, UpdateFileCommand{FileID: file.ID, Name: stringPtr("other.pdf"), IfMatch: ifMatch})
+		if err != domain.ErrFileModified {
+			t.Errorf("If-Match %v: got %v", ifMatch, err)
+		}
+	}
+	stored, _, _ := files.FindByID(ctx, file.ID)
+	if stored.Name != "annual report.pdf" || len(outbox.events(domain.OutboxReady)) != 1 || len(outbox.events(domain.OutboxStaged)) != 0 {
+		t.Fatalf("rejected updates were applied: name %q, %d events", stored.Name, len(outbox.events(domain.OutboxReady)))
+	}
+
+	// Without If-Match the update applies to whatever version is stored
+	if _, _, err := uc.Execute(ctx, UpdateFileCommand{FileID: file.ID, Category: stringPtr("finance")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// racingFileRepository changes a file between the read and the write of an
+// update, as a concurrent request would.
+type racingFileRepository struct {
+	*memoryFileRepository
+}
+
+func (r racingFileRepository) UpdateMetadata(ctx context.Context, file *domain.File) error {
+	other := *file
+	if err := r.memoryFileRepository.UpdateMetadata(ctx, &other); err != nil {
+		return err
+	}
+	return r.memoryFileRepository.UpdateMetadata(ctx, file)
+}
+
+func TestUpdateFileRejectsConcurrentChanges(t *testing.T) {
+	_, files, outbox, file := newUpdateFixture(t)
+	uc := NewUpdateFileUseCase(racingFileRepository{files}, newMemoryCollectionRepository(), outbox)
+
+	_, _, err := uc.Execute(context.Background(), UpdateFileCommand{FileID: file.ID, Name: stringPtr("renamed.pdf"), IfMatch: []int64{file.Version}})
+	if err != domain.ErrFileModified {
+		t.Fatalf("got %v", err)
+	}
+	if len(outbox.events(domain.OutboxStaged)) != 0 || len(outbox.events(domain.OutboxReady)) != 0 {
+		t.Errorf("events left: %d staged, %d committed", len(outbox.events(domain.OutboxStaged)), len(outbox.events(domain.OutboxReady)))
+	}
+}
@@ -1,6 +1,7 @@
 package usecases
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -11,22 +12,100 @@ type UploadFileCommand struct {
 	Name        string
 	ContentType string
 	Content     io.Reader
+	// Size is the length of Content, checked against the storage quota
+	// before anything is stored; 0 when not known in advance.
+	Size int64
+	// CollectionID optionally files the upload in a collection.
+	CollectionID string
 }
 
 type UploadFileUseCase struct {
-	repo domain.FileRepository
+	repo        domain.FileRepository
+	collections domain.CollectionRepository
+	outbox      domain.OutboxRepository
 }
 
-func NewUploadFileUseCase(repo domain.FileRepository) *UploadFileUseCase {
-	return &UploadFileUseCase{repo: repo}
+func NewUploadFileUseCase(repo domain.FileRepository, collections domain.CollectionRepository, outbox domain.OutboxRepository) *UploadFileUseCase {
+	return &UploadFileUseCase{repo: repo, collections: collections, outbox: outbox}
 }
 
-func (uc *UploadFileUseCase) Execute(command UploadFileCommand) (*domain.File, error) {
+// Execute stores the file under its sanitized name and records a
+// file.uploaded event. The event is staged before the upload starts so it
+// cannot be lost if the process dies right after the content has been
+// written.
+func (uc *UploadFileUseCase) Execute(ctx context.Context, command UploadFileCommand) (_ *domain.File, err error) {
+	ctx, span := startSpan(ctx, "UploadFileUseCase.Execute")
+	defer func() { endSpan(span, err) }()
+
+	name := domain.SanitizeFileName(command.Name)
+	if err := domain.ValidateFileName(name); err != nil {
+		return nil, err
+	}
+	if err := uc.checkQuota(ctx, command.Size); err != nil {
+		return nil, err
+	}
+
+	var collection *domain.Collection
+	if command.CollectionID != "" {
+		if collection, err = uc.collections.FindByID(ctx, command.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+
 	file := &domain.File{
-		Name:        command.Name,
+		ID:          uc.repo.NextID(),
+		Name:        name,
 		ContentType: command.ContentType,
 		UploadDate:  time.Now(),
 	}
-	err := uc.repo.Save(file, command.Content)
-	return file, err
+	if collection != nil {
+		file.CollectionID = collection.ID
+	}
+
+	event := domain.NewEvent(domain.EventFileUploaded, file.ID, nil)
+	if err := uc.outbox.Stage(ctx, event); err != nil {
+		return file, err
+	}
+
+	// Once the content is stored the outcome is settled, so the outbox is
+	// updated even if the caller has gone away in the meantime.
+	settleCtx := context.WithoutCancel(ctx)
+	if err := uc.repo.Save(ctx, file, command.Content); err != nil {
+		_ = uc.outbox.Discard(settleCtx, event.ID)
+		return file, err
+	}
+	if collection != nil {
+		// Locking after the save conflicts with a concurrent delete of the
+		// collection: either the delete sees the file, or the lock finds
+		// the collection gone and the upload is undone.
+		if err := uc.collections.Lock(settleCtx, collection.ID); err != nil {
+			_ = uc.repo.Delete(settleCtx, file.ID)
+			_ = uc.outbox.Discard(settleCtx, event.ID)
+			return file, err
+		}
+	}
+
+	event.Data = domain.FileEventData(file)
+	commitEvent(settleCtx, uc.outbox, event)
+
+	if collection != nil {
+		if err := withPaths(ctx, uc.collections, collection); err == nil {
+			file.Path = collection.Path
+		}
+	}
+	return file, nil
+}
+
+// checkQuota rejects the upload when it would take the archive over its
+// storage quota. Concurrent uploads are not accounted for, so the quota can
+// be exceeded by the uploads in progress.
+func (uc *UploadFileUseCase) checkQuota(ctx context.Context, size int64) error {
+	if domain.StorageQuota <= 0 {
+		return nil
+	}
+	stats, err := uc.repo.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	return domain.ValidateQuota(stats.Bytes, size)
 }
@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func TestUserTokens(t *testing.T) {
+	repo := newMemoryUserRepository()
+	ctx := context.Background()
+	create, authenticate := NewCreateUserUseCase(repo), NewAuthenticateUserUseCase(repo)
+
+	user, token, err := create.Execute(ctx, "scanner-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, domain.TokenPrefix) || repo.users["scanner-01"].TokenHash == token {
+		t.Fatalf("token %q is stored as %q", token, repo.users["scanner-01"].TokenHash)
+	}
+	if got, err := authenticate.Execute(ctx, token); err != nil || got.ID != user.ID {
+		t.Fatalf("authenticate: %+v, %v", got, err)
+	}
+
+	for _, invalid := range []string{"", token + "x", strings.TrimPrefix(token, domain.TokenPrefix), domain.TokenPrefix} {
+		if _, err := authenticate.Execute(ctx, invalid); err != domain.ErrInvalidToken {
+			t.Errorf("token %q: got %v", invalid, err)
+		}
+	}
+
+	if _, _, err := create.Execute(ctx, "scanner-01"); err != domain.ErrUserNameTaken {
+		t.Errorf("second user with the same name: got %v", err)
+	}
+	if err := NewDeleteUserUseCase(repo).Execute(ctx, "scanner-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authenticate.Execute(ctx, token); err != domain.ErrInvalidToken {
+		t.Errorf("token of a deleted user: got %v", err)
+	}
+	if err := NewDeleteUserUseCase(repo).Execute(ctx, "scanner-01"); err != domain.ErrUserNotFound {
+		t.Errorf("deleting twice: got %v", err)
+	}
+}
+
+func TestCreateUserValidatesName(t *testing.T) {
+	create := NewCreateUserUseCase(newMemoryUserRepository())
+	for _, name := range []string{"budi", "budi.santoso@arsip", "scanner_01"} {
+		if _, _, err := create.Execute(context.Background(), name); err != nil {
+			t.Errorf("%q: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "cli:budi", "share:abc", "budi santoso", strings.Repeat("a", domain.MaxUserNameLength+1)} {
+		if _, _, err := create.Execute(context.Background(), name); !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("%q: got %v", name, err)
+		}
+	}
+}
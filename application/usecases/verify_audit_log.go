@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type AuditVerification struct {
+	Valid    bool
+	Checked  int64
+	LastHash string
+	BrokenAt int64
+	Reason   string
+}
+
+type VerifyAuditLogUseCase struct {
+	repo domain.AuditRepository
+}
+
+func NewVerifyAuditLogUseCase(repo domain.AuditRepository) *VerifyAuditLogUseCase {
+	return &VerifyAuditLogUseCase{repo: repo}
+}
+
+// Execute walks the whole log in sequence order and checks that sequence
+// numbers are contiguous, that every entry links to its predecessor and that
+// every stored hash matches the recomputed one. The chain must also still
+// contain each anchor, which detects entries removed from its end.
+func (uc *VerifyAuditLogUseCase) Execute(ctx context.Context, anchors ...domain.AuditAnchor) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	var expectedSeq int64 = 1
+	prevHash := ""
+	hashVersion := 0
+	anchored := make(map[int64]string, len(anchors))
+	for _, anchor := range anchors {
+		anchored[anchor.Sequence] = anchor.Hash
+	}
+
+	err := uc.repo.Walk(ctx, func(entry *domain.AuditEntry) bool {
+		anchorHash, isAnchor := anchored[entry.Sequence]
+		switch {
+		case entry.Sequence != expectedSeq:
+			result.Reason = fmt.Sprintf("expected sequence %d, found %d", expectedSeq, entry.Sequence)
+		case entry.PrevHash != prevHash:
+			result.Reason = "previous hash does not match preceding entry"
+		case entry.HashVersion < hashVersion:
+			// Otherwise an edited entry could fall back to the weaker format
+			result.Reason = "entry uses an older hash format than its predecessor"
+		case entry.ComputeHash() != entry.Hash:
+			result.Reason = "entry hash does not match its contents"
+		case isAnchor && entry.Hash != anchorHash:
+			result.Reason = "entry hash does not match the anchor"
+		}
+		if result.Reason != "" {
+			result.Valid = false
+			result.BrokenAt = entry.Sequence
+			return false
+		}
+
+		result.Checked++
+		prevHash = entry.Hash
+		hashVersion = entry.HashVersion
+		expectedSeq++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.LastHash = prevHash
+	if result.Valid {
+		for _, anchor := range anchors {
+			if anchor.Sequence > result.Checked {
+				result.Valid = false
+				result.BrokenAt = result.Checked + 1
+				result.Reason = fmt.Sprintf("chain ends before anchor at sequence %d", anchor.Sequence)
+				break
+			}
+		}
+	}
+	return result, nil
+}
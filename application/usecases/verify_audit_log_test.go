@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// memoryAuditRepository keeps the log in a slice, in sequence order.
+type memoryAuditRepository struct {
+	entries []*domain.AuditEntry
+}
+
+func (r *memoryAuditRepository) Last(ctx context.Context) (*domain.AuditEntry, error) {
+	if len(r.entries) == 0 {
+		return nil, nil
+	}
+	last := *r.entries[len(r.entries)-1]
+	return &last, nil
+}
+
+func (r *memoryAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	for _, e := range r.entries {
+		if e.Sequence == entry.Sequence {
+			return domain.ErrAuditSequenceConflict
+		}
+	}
+	stored := *entry
+	r.entries = append(r.entries, &stored)
+	return nil
+}
+
+func (r *memoryAuditRepository) FindAll(ctx context.Context, filter domain.AuditFilter, skip, limit int64) ([]*domain.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func (r *memoryAuditRepository) Count(ctx context.Context, filter domain.AuditFilter) (int64, error) {
+	return int64(len(r.entries)), nil
+}
+
+func (r *memoryAuditRepository) Walk(ctx context.Context, fn func(entry *domain.AuditEntry) bool) error {
+	for _, entry := range r.entries {
+		if !fn(entry) {
+			break
+		}
+	}
+	return nil
+}
+
+func recordedLog(t *testing.T, n int) *memoryAuditRepository {
+	t.Helper()
+	repo := &memoryAuditRepository{}
+	record := NewRecordAuditUseCase(repo)
+	for i := 0; i < n; i++ {
+		if _, err := record.Execute(context.Background(), RecordAuditCommand{
+			Actor:   "alice",
+			Action:  domain.AuditActionDownload,
+			FileID:  "f1",
+			Outcome: domain.AuditOutcomeSuccess,
+			Status:  200,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func verifyLog(t *testing.T, repo *memoryAuditRepository, anchors ...domain.AuditAnchor) *AuditVerification {
+	t.Helper()
+	result, err := NewVerifyAuditLogUseCase(repo).Execute(context.Background(), anchors...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestVerifyAuditLogAcceptsRecordedChain(t *testing.T) {
+	repo := recordedLog(t, 3)
+
+	result := verifyLog(t, repo)
+	if !result.Valid || result.Checked != 3 || result.LastHash != repo.entries[2].Hash {
+		t.Fatalf("got %+v, want a valid chain of 3 entries", result)
+	}
+}
+
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(repo *memoryAuditRepository)
+	}{
+		{"edited field", func(repo *memoryAuditRepository) { repo.entries[1].Actor = "mallory" }},
+		{"removed entry", func(repo *memoryAuditRepository) {
+			repo.entries = append(repo.entries[:1], repo.entries[2:]...)
+		}},
+		{"downgraded hash format", func(repo *memoryAuditRepository) {
+			entry := repo.entries[2]
+			entry.HashVersion = 0
+			entry.Hash = entry.ComputeHash()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := recordedLog(t, 3)
+			tt.tamper(repo)
+
+			if result := verifyLog(t, repo); result.Valid {
+				t.Fatal("tampered chain verified")
+			}
+		})
+	}
+}
+
+func TestVerifyAuditLogDetectsTruncationWithAnchor(t *testing.T) {
+	repo := recordedLog(t, 3)
+	head := domain.AuditAnchor{Sequence: 3, Hash: repo.entries[2].Hash}
+	repo.entries = repo.entries[:2]
+
+	if result := verifyLog(t, repo); !result.Valid {
+		t.Fatalf("truncated chain without anchor: got %+v, want valid", result)
+	}
+	if result := verifyLog(t, repo, head); result.Valid || result.BrokenAt != 3 {
+		t.Fatalf("truncated chain with anchor: got %+v, want broken at 3", result)
+	}
+}
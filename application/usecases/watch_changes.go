@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type WatchChangesUseCase struct {
+	feed domain.ChangeFeed
+}
+
+func NewWatchChangesUseCase(feed domain.ChangeFeed) *WatchChangesUseCase {
+	return &WatchChangesUseCase{feed: feed}
+}
+
+func (uc *WatchChangesUseCase) Execute(ctx context.Context, cursor string, fn func(change domain.Change) error) error {
+	return uc.feed.Watch(ctx, cursor, fn)
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionUpload   AuditAction = "file.upload"
+	AuditActionRead     AuditAction = "file.read"
+	AuditActionDownload AuditAction = "file.download"
+	AuditActionDelete   AuditAction = "file.delete"
+	AuditActionShare    AuditAction = "file.share"
+	AuditActionRevoke   AuditAction = "share.revoke"
+	AuditActionExport   AuditAction = "file.export"
+	AuditActionMove     AuditAction = "file.move"
+	AuditActionUpdate   AuditAction = "file.update"
+
+	AuditActionCollectionCreate AuditAction = "collection.create"
+	AuditActionCollectionUpdate AuditAction = "collection.update"
+	AuditActionCollectionDelete AuditAction = "collection.delete"
+)
+
+// OnCollection reports whether the action changes a collection rather than
+// a file.
+func (a AuditAction) OnCollection() bool {
+	return strings.HasPrefix(string(a), "collection.")
+}
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditEntry is a single record in the append-only audit log. Entries form a
+// hash chain: each Hash covers the entry's fields and the Hash of the entry
+// before it, so editing or removing any entry breaks the chain.
+type AuditEntry struct {
+	Sequence int64
+	Actor    string
+	Action   AuditAction
+	FileID   string
+	// CollectionID is set for actions on collections.
+	CollectionID string
+	IP           string
+	Timestamp    time.Time
+	Outcome      AuditOutcome
+	Status       int
+	// Changes lists what the action changed, for actions that modify a file or
+	// collection.
+	Changes []AuditChange
+	// HashVersion selects the hash payload format; see ComputeHash.
+	HashVersion int
+	PrevHash    string
+	Hash        string
+}
+
+// AuditChange is one field changed by an audited action.
+type AuditChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// FileChanges lists the fields that differ between two states of a file.
+func FileChanges(before, after *File) []AuditChange {
+	var changes []AuditChange
+	for _, field := range []struct {
+		name     string
+		from, to string
+	}{
+		{"name", before.Name, after.Name},
+		{"description", before.Description, after.Description},
+		{"tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ",")},
+		{"category", before.Category, after.Category},
+		{"collection_id", before.CollectionID, after.CollectionID},
+	} {
+		if field.from != field.to {
+			changes = append(changes, AuditChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	return changes
+}
+
+// CollectionChanges lists the fields that differ between two states of a
+// collection.
+func CollectionChanges(before, after *Collection) []AuditChange {
+	var changes []AuditChange
+	if before.Name != after.Name {
+		changes = append(changes, AuditChange{Field: "name", From: before.Name, To: after.Name})
+	}
+	if before.ParentID != after.ParentID {
+		changes = append(changes, AuditChange{Field: "parent_id", From: before.ParentID, To: after.ParentID})
+	}
+	return changes
+}
+
+type AuditFilter struct {
+	Actor  string
+	Action AuditAction
+	FileID string
+}
+
+// AuditHashVersion is the hash payload format of new entries. Version 0
+// joined fields with "|" unescaped, so different entries could share a
+// payload; it is only kept to verify entries written before version 1.
+// Version 2 adds the collection ID.
+const AuditHashVersion = 2
+
+// ComputeHash returns the chain hash of the entry. The timestamp is truncated
+// to milliseconds so the hash survives a round trip through MongoDB.
+func (e *AuditEntry) ComputeHash() string {
+	var payload string
+	if e.HashVersion == 0 {
+		payload = e.legacyPayload()
+	} else {
+		payload = e.payload()
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// payload encodes every field with its length, so no two entries share a
+// payload whatever their values contain.
+func (e *AuditEntry) payload() string {
+	var b strings.Builder
+	field := func(value string) {
+		fmt.Fprintf(&b, "%d:%s", len(value), value)
+	}
+	field("v" + strconv.Itoa(e.HashVersion))
+	field(strconv.FormatInt(e.Sequence, 10))
+	field(e.Timestamp.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano))
+	field(e.Actor)
+	field(string(e.Action))
+	field(e.FileID)
+	if e.HashVersion >= 2 {
+		field(e.CollectionID)
+	}
+	field(e.IP)
+	field(string(e.Outcome))
+	field(strconv.Itoa(e.Status))
+	field(e.PrevHash)
+	field(strconv.Itoa(len(e.Changes)))
+	for _, change := range e.Changes {
+		field(change.Field)
+		field(change.From)
+		field(change.To)
+	}
+	return b.String()
+}
+
+func (e *AuditEntry) legacyPayload() string {
+	payload := fmt.Sprintf("%d|%s|%s|%s|%s|%s|%s|%d|%s",
+		e.Sequence,
+		e.Timestamp.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+		e.Actor,
+		e.Action,
+		e.FileID,
+		e.IP,
+		e.Outcome,
+		e.Status,
+		e.PrevHash,
+	)
+	for _, change := range e.Changes {
+		payload += fmt.Sprintf("|%q=%q>%q", change.Field, change.From, change.To)
+	}
+	return payload
+}
+
+// AuditAnchor is a chain position recorded outside the database. Removing
+// entries from the end of the chain leaves a valid shorter chain, which only
+// a copy of a later head kept elsewhere reveals.
+type AuditAnchor struct {
+	Sequence int64
+	Hash     string
+}
+
+// ParseAuditAnchor parses an anchor written as "<seq>:<hash>", the form
+// verification reports the head of the chain in.
+func ParseAuditAnchor(value string) (AuditAnchor, error) {
+	seq, hash, ok := strings.Cut(value, ":")
+	sequence, err := strconv.ParseInt(seq, 10, 64)
+	if !ok || err != nil || sequence < 1 || hash == "" {
+		return AuditAnchor{}, invalidField("anchor", "anchor", "anchor must be <sequence>:<hash>", "anchor")
+	}
+	return AuditAnchor{Sequence: sequence, Hash: hash}, nil
+}
+
+var ErrAuditSequenceConflict = errors.New("audit sequence already taken")
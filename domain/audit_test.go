@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditEntryHashSeparatesFields(t *testing.T) {
+	at := time.Date(2025, 4, 16, 10, 0, 0, 0, time.UTC)
+	a := &AuditEntry{Sequence: 1, Timestamp: at, Actor: "alice|file.read", Action: AuditActionUpload, HashVersion: AuditHashVersion}
+	b := &AuditEntry{Sequence: 1, Timestamp: at, Actor: "alice", Action: "file.read|file.upload", HashVersion: AuditHashVersion}
+
+	// The unescaped legacy payload could not tell these apart
+	if a.legacyPayload() != b.legacyPayload() {
+		t.Fatal("legacy payloads differ; the test no longer covers a collision")
+	}
+	if a.ComputeHash() == b.ComputeHash() {
+		t.Fatal("entries with different fields have the same hash")
+	}
+}
+
+func TestAuditEntryHashCoversChanges(t *testing.T) {
+	entry := &AuditEntry{
+		Sequence:    2,
+		Action:      AuditActionUpdate,
+		Changes:     []AuditChange{{Field: "name", From: "a", To: "b"}},
+		HashVersion: AuditHashVersion,
+	}
+	hash := entry.ComputeHash()
+
+	entry.Changes[0].To = "c"
+	if entry.ComputeHash() == hash {
+		t.Fatal("changing a recorded change does not change the hash")
+	}
+}
+
+func TestAuditEntryLegacyHashIsStable(t *testing.T) {
+	// Entries written before hash versions existed must keep verifying
+	entry := &AuditEntry{
+		Sequence:  1,
+		Timestamp: time.Date(2025, 4, 16, 10, 0, 0, 0, time.UTC),
+		Actor:     "alice",
+		Action:    AuditActionUpload,
+		FileID:    "f1",
+		IP:        "10.0.0.1",
+		Outcome:   AuditOutcomeSuccess,
+		Status:    201,
+	}
+	const want = "1|2025-04-16T10:00:00Z|alice|file.upload|f1|10.0.0.1|success|201|"
+	if got := entry.legacyPayload(); got != want {
+		t.Fatalf("legacy payload = %q, want %q", got, want)
+	}
+}
+
+func TestParseAuditAnchor(t *testing.T) {
+	anchor, err := ParseAuditAnchor("12:abc")
+	if err != nil || anchor.Sequence != 12 || anchor.Hash != "abc" {
+		t.Fatalf("ParseAuditAnchor(12:abc) = %+v, %v", anchor, err)
+	}
+	for _, value := range []string{"", "abc", "0:abc", "x:abc", "12:"} {
+		if _, err := ParseAuditAnchor(value); err == nil {
+			t.Errorf("ParseAuditAnchor(%q) succeeded", value)
+		}
+	}
+}
+
+func TestAuditEntryHashCoversCollection(t *testing.T) {
+	entry := &AuditEntry{Sequence: 3, Action: AuditActionCollectionDelete, CollectionID: "c1", HashVersion: AuditHashVersion}
+	hash := entry.ComputeHash()
+
+	entry.CollectionID = "c2"
+	if entry.ComputeHash() == hash {
+		t.Fatal("changing the collection does not change the hash")
+	}
+
+	// Version 1 entries were hashed without the collection
+	entry.HashVersion = 1
+	hash = entry.ComputeHash()
+	entry.CollectionID = ""
+	if entry.ComputeHash() != hash {
+		t.Fatal("the collection changes the hash of a version 1 entry")
+	}
+}
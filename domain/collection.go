@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxCollectionNameLength is the longest collection name, in characters.
+const MaxCollectionNameLength = 255
+
+// Collection groups files, and other collections, to mirror a classification
+// scheme such as fonds, series and file. Ancestors holds the IDs of the
+// collections above it, from the top level down to its parent.
+type Collection struct {
+	ID        string
+	Name      string
+	ParentID  string
+	Ancestors []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Path leads from the top level to the collection itself. It is only
+	// filled in for responses.
+	Path []Breadcrumb
+}
+
+// Breadcrumb is one step of the path to a collection.
+type Breadcrumb struct {
+	ID   string
+	Name string
+}
+
+// Contains reports whether id is the collection itself or one above it.
+func (c *Collection) Contains(id string) bool {
+	if c.ID == id {
+		return true
+	}
+	for _, ancestor := range c.Ancestors {
+		if ancestor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCollectionName rejects empty and overlong names, and names with
+// slashes or control characters, which would make paths ambiguous.
+func ValidateCollectionName(name string) error {
+	field := func(code, message string, params ...string) error {
+		return ErrInvalidRequest.WithFields(FieldError{Field: "name", Code: code, Message: message, Params: params})
+	}
+	if strings.TrimSpace(name) == "" {
+		return field("required", "name is required", "name")
+	}
+	if utf8.RuneCountInString(name) > MaxCollectionNameLength {
+		return field("max_length", fmt.Sprintf("name must be at most %d characters", MaxCollectionNameLength),
+			"name", strconv.Itoa(MaxCollectionNameLength))
+	}
+	if strings.ContainsAny(name, "/\\") || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return field("collection_name", "name must not contain slashes or control characters", "name")
+	}
+	return nil
+}
+
+var (
+	ErrCollectionNotFound  = NewError(KindNotFound, "collection_not_found", "collection not found")
+	ErrCollectionNameTaken = NewError(KindConflict, "collection_name_taken", "a collection with this name already exists here")
+	ErrCollectionNotEmpty  = NewError(KindConflict, "collection_not_empty", "collection still contains files or collections")
+	ErrCollectionCycle     = NewError(KindInvalid, "collection_cycle", "a collection cannot be moved into itself or one of its descendants")
+)
@@ -0,0 +1,68 @@
+package domain
+
+import "errors"
+
+// ErrorKind classifies an Error so that each adapter can map it to its own
+// status codes.
+type ErrorKind string
+
+const (
+	KindInvalid            ErrorKind = "invalid"
+	KindNotFound           ErrorKind = "not_found"
+	KindConflict           ErrorKind = "conflict"
+	KindPreconditionFailed ErrorKind = "precondition_failed"
+	KindUnauthorized       ErrorKind = "unauthorized"
+	KindForbidden          ErrorKind = "forbidden"
+	KindGone               ErrorKind = "gone"
+	KindTooLarge           ErrorKind = "too_large"
+	KindUnsupported        ErrorKind = "unsupported"
+	KindUnprocessable      ErrorKind = "unprocessable"
+	KindQuotaExceeded      ErrorKind = "quota_exceeded"
+)
+
+// Error is an error the API reports to clients. Code is stable and machine
+// readable; Message is for humans and may change.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError describes why one input field was rejected. Params are the
+// values substituted into localized versions of Message.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+	Params  []string
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors by code, so that an error returned by WithFields still
+// matches the sentinel it was derived from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithFields returns a copy of e carrying field-level details.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	copied := *e
+	copied.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &copied
+}
+
+// AsError returns the Error in err's chain, if any.
+func AsError(err error) (*Error, bool) {
+	var domainErr *Error
+	ok := errors.As(err, &domainErr)
+	return domainErr, ok
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventFileUploaded EventType = "file.uploaded"
+	EventFileUpdated  EventType = "file.updated"
+	EventFileDeleted  EventType = "file.deleted"
+)
+
+// Event describes something that happened to the archive.
+type Event struct {
+	ID         string
+	Type       EventType
+	OccurredAt time.Time
+	FileID     string
+	Data       map[string]interface{}
+}
+
+func NewEvent(eventType EventType, fileID string, data map[string]interface{}) Event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		FileID:     fileID,
+		Data:       data,
+	}
+}
+
+// FileEventData is the payload of events describing a file's current state.
+func FileEventData(file *File) map[string]interface{} {
+	data := map[string]interface{}{
+		"name":         file.Name,
+		"size":         file.Size,
+		"content_type": file.ContentType,
+	}
+	if file.CollectionID != "" {
+		data["collection_id"] = file.CollectionID
+	}
+	if file.Description != "" {
+		data["description"] = file.Description
+	}
+	if len(file.Tags) > 0 {
+		data["tags"] = file.Tags
+	}
+	if file.Category != "" {
+		data["category"] = file.Category
+	}
+	return data
+}
+
+// EventSink is a destination the outbox dispatcher publishes events to.
+// Delivery is at-least-once: a sink may see the same event ID more than once.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
+
+// Change is an event observed on the archive's change feed. Cursor is an
+// opaque position that can be passed back to resume the feed after it.
+type Change struct {
+	Cursor string
+	Event  Event
+	// Reset marks a change without an event: the feed could not resume
+	// after the requested cursor and started from now instead, so changes
+	// in between may have been missed.
+	Reset bool
+}
+
+type ChangeFeed interface {
+	// Watch calls fn for every change after cursor (or from now when cursor
+	// is empty) until ctx is cancelled, fn returns an error, or the feed
+	// fails. A cursor it cannot resume from yields a reset change first.
+	Watch(ctx context.Context, cursor string, fn func(change Change) error) error
+}
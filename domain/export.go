@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// Export is a ZIP archive of many files built in the background, for
+// selections too large to stream within one request.
+type Export struct {
+	ID         string
+	Filter     FileFilter
+	Status     ExportStatus
+	Files      int
+	Missing    []string
+	Size       int64
+	Error      string
+	CreatedBy  string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	ExpiresAt  time.Time
+}
+
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportCompleted ExportStatus = "completed"
+	ExportFailed    ExportStatus = "failed"
+)
+
+func (e *Export) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// ArchiveEntry describes one file in an archive manifest.
+type ArchiveEntry struct {
+	ID          string
+	Name        string
+	Path        string
+	ContentType string
+	Size        int64
+	UploadDate  time.Time
+	SHA256      string
+}
+
+// ArchiveManifest lists the files written to an archive and the requested
+// files that could not be found.
+type ArchiveManifest struct {
+	CreatedAt time.Time
+	Files     []ArchiveEntry
+	Missing   []string
+}
+
+var (
+	ErrExportNotFound  = NewError(KindNotFound, "export_not_found", "export not found")
+	ErrExportNotReady  = NewError(KindConflict, "export_not_ready", "export is not ready yet")
+	ErrExportFailed    = NewError(KindConflict, "export_failed", "export failed")
+	ErrArchiveEmpty    = NewError(KindNotFound, "archive_empty", "no files match the selection")
+	ErrArchiveTooLarge = NewError(KindTooLarge, "archive_too_many_files", "too many files for a direct download, request an asynchronous export")
+)
@@ -1,9 +1,6 @@
 package domain
 
-import (
-	"errors"
-	"time"
-)
+import "time"
 
 type File struct {
 	ID          string
@@ -11,10 +8,66 @@ type File struct {
 	Size        int64
 	ContentType string
 	UploadDate  time.Time
+	// Description, Tags and Category describe the file and can be changed
+	// after upload.
+	Description string
+	Tags        []string
+	Category    string
+	// Version counts the changes made to the file since upload. It is 0 for
+	// files that were never changed.
+	Version int64
+	// CollectionID is empty for files outside any collection.
+	CollectionID string
+	// SHA256 is the hex checksum of the content. It is computed by a
+	// background job after upload and empty until then.
+	SHA256 string
+	// Path leads from the top level to the file's collection. It is derived
+	// from the collections and only filled in for responses.
+	Path []Breadcrumb
+}
+
+// FileCursor is a position in the file listing, which is ordered by upload
+// date and then ID, newest first.
+type FileCursor struct {
+	UploadDate time.Time
+	ID         string
+}
+
+// FileFilter narrows the file listing. Zero fields match every file.
+type FileFilter struct {
+	IDs          []string
+	ContentType  string
+	UploadedFrom time.Time
+	UploadedTo   time.Time
+	// CollectionIDs matches files in any of the collections.
+	CollectionIDs []string
+}
+
+// FilePageQuery selects up to Limit files matching Filter after or before a
+// cursor. Without a cursor it selects the newest files.
+type FilePageQuery struct {
+	Filter FileFilter
+	After  *FileCursor
+	Before *FileCursor
+	Limit  int64
+}
+
+type ArchiveStats struct {
+	Files int64
+	Bytes int64
 }
 
 // success
 var Success = "success"
 
 // Error
-var ErrFileNotFound = errors.New("file not found")
+var (
+	ErrFileNotFound   = NewError(KindNotFound, "file_not_found", "file not found")
+	ErrFileRequired   = NewError(KindInvalid, "file_required", "file is required")
+	ErrFileTooLarge   = NewError(KindTooLarge, "file_too_large", "file is too large")
+	ErrFileType       = NewError(KindUnsupported, "file_type_not_allowed", "file type not allowed")
+	ErrQuotaExceeded  = NewError(KindQuotaExceeded, "storage_quota_exceeded", "storage quota exceeded")
+	ErrInvalidRequest = NewError(KindInvalid, "invalid_request", "invalid request")
+	ErrInvalidCursor  = NewError(KindInvalid, "invalid_cursor", "invalid pagination cursor")
+	ErrFileModified   = NewError(KindPreconditionFailed, "file_modified", "file has been modified since it was read")
+)
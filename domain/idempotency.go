@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord remembers a request made with an idempotency key and,
+// once it completed, the response to replay when the request is retried.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+var (
+	ErrIdempotencyKeyReused  = NewError(KindUnprocessable, "idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = NewError(KindConflict, "idempotency_request_in_progress", "a request with this idempotency key is still in progress")
+)
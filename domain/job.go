@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	// JobPending jobs wait for RunAt, JobRunning ones are leased by a
+	// worker until LockedUntil. Jobs that fail MaxAttempts times are dead:
+	// they stay in the queue until an operator retries them.
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead"
+)
+
+// Job is a unit of background work, run by the handler registered for its
+// Type with the decoded Payload.
+type Job struct {
+	ID          string
+	Type        string
+	Payload     map[string]interface{}
+	Status      JobStatus
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedBy    string
+	LockedUntil time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  time.Time
+	// ExpiresAt is when a succeeded job is removed from the queue.
+	ExpiresAt time.Time
+}
+
+// JobFilter narrows the job listing. Zero fields match every job.
+type JobFilter struct {
+	Type   string
+	Status JobStatus
+}
+
+// JobHandler runs the jobs of one type. Handlers must stop when ctx is
+// cancelled, which happens on shutdown and when the job's lease is lost.
+// A job may run more than once, so handlers should be idempotent.
+type JobHandler interface {
+	Type() string
+	Handle(ctx context.Context, job *Job) error
+}
+
+var (
+	JobInitialBackoff = 30 * time.Second
+	JobMaxBackoff     = time.Hour
+)
+
+// JobBackoff returns the delay before the next attempt after the given
+// number of failed attempts.
+func JobBackoff(attempts int) time.Duration {
+	backoff := JobInitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= JobMaxBackoff {
+			return JobMaxBackoff
+		}
+	}
+	return backoff
+}
+
+var (
+	ErrJobNotFound     = NewError(KindNotFound, "job_not_found", "job not found")
+	ErrJobNotRetryable = NewError(KindConflict, "job_not_retryable", "only dead jobs can be retried")
+	// ErrJobLeaseLost is returned when a worker no longer holds the lease of
+	// a job, because it expired and another worker claimed the job.
+	ErrJobLeaseLost = errors.New("job lease lost")
+)
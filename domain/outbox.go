@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	// OutboxStaged events are written before the change they describe. They
+	// are committed once the change succeeds, or resolved by the recovery
+	// job if the process died in between.
+	OutboxStaged    OutboxStatus = "staged"
+	OutboxReady     OutboxStatus = "ready"
+	OutboxPublished OutboxStatus = "published"
+)
+
+type OutboxEntry struct {
+	Event         Event
+	Status        OutboxStatus
+	DeliveredTo   []string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+// Delivered reports whether the event already reached the named sink.
+func (e *OutboxEntry) Delivered(sink string) bool {
+	for _, name := range e.DeliveredTo {
+		if name == sink {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	OutboxInitialBackoff = 5 * time.Second
+	OutboxMaxBackoff     = 10 * time.Minute
+)
+
+func OutboxBackoff(attempts int) time.Duration {
+	backoff := OutboxInitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= OutboxMaxBackoff {
+			return OutboxMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -1,10 +1,187 @@
 package domain
 
-import "io"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type FileRepository interface {
-	Save(file *File, content io.Reader) error
-	FindByID(id string) (*File, io.ReadCloser, error)
-	FindAll(skip, limit int64) ([]*File, error)
-	Count() (int64, error)
+	// NextID reserves an ID for a file that is about to be saved.
+	NextID() string
+	// Save stores the file under file.ID when it is set, or a new ID otherwise.
+	Save(ctx context.Context, file *File, content io.Reader) error
+	FindByID(ctx context.Context, id string) (*File, io.ReadCloser, error)
+	FindAll(ctx context.Context, filter FileFilter, skip, limit int64) ([]*File, error)
+	// FindPage returns files in listing order, also when paging backwards.
+	FindPage(ctx context.Context, query FilePageQuery) ([]*File, error)
+	Count(ctx context.Context, filter FileFilter) (int64, error)
+	// UpdateMetadata stores the name, descriptive metadata and collection of
+	// an existing file and increments its version. It returns ErrFileModified
+	// when the stored version is no longer file.Version.
+	UpdateMetadata(ctx context.Context, file *File) error
+	// SetChecksum records the SHA-256 checksum of the file's content.
+	SetChecksum(ctx context.Context, id, sha256 string) error
+	Delete(ctx context.Context, id string) error
+	Exists(ctx context.Context, id string) (bool, error)
+	Stats(ctx context.Context) (*ArchiveStats, error)
+}
+
+type CollectionRepository interface {
+	// Save and Update return ErrCollectionNameTaken when the parent already
+	// has a collection with the same name, ignoring case.
+	Save(ctx context.Context, collection *Collection) error
+	Update(ctx context.Context, collection *Collection) error
+	FindByID(ctx context.Context, id string) (*Collection, error)
+	// FindByIDs returns the collections that exist, in no particular order.
+	FindByIDs(ctx context.Context, ids []string) ([]*Collection, error)
+	// FindChildren returns the collections directly below parentID, or the
+	// top-level ones when parentID is empty, ordered by name.
+	FindChildren(ctx context.Context, parentID string) ([]*Collection, error)
+	FindDescendantIDs(ctx context.Context, id string) ([]string, error)
+	// MoveDescendants replaces the ancestors above id, oldAncestors, with
+	// newAncestors in every collection below id.
+	MoveDescendants(ctx context.Context, id string, oldAncestors, newAncestors []string) error
+	// Lock writes to the collection, so that transactions that lock or
+	// delete it at the same time conflict. It returns ErrCollectionNotFound
+	// when the collection does not exist (anymore).
+	Lock(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Transactor runs fn in a transaction: the repository calls fn makes with
+// the context it is given take effect together or not at all. fn may run
+// more than once when the transaction conflicts with another one.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ExportRepository interface {
+	Save(ctx context.Context, export *Export) error
+	Update(ctx context.Context, export *Export) error
+	FindByID(ctx context.Context, id string) (*Export, error)
+	FindExpired(ctx context.Context, now time.Time) ([]*Export, error)
+	// Delete removes the export and its archive.
+	Delete(ctx context.Context, id string) error
+	// WriteContent stores the archive of an export and returns its size.
+	WriteContent(ctx context.Context, id string, content io.Reader) (int64, error)
+	OpenContent(ctx context.Context, id string) (io.ReadCloser, error)
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores record unless an unexpired record with the same key
+	// exists, in which case it returns that record instead.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete replaces the uncompleted record with the key and fingerprint
+	// of record, returning ErrIdempotencyInProgress when there is none.
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+type AuditRepository interface {
+	// Last returns the most recent entry, or nil when the log is empty.
+	Last(ctx context.Context) (*AuditEntry, error)
+	// Append inserts the entry; it returns ErrAuditSequenceConflict when an
+	// entry with the same sequence already exists.
+	Append(ctx context.Context, entry *AuditEntry) error
+	FindAll(ctx context.Context, filter AuditFilter, skip, limit int64) ([]*AuditEntry, error)
+	Count(ctx context.Context, filter AuditFilter) (int64, error)
+	// Walk calls fn for every entry in sequence order until fn returns false.
+	Walk(ctx context.Context, fn func(entry *AuditEntry) bool) error
+}
+
+type ShareLinkRepository interface {
+	Save(ctx context.Context, link *ShareLink) error
+	FindByID(ctx context.Context, id string) (*ShareLink, error)
+	FindByFileID(ctx context.Context, fileID string) ([]*ShareLink, error)
+	// IncrementDownloads atomically counts one download, returning
+	// ErrShareLinkExhausted when the limit was already reached.
+	IncrementDownloads(ctx context.Context, id string) error
+	Revoke(ctx context.Context, id string) error
+}
+
+type UserRepository interface {
+	// Save stores a new user, returning ErrUserNameTaken when the name is in
+	// use.
+	Save(ctx context.Context, user *User) error
+	FindByTokenHash(ctx context.Context, hash string) (*User, error)
+	DeleteByName(ctx context.Context, name string) error
+}
+
+type WebhookRepository interface {
+	Save(ctx context.Context, webhook *Webhook) error
+	FindByID(ctx context.Context, id string) (*Webhook, error)
+	FindAll(ctx context.Context) ([]*Webhook, error)
+	FindByEvent(ctx context.Context, eventType EventType) ([]*Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	Save(ctx context.Context, delivery *WebhookDelivery) error
+	Update(ctx context.Context, delivery *WebhookDelivery) error
+	FindByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	FindByWebhookID(ctx context.Context, webhookID string, skip, limit int64) ([]*WebhookDelivery, error)
+	CountByWebhookID(ctx context.Context, webhookID string) (int64, error)
+	// ClaimDue returns up to limit pending deliveries whose next attempt is
+	// due, pushing their next attempt back by lease so that other instances
+	// do not pick them up at the same time.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+}
+
+// WebhookSender performs a single HTTP delivery attempt and returns the
+// response status code.
+type WebhookSender interface {
+	Send(ctx context.Context, webhook *Webhook, delivery *WebhookDelivery) (int, error)
+}
+
+// WebhookHostChecker rejects webhook hosts the service must not call.
+type WebhookHostChecker interface {
+	CheckHost(ctx context.Context, host string) error
+}
+
+type OutboxRepository interface {
+	Stage(ctx context.Context, event Event) error
+	// Commit marks a staged event ready for dispatch, replacing its data.
+	// An event whose staged entry is gone is added ready; one that was
+	// committed already is left as it is.
+	Commit(ctx context.Context, event Event) error
+	Discard(ctx context.Context, eventID string) error
+	FindStaged(ctx context.Context, olderThan time.Time, limit int64) ([]*OutboxEntry, error)
+	// ClaimReady returns up to limit ready entries that are due, pushing
+	// their next attempt back by lease.
+	ClaimReady(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxEntry, error)
+	MarkDelivered(ctx context.Context, eventID, sink string) error
+	MarkPublished(ctx context.Context, eventID string) error
+	Reschedule(ctx context.Context, eventID string, attempts int, next time.Time, lastError string) error
+}
+
+type JobRepository interface {
+	Save(ctx context.Context, job *Job) error
+	FindByID(ctx context.Context, id string) (*Job, error)
+	// FindAll returns jobs newest first.
+	FindAll(ctx context.Context, filter JobFilter, skip, limit int64) ([]*Job, error)
+	Count(ctx context.Context, filter JobFilter) (int64, error)
+	// Claim leases the next due job of one of the types to worker until
+	// now+lease, counting an attempt. Jobs whose lease expired are due
+	// again. It returns nil when no job is due.
+	Claim(ctx context.Context, types []string, worker string, now time.Time, lease time.Duration) (*Job, error)
+	// Heartbeat extends the lease worker holds on a job, returning
+	// ErrJobLeaseLost when it no longer holds it.
+	Heartbeat(ctx context.Context, id, worker string, until time.Time) error
+	// Finish stores the status, attempts, next run and error of a job
+	// leased by worker and releases the lease, returning ErrJobLeaseLost
+	// when worker no longer holds it.
+	Finish(ctx context.Context, job *Job, worker string) error
+	// Retry makes a dead job pending again with fresh attempts, returning
+	// ErrJobNotRetryable when it is not dead.
+	Retry(ctx context.Context, id string, now time.Time) error
+}
+
+// StorageInspector finds and removes inconsistencies in GridFS storage.
+type StorageInspector interface {
+	FindOrphanChunks(ctx context.Context) ([]*StorageIssue, error)
+	FindIncompleteFiles(ctx context.Context) ([]*StorageIssue, error)
+	// DeleteOrphanChunks removes the chunks of fileID unless a files
+	// document for it exists, and returns how many were removed.
+	DeleteOrphanChunks(ctx context.Context, fileID string) (int64, error)
 }
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// ShareLink grants access to a single file through a signed download URL,
+// without an account.
+type ShareLink struct {
+	ID           string
+	FileID       string
+	CreatedBy    string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	MaxDownloads int64 // 0 means unlimited
+	Downloads    int64
+	PasswordHash string
+	Revoked      bool
+}
+
+var (
+	DefaultShareLinkTTL = 24 * time.Hour
+	MaxShareLinkTTL     = 30 * 24 * time.Hour
+)
+
+func (l *ShareLink) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+func (l *ShareLink) Exhausted() bool {
+	return l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads
+}
+
+func (l *ShareLink) PasswordProtected() bool {
+	return l.PasswordHash != ""
+}
+
+var (
+	ErrShareLinkNotFound         = NewError(KindNotFound, "share_link_not_found", "share link not found")
+	ErrShareLinkInvalidSignature = NewError(KindForbidden, "share_link_invalid", "share link signature is invalid")
+	ErrShareLinkExpired          = NewError(KindGone, "share_link_expired", "share link has expired")
+	ErrShareLinkRevoked          = NewError(KindGone, "share_link_revoked", "share link has been revoked")
+	ErrShareLinkExhausted        = NewError(KindGone, "share_link_exhausted", "share link download limit reached")
+	ErrShareLinkPasswordRequired = NewError(KindUnauthorized, "share_link_password_required", "share link password required")
+	ErrShareLinkPasswordInvalid  = NewError(KindUnauthorized, "share_link_password_invalid", "share link password is invalid")
+	ErrShareLinkInvalidTTL       = NewError(KindInvalid, "share_link_invalid_ttl", "share link expiry exceeds maximum allowed")
+)
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+type StorageIssueKind string
+
+const (
+	// StorageIssueOrphanChunks is a set of chunks whose files document does
+	// not exist, left by an upload that was never finalized or aborted.
+	StorageIssueOrphanChunks StorageIssueKind = "orphan_chunks"
+	// StorageIssueIncompleteFile is a files document whose chunks are
+	// missing or do not add up to its length.
+	StorageIssueIncompleteFile StorageIssueKind = "incomplete_file"
+)
+
+// StorageIssue describes one inconsistency between the GridFS files and
+// chunks collections.
+type StorageIssue struct {
+	Kind   StorageIssueKind
+	FileID string
+	// Name and Length come from the files document; they are empty for
+	// orphan chunks.
+	Name   string
+	Length int64
+	// Chunks and Bytes are what is actually stored in the chunks collection.
+	Chunks         int64
+	ExpectedChunks int64
+	Bytes          int64
+	// CreatedAt is when the upload started, taken from the file ID.
+	CreatedAt time.Time
+}
+
+// StorageReport is the result of one storage consistency check.
+type StorageReport struct {
+	CheckedAt  time.Time
+	Orphans    []*StorageIssue
+	Incomplete []*StorageIssue
+	// InProgress counts orphan chunk sets too recent to be told apart from
+	// uploads that are still being written; they are never touched.
+	InProgress     int
+	OrphanBytes    int64
+	DeletedOrphans int
+	ReclaimedBytes int64
+	DeletedFiles   int
+}
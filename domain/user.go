@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// User is an account of a client that calls the API directly instead of
+// through the authenticating proxy, such as a scanning station or a script.
+// It authenticates with a bearer token; only the token's hash is stored.
+type User struct {
+	ID        string
+	Name      string
+	TokenHash string
+	CreatedAt time.Time
+}
+
+// TokenPrefix starts every user token, so that bearer tokens meant for the
+// authenticating proxy are told apart from the archive's own.
+const TokenPrefix = "arc_"
+
+const MaxUserNameLength = 64
+
+// HashToken returns the hash a user token is stored and looked up by.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateUserName checks that name can be recorded as an actor in the audit
+// log: letters, digits and . _ @ - only, so it cannot be mistaken for the
+// cli: or share: actors.
+func ValidateUserName(name string) error {
+	field := func(code, message string, params ...string) error {
+		return ErrInvalidRequest.WithFields(FieldError{Field: "name", Code: code, Message: message, Params: params})
+	}
+	if name == "" {
+		return field("required", "name is required", "name")
+	}
+	if len(name) > MaxUserNameLength {
+		return field("max_length", fmt.Sprintf("name must be at most %d characters", MaxUserNameLength),
+			"name", strconv.Itoa(MaxUserNameLength))
+	}
+	valid := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._@-", r)
+	}
+	if strings.IndexFunc(name, func(r rune) bool { return !valid(r) }) >= 0 {
+		return field("user_name", "name may only contain letters, digits and . _ @ -", "name")
+	}
+	return nil
+}
+
+var (
+	ErrUserNotFound  = NewError(KindNotFound, "user_not_found", "user not found")
+	ErrUserNameTaken = NewError(KindConflict, "user_name_taken", "a user with this name already exists")
+	ErrInvalidToken  = NewError(KindUnauthorized, "invalid_token", "access token is invalid")
+)
@@ -3,10 +3,14 @@ package domain
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dustin/go-humanize"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
@@ -17,6 +21,19 @@ var (
 	}
 
 	MaxFileSize = 10 * 1024 * 1024 // Example: 10 MB
+
+	// StorageQuota limits the bytes stored in the archive; 0 means no limit.
+	StorageQuota int64
+)
+
+// Limits of the descriptive metadata of a file, in characters.
+const (
+	MaxFileNameLength    = 255
+	MaxExtensionLength   = 10
+	MaxDescriptionLength = 2000
+	MaxCategoryLength    = 100
+	MaxTagLength         = 50
+	MaxTags              = 20
 )
 
 func init() {
@@ -35,12 +52,12 @@ func validateFileName(fl validator.FieldLevel) bool {
 	}
 
 	// Prevent path traversal
-	if strings.ContainsAny(filename, "\\/:*?\"<>|") {
+	if strings.ContainsAny(filename, reservedFileNameChars) {
 		return false
 	}
 
 	// Limit extension length
-	if len(filepath.Ext(filename)) > 10 {
+	if len(filepath.Ext(filename)) > MaxExtensionLength {
 		return false
 	}
 
@@ -66,18 +83,160 @@ func ValidateUploadRequest(req FileUploadRequest) error {
 }
 
 func ValidateMimeType(contentType string) error {
-	return validate.Var(contentType, "mimetype")
+	if err := validate.Var(contentType, "mimetype"); err != nil {
+		return ErrFileType.WithFields(FieldError{
+			Field:   "file",
+			Code:    "mimetype",
+			Message: FormatValidationErrors(err)[0],
+			Params:  []string{strings.Join(AllowedMimeTypes, ", ")},
+		})
+	}
+	return nil
 }
 
 func ValidateFileSize(size int64) error {
 	if size > int64(MaxFileSize) {
-		return fmt.Errorf("file size exceeds maximum allowed: %s", humanize.Bytes(uint64(MaxFileSize)))
+		maxSize := humanize.IBytes(uint64(MaxFileSize))
+		return ErrFileTooLarge.WithFields(FieldError{
+			Field:   "file",
+			Code:    "max_size",
+			Message: fmt.Sprintf("file size exceeds maximum allowed: %s", maxSize),
+			Params:  []string{maxSize},
+		})
 	}
 	return nil
 }
 
+// ValidateQuota rejects an upload of size bytes that would take the archive,
+// holding used bytes, over StorageQuota.
+func ValidateQuota(used, size int64) error {
+	if StorageQuota <= 0 || used+size <= StorageQuota {
+		return nil
+	}
+	quota := humanize.IBytes(uint64(StorageQuota))
+	left := humanize.IBytes(uint64(max(StorageQuota-used, 0)))
+	return ErrQuotaExceeded.WithFields(FieldError{
+		Field:   "file",
+		Code:    "quota",
+		Message: fmt.Sprintf("storage quota of %s reached, %s left", quota, left),
+		Params:  []string{quota, left},
+	})
+}
+
+// reservedFileNameChars are refused in file names by common file systems.
+const reservedFileNameChars = "\\/:*?\"<>|"
+
+// SanitizeFileName turns the name a client uploaded a file under into one
+// that is safe to store and serve: any directory part is dropped, characters
+// file systems reserve are replaced with "_", and the result is normalized
+// and shortened to MaxFileNameLength characters, keeping the extension.
+func SanitizeFileName(name string) string {
+	if i := strings.LastIndexAny(name, "/\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(reservedFileNameChars, r) {
+			return '_'
+		}
+		return r
+	}, NormalizeFileName(name))
+
+	runes := []rune(name)
+	if len(runes) <= MaxFileNameLength {
+		return name
+	}
+	ext := []rune(filepath.Ext(name))
+	if len(ext) > MaxExtensionLength {
+		ext = nil
+	}
+	return strings.TrimSpace(string(runes[:MaxFileNameLength-len(ext)])) + string(ext)
+}
+
+// NormalizeFileName converts name to Unicode NFC, so that names that look the
+// same are stored the same, and removes control and bidirectional formatting
+// characters, which could break headers or disguise the extension.
+func NormalizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return -1
+		}
+		return r
+	}, norm.NFC.String(name))
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// ValidateFileName rejects empty names, names with path separators or
+// characters most file systems refuse, and unusually long extensions.
+func ValidateFileName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidField("name", "required", "name is required", "name")
+	}
+	if err := maxLength("name", name, MaxFileNameLength); err != nil {
+		return err
+	}
+	if err := validate.Var(name, "filename"); err != nil {
+		return invalidField("name", "filename", FormatValidationErrors(err)[0], "name")
+	}
+	return nil
+}
+
+func ValidateDescription(description string) error {
+	return maxLength("description", description, MaxDescriptionLength)
+}
+
+func ValidateCategory(category string) error {
+	return maxLength("category", category, MaxCategoryLength)
+}
+
+// NormalizeTags trims the tags and drops empty ones and repeats, ignoring
+// case, keeping the first spelling of each tag.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if err := maxLength("tags", tag, MaxTagLength); err != nil {
+			return nil, err
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, invalidField("tags", "max_count",
+			fmt.Sprintf("tags must have at most %d items", MaxTags), "tags", strconv.Itoa(MaxTags))
+	}
+	return normalized, nil
+}
+
+func maxLength(field, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return invalidField(field, "max_length",
+			fmt.Sprintf("%s must be at most %d characters", field, max), field, strconv.Itoa(max))
+	}
+	return nil
+}
+
+func invalidField(field, code, message string, params ...string) error {
+	return ErrInvalidRequest.WithFields(FieldError{Field: field, Code: code, Message: message, Params: params})
+}
+
+// FormatValidationErrors returns one message per rejected field. Errors that
+// carry no field details are returned as a single message.
 func FormatValidationErrors(err error) []string {
 	var errors []string
+	if domainErr, ok := AsError(err); ok && len(domainErr.Fields) > 0 {
+		for _, field := range domainErr.Fields {
+			errors = append(errors, field.Message)
+		}
+		return errors
+	}
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		for _, e := range validationErrors {
 			switch e.Tag() {
@@ -93,6 +252,10 @@ func FormatValidationErrors(err error) []string {
 				errors = append(errors, e.Param())
 			}
 		}
+		return errors
+	}
+	if err != nil {
+		errors = append(errors, err.Error())
 	}
 	return errors
 }
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"plain", "report.pdf", "report.pdf"},
+		{"unix path", "../../etc/passwd", "passwd"},
+		{"windows path", `C:\Users\budi\surat.pdf`, "surat.pdf"},
+		{"reserved characters", `a:b*c?"d"<e>|f.pdf`, "a_b_c__d__e__f.pdf"},
+		{"control characters", "re\x00po\nrt.pdf", "report.pdf"},
+		{"bidi override", "invoice\u202Efdp.exe", "invoicefdp.exe"},
+		{"decomposed accent", "Re\u0301sume\u0301.pdf", "Résumé.pdf"},
+		{"surrounding space", "  report.pdf  ", "report.pdf"},
+		{"dot dot", "..", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SanitizeFileName(tc.in); got != tc.want {
+				t.Errorf("SanitizeFileName(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFileNameKeepsExtensionWhenShortening(t *testing.T) {
+	got := SanitizeFileName(strings.Repeat("é", 300) + ".pdf")
+	if utf8.RuneCountInString(got) != MaxFileNameLength || !strings.HasSuffix(got, "é.pdf") {
+		t.Fatalf("got %d characters ending in %q", utf8.RuneCountInString(got), got[len(got)-8:])
+	}
+	if err := ValidateFileName(got); err != nil {
+		t.Fatalf("shortened name is invalid: %v", err)
+	}
+
+	// An extension too long to be one is cut like the rest of the name
+	got = SanitizeFileName(strings.Repeat("a", 300) + "." + strings.Repeat("b", 20))
+	if utf8.RuneCountInString(got) != MaxFileNameLength || strings.Contains(got, "b") {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestValidateFileName(t *testing.T) {
+	for _, name := range []string{"report.pdf", "Résumé 2024.pdf", "no extension"} {
+		if err := ValidateFileName(name); err != nil {
+			t.Errorf("ValidateFileName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "   ", "a/b.pdf", `a\b.pdf`, "a.verylongextension", strings.Repeat("a", MaxFileNameLength+1)} {
+		if err := ValidateFileName(name); err == nil {
+			t.Errorf("ValidateFileName(%q) accepted the name", name)
+		}
+	}
+}
+
+func TestValidateQuota(t *testing.T) {
+	defer func(previous int64) { StorageQuota = previous }(StorageQuota)
+
+	StorageQuota = 0
+	if err := ValidateQuota(1<<40, 1<<40); err != nil {
+		t.Errorf("without a quota: %v", err)
+	}
+
+	StorageQuota = 3 * 1024
+	for _, tc := range []struct {
+		used, size int64
+		left       string
+	}{
+		{used: 1024, size: 2048},
+		{used: 1024, size: 2049, left: "2.0 KiB"},
+		{used: 4096, size: 0, left: "0 B"},
+	} {
+		err := ValidateQuota(tc.used, tc.size)
+		if tc.left == "" {
+			if err != nil {
+				t.Errorf("ValidateQuota(%d, %d) = %v", tc.used, tc.size, err)
+			}
+			continue
+		}
+		domainErr, ok := AsError(err)
+		if !ok || domainErr.Code != ErrQuotaExceeded.Code || len(domainErr.Fields) != 1 ||
+			strings.Join(domainErr.Fields[0].Params, ",") != "3.0 KiB,"+tc.left {
+			t.Errorf("ValidateQuota(%d, %d) = %#v", tc.used, tc.size, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+type Webhook struct {
+	ID        string
+	URL       string
+	Events    []EventType
+	Secret    string
+	Active    bool
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Subscribes reports whether the webhook wants events of the given type. A
+// webhook without an event list receives everything.
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	ID        string
+	WebhookID string
+	EventID   string
+	EventType EventType
+	// RedeliveryOf is the ID of the delivery this one repeats; it is empty
+	// for the delivery of a dispatched event, of which there is one per
+	// webhook.
+	RedeliveryOf   string
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	ResponseStatus int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+var (
+	MaxWebhookAttempts    = 8
+	WebhookInitialBackoff = 30 * time.Second
+	WebhookMaxBackoff     = 6 * time.Hour
+)
+
+// WebhookBackoff returns the delay before the next attempt after the given
+// number of failed attempts.
+func WebhookBackoff(attempts int) time.Duration {
+	backoff := WebhookInitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= WebhookMaxBackoff {
+			return WebhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+var (
+	ErrWebhookNotFound         = NewError(KindNotFound, "webhook_not_found", "webhook not found")
+	ErrWebhookDeliveryNotFound = NewError(KindNotFound, "webhook_delivery_not_found", "webhook delivery not found")
+	ErrWebhookInvalidURL       = NewError(KindInvalid, "webhook_invalid_url", "webhook url must be an absolute http(s) url")
+	ErrWebhookUnknownEvent     = NewError(KindInvalid, "webhook_unknown_event", "unknown event type")
+	ErrWebhookForbiddenAddress = NewError(KindInvalid, "webhook_forbidden_address", "webhook url must not point to a loopback, link-local or private address")
+)
+
+// KnownEventTypes lists the events webhooks can subscribe to.
+var KnownEventTypes = []EventType{
+	EventFileUploaded,
+	EventFileUpdated,
+	EventFileDeleted,
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// gc reclaims storage: stale orphan chunks, expired exports and, when asked
+// to, files whose content is missing or truncated.
+func gc(args []string) error {
+	flags := newFlagSet("gc", "",
+		"Deletes chunks that have no files document and exports past their retention.\n"+
+			"Chunks newer than the stale upload threshold may belong to uploads in progress and are kept.")
+	dryRun := flags.Bool("dry-run", false, "only report what would be deleted")
+	deleteIncomplete := flags.Bool("delete-incomplete", false, "also delete files whose chunks are missing or truncated")
+	actor := actorFlag(flags)
+	cfg, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	ctx := context.Background()
+	report, err := cleanupStorage(db, cfg).Execute(ctx, usecases.CleanupStorageCommand{DeleteOrphans: !*dryRun})
+	if err != nil {
+		return err
+	}
+	if *deleteIncomplete && !*dryRun {
+		// Incomplete files cannot be repaired. They go through the regular
+		// delete, so file.deleted is emitted, and are audited like any delete.
+		deleteUC := usecases.NewDeleteFileUseCase(infrastructure.NewMongoFileRepository(db, cfg.Storage), infrastructure.NewMongoOutboxRepository(db))
+		recordAuditUC := usecases.NewRecordAuditUseCase(infrastructure.NewMongoAuditRepository(db))
+		for _, file := range report.Incomplete {
+			err := deleteUC.Execute(ctx, file.FileID)
+			if err == domain.ErrFileNotFound {
+				continue
+			}
+			audit(ctx, recordAuditUC, *actor, domain.AuditActionDelete, file.FileID, err)
+			if err != nil {
+				return err
+			}
+			report.DeletedFiles++
+		}
+	}
+	printStorageReport(report)
+	fmt.Printf("deleted %d orphan chunk sets (%d bytes) and %d incomplete files\n",
+		report.DeletedOrphans, report.ReclaimedBytes, report.DeletedFiles)
+
+	if !*dryRun {
+		deleted, err := usecases.NewCleanupExportsUseCase(infrastructure.NewMongoExportRepository(db, cfg.Export.Bucket)).Execute(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d expired exports\n", deleted)
+	}
+
+	if len(report.Orphans) > report.DeletedOrphans || len(report.Incomplete) > report.DeletedFiles {
+		return errors.New("storage issues remain")
+	}
+	return nil
+}
+
+// cleanupStorage builds the storage check shared by verify and gc.
+func cleanupStorage(db *mongo.Database, cfg *configs.Config) *usecases.CleanupStorageUseCase {
+	return usecases.NewCleanupStorageUseCase(infrastructure.NewMongoStorageInspector(db, cfg.Storage.Bucket), cfg.Storage.StaleUploadAfter)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+)
+
+// importFiles uploads local files in batches, with the same validation and
+// events as uploads through the API.
+func importFiles(args []string) error {
+	flags := newFlagSet("import", "path...",
+		"Uploads files, and the files below directories, as if they were sent to the batch upload endpoint.\n"+
+			"Files are checked against the upload size and content type limits; hidden files are skipped.")
+	collectionID := flags.String("collection", "", "ID of the collection to file the uploads in")
+	actor := actorFlag(flags)
+	cfg, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no files to import")
+	}
+
+	domain.MaxFileSize = int(cfg.Upload.MaxFileSize)
+	domain.AllowedMimeTypes = cfg.Upload.AllowedMimeTypes
+
+	items, paths, err := importItems(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	fileRepo := infrastructure.NewMongoFileRepository(db, cfg.Storage)
+	uploadUC := usecases.NewUploadFileUseCase(fileRepo, infrastructure.NewMongoCollectionRepository(db), infrastructure.NewMongoOutboxRepository(db))
+	batchUC := usecases.NewBatchUploadUseCase(uploadUC, cfg.Upload.BatchMaxFiles, cfg.Upload.BatchConcurrency)
+	recordAuditUC := usecases.NewRecordAuditUseCase(infrastructure.NewMongoAuditRepository(db))
+
+	ctx := context.Background()
+	imported, failed := 0, 0
+	for start := 0; start < len(items); start += cfg.Upload.BatchMaxFiles {
+		end := min(start+cfg.Upload.BatchMaxFiles, len(items))
+		results, err := batchUC.Execute(ctx, usecases.BatchUploadCommand{
+			Items:        items[start:end],
+			CollectionID: *collectionID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, result := range results {
+			if result.Err != nil {
+				fmt.Printf("failed    %s: %v\n", paths[start+i], result.Err)
+				failed++
+				continue
+			}
+			fmt.Printf("imported  %s  %s\n", result.File.ID, paths[start+i])
+			audit(ctx, recordAuditUC, *actor, domain.AuditActionUpload, result.File.ID, nil)
+			imported++
+		}
+	}
+
+	fmt.Printf("%d files imported, %d failed\n", imported, failed)
+	if failed > 0 {
+		return errors.Errorf("%d files could not be imported", failed)
+	}
+	return nil
+}
+
+// importItems lists the files to import, walking directories. It returns
+// the local path of each item alongside it.
+func importItems(args []string) ([]usecases.BatchUploadItem, []string, error) {
+	var items []usecases.BatchUploadItem
+	var paths []string
+	for _, arg := range args {
+		err := filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			hidden := path != arg && strings.HasPrefix(entry.Name(), ".")
+			if entry.IsDir() {
+				if hidden {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if hidden || !entry.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			items = append(items, usecases.BatchUploadItem{
+				Name:        entry.Name(),
+				ContentType: localContentType(path),
+				Size:        info.Size(),
+				Open:        func() (io.ReadCloser, error) { return os.Open(path) },
+			})
+			paths = append(paths, path)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to list files to import")
+		}
+	}
+	return items, paths, nil
+}
+
+// localContentType derives the content type from the file extension, as
+// batch uploads from ZIP archives do.
+func localContentType(path string) string {
+	contentType, _, _ := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(path)))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
@@ -1,7 +1,371 @@
 package configs
 
-// Add to existing config.go
-func InitializeConfig() {
-	LoadEnv()
-	InitializeLogger()
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config holds every setting of the service. It is loaded once at startup by
+// Load and passed down to the components that need it.
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Mongo       MongoConfig       `yaml:"mongo" toml:"mongo"`
+	Storage     StorageConfig     `yaml:"storage" toml:"storage"`
+	Upload      UploadConfig      `yaml:"upload" toml:"upload"`
+	Export      ExportConfig      `yaml:"export" toml:"export"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Jobs        JobsConfig        `yaml:"jobs" toml:"jobs"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	Share       ShareConfig       `yaml:"share" toml:"share"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown" toml:"shutdown"`
+}
+
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port"`
+	// PublicURL is the address clients reach the API at, used in links.
+	// When empty, links use the address of each request.
+	PublicURL string `yaml:"public_url" toml:"public_url"`
+	// TrustProxy makes links honor X-Forwarded-Proto and X-Forwarded-Host.
+	// Only enable it behind a proxy that sets these headers.
+	TrustProxy bool `yaml:"trust_proxy" toml:"trust_proxy"`
+	// DefaultLanguage is used for error messages when the client sends no
+	// supported Accept-Language: "en" or "id".
+	DefaultLanguage string `yaml:"default_language" toml:"default_language"`
+	// SwaggerUIDir is a copy of the swagger-ui-dist package to serve the API
+	// documentation from. When empty, the UI loads its assets from unpkg.
+	SwaggerUIDir string `yaml:"swagger_ui_dir" toml:"swagger_ui_dir"`
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri" toml:"uri"`
+	Database string `yaml:"database" toml:"database"`
+	// OperationTimeout bounds every MongoDB operation that is not already
+	// bound by a shorter request deadline.
+	OperationTimeout time.Duration `yaml:"operation_timeout" toml:"operation_timeout"`
+}
+
+type StorageConfig struct {
+	Bucket          string        `yaml:"bucket" toml:"bucket"`
+	UploadTimeout   time.Duration `yaml:"upload_timeout" toml:"upload_timeout"`
+	DownloadTimeout time.Duration `yaml:"download_timeout" toml:"download_timeout"`
+	// StaleUploadAfter is the age after which chunks without a files
+	// document, and events staged for an upload, are treated as abandoned;
+	// it defaults to twice UploadTimeout.
+	StaleUploadAfter time.Duration `yaml:"stale_upload_after" toml:"stale_upload_after"`
+	CheckInterval    time.Duration `yaml:"check_interval" toml:"check_interval"`
+	DeleteOrphans    bool          `yaml:"delete_orphans" toml:"delete_orphans"`
+}
+
+type UploadConfig struct {
+	MaxFileSize      ByteSize `yaml:"max_file_size" toml:"max_file_size"`
+	AllowedMimeTypes []string `yaml:"allowed_mime_types" toml:"allowed_mime_types"`
+	// StorageQuota limits the bytes stored in the archive; 0 means no limit.
+	StorageQuota ByteSize `yaml:"storage_quota" toml:"storage_quota"`
+	// BatchMaxFiles limits the files in one batch upload, counting the
+	// entries of uploaded ZIP archives.
+	BatchMaxFiles int `yaml:"batch_max_files" toml:"batch_max_files"`
+	// BatchConcurrency is how many files of a batch are stored at once.
+	BatchConcurrency int `yaml:"batch_concurrency" toml:"batch_concurrency"`
+	// BatchMaxSize limits the body of one batch upload. Keyed requests
+	// spool their body to a temporary file, so it also bounds the disk space
+	// each of them takes. It defaults to 1GiB, or MaxFileSize when that is
+	// larger.
+	BatchMaxSize ByteSize `yaml:"batch_max_size" toml:"batch_max_size"`
+}
+
+type ExportConfig struct {
+	// MaxFiles limits archives streamed directly in the response; larger
+	// selections need an asynchronous export.
+	MaxFiles int `yaml:"max_files" toml:"max_files"`
+	// Bucket is the GridFS bucket asynchronous exports are stored in.
+	Bucket string `yaml:"bucket" toml:"bucket"`
+	// Retention is how long asynchronous exports can be downloaded.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a response is replayed for retries with the same
+	// Idempotency-Key.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+	// Lease is how long a key stays reserved by a request that has not
+	// completed; it defaults to storage.upload_timeout, the longest an
+	// idempotent request runs.
+	Lease time.Duration `yaml:"lease" toml:"lease"`
+}
+
+type JobsConfig struct {
+	// Concurrency is how many jobs an instance runs at once.
+	Concurrency int `yaml:"concurrency" toml:"concurrency"`
+	// PollInterval is how often idle workers look for due jobs.
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// Lease is how long a job stays with a worker that stopped renewing it,
+	// e.g. because the instance crashed, before another worker retries it.
+	Lease       time.Duration `yaml:"lease" toml:"lease"`
+	MaxAttempts int           `yaml:"max_attempts" toml:"max_attempts"`
+	// Retention is how long succeeded jobs are kept; dead jobs stay until
+	// they are retried.
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+}
+
+type LogConfig struct {
+	Dir        string `yaml:"dir" toml:"dir"`
+	Level      string `yaml:"level" toml:"level"`
+	MaxSizeMB  int    `yaml:"max_size_mb" toml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups" toml:"max_backups"`
+	MaxAgeDays int    `yaml:"max_age_days" toml:"max_age_days"`
+}
+
+type TracingConfig struct {
+	// Exporter is where traces are sent: "otlp", "stdout", "file" or "none".
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	File     string `yaml:"file" toml:"file"`
+}
+
+type HealthConfig struct {
+	// DiskMinFree is the free space required on the log and temp
+	// filesystems for the instance to report ready.
+	DiskMinFree ByteSize `yaml:"disk_min_free" toml:"disk_min_free"`
+	ScannerAddr string   `yaml:"scanner_addr" toml:"scanner_addr"`
+}
+
+type ShareConfig struct {
+	// Secret is the HMAC key for share links. Without one a random key is
+	// generated and links stop working after a restart.
+	Secret string `yaml:"secret" toml:"secret"`
+}
+
+type WebhooksConfig struct {
+	// AllowedNetworks lists networks, in CIDR notation, that webhooks may
+	// reach although they are loopback, link-local or private.
+	AllowedNetworks []string `yaml:"allowed_networks" toml:"allowed_networks"`
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds how long in-flight uploads and downloads may run after
+	// a shutdown signal before they are aborted.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// ReadinessDelay is how long the instance reports not ready before it
+	// stops accepting connections, giving load balancers time to notice.
+	ReadinessDelay time.Duration `yaml:"readiness_delay" toml:"readiness_delay"`
+}
+
+// ByteSize is a size in bytes that can be written as "10MB" or "512 KiB".
+type ByteSize int64
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := humanize.ParseBytes(string(text))
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+func (b ByteSize) String() string {
+	return humanize.IBytes(uint64(b))
+}
+
+// Default returns the configuration used for anything not set explicitly.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			DefaultLanguage: "en",
+		},
+		Mongo: MongoConfig{
+			OperationTimeout: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Bucket:          "files",
+			UploadTimeout:   10 * time.Minute,
+			DownloadTimeout: 30 * time.Minute,
+			CheckInterval:   6 * time.Hour,
+		},
+		Upload: UploadConfig{
+			MaxFileSize:      10 * 1024 * 1024,
+			AllowedMimeTypes: []string{"application/pdf"},
+			BatchMaxFiles:    500,
+			BatchConcurrency: 4,
+		},
+		Export: ExportConfig{
+			MaxFiles:  1000,
+			Bucket:    "exports",
+			Retention: 24 * time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Jobs: JobsConfig{
+			Concurrency:  2,
+			PollInterval: time.Second,
+			Lease:        time.Minute,
+			MaxAttempts:  5,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Dir:        "logs",
+			Level:      "info",
+			MaxSizeMB:  100,
+			MaxBackups: 30,
+			MaxAgeDays: 30,
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+			File:     "logs/traces.json",
+		},
+		Health: HealthConfig{
+			DiskMinFree: 100 * 1024 * 1024,
+		},
+		Shutdown: ShutdownConfig{
+			Timeout:        30 * time.Second,
+			ReadinessDelay: 5 * time.Second,
+		},
+	}
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate fills in derived defaults and checks the configuration, reporting
+// all problems at once.
+func (c *Config) Validate() error {
+	c.Server.PublicURL = strings.TrimRight(c.Server.PublicURL, "/")
+	if c.Storage.StaleUploadAfter == 0 {
+		c.Storage.StaleUploadAfter = 2 * c.Storage.UploadTimeout
+	}
+	if c.Idempotency.Lease == 0 {
+		c.Idempotency.Lease = c.Storage.UploadTimeout
+	}
+	if c.Upload.BatchMaxSize == 0 {
+		c.Upload.BatchMaxSize = max(1024*1024*1024, c.Upload.MaxFileSize)
+	}
+
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port == "" {
+		problem("server.port is required")
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problem("server.public_url must be an absolute http(s) URL, got %q", c.Server.PublicURL)
+		}
+	}
+	switch c.Server.DefaultLanguage {
+	case "en", "id":
+	default:
+		problem("server.default_language must be one of en, id, got %q", c.Server.DefaultLanguage)
+	}
+	if c.Mongo.URI == "" {
+		problem("mongo.uri is required (MONGODB_URI)")
+	}
+	if c.Mongo.Database == "" {
+		problem("mongo.database is required (DATABASE_NAME)")
+	}
+	if c.Storage.Bucket == "" {
+		problem("storage.bucket is required")
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"mongo.operation_timeout", c.Mongo.OperationTimeout},
+		{"storage.upload_timeout", c.Storage.UploadTimeout},
+		{"storage.download_timeout", c.Storage.DownloadTimeout},
+		{"storage.stale_upload_after", c.Storage.StaleUploadAfter},
+		{"storage.check_interval", c.Storage.CheckInterval},
+		{"export.retention", c.Export.Retention},
+		{"idempotency.ttl", c.Idempotency.TTL},
+		{"idempotency.lease", c.Idempotency.Lease},
+		{"jobs.poll_interval", c.Jobs.PollInterval},
+		{"jobs.lease", c.Jobs.Lease},
+		{"jobs.retention", c.Jobs.Retention},
+		{"shutdown.timeout", c.Shutdown.Timeout},
+	} {
+		if d.value <= 0 {
+			problem("%s must be positive, got %s", d.name, d.value)
+		}
+	}
+	if c.Shutdown.ReadinessDelay < 0 {
+		problem("shutdown.readiness_delay must not be negative, got %s", c.Shutdown.ReadinessDelay)
+	}
+	if c.Storage.StaleUploadAfter > 0 && c.Storage.StaleUploadAfter <= c.Storage.UploadTimeout {
+		problem("storage.stale_upload_after (%s) must be longer than storage.upload_timeout (%s)",
+			c.Storage.StaleUploadAfter, c.Storage.UploadTimeout)
+	}
+	if c.Idempotency.Lease > c.Idempotency.TTL {
+		problem("idempotency.lease (%s) must not be longer than idempotency.ttl (%s)", c.Idempotency.Lease, c.Idempotency.TTL)
+	}
+	if c.Upload.MaxFileSize <= 0 {
+		problem("upload.max_file_size must be positive")
+	}
+	if len(c.Upload.AllowedMimeTypes) == 0 {
+		problem("upload.allowed_mime_types must not be empty")
+	}
+	if c.Upload.BatchMaxFiles <= 0 {
+		problem("upload.batch_max_files must be positive")
+	}
+	if c.Upload.BatchConcurrency <= 0 {
+		problem("upload.batch_concurrency must be positive")
+	}
+	if c.Upload.BatchMaxSize < c.Upload.MaxFileSize {
+		problem("upload.batch_max_size (%s) must not be smaller than upload.max_file_size (%s)",
+			c.Upload.BatchMaxSize, c.Upload.MaxFileSize)
+	}
+	if c.Export.MaxFiles <= 0 {
+		problem("export.max_files must be positive")
+	}
+	if c.Export.Bucket == "" {
+		problem("export.bucket is required")
+	} else if c.Export.Bucket == c.Storage.Bucket {
+		problem("export.bucket must differ from storage.bucket")
+	}
+	if c.Jobs.Concurrency <= 0 {
+		problem("jobs.concurrency must be positive")
+	}
+	if c.Jobs.MaxAttempts <= 0 {
+		problem("jobs.max_attempts must be positive")
+	}
+	for _, network := range c.Webhooks.AllowedNetworks {
+		if _, err := netip.ParsePrefix(network); err != nil {
+			problem("webhooks.allowed_networks: %q is not a CIDR network", network)
+		}
+	}
+	if c.Log.Dir == "" {
+		problem("log.dir is required")
+	}
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		problem("log.level: %s", err.Error())
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	case "file":
+		if c.Tracing.File == "" {
+			problem("tracing.file is required with the file exporter")
+		}
+	default:
+		problem("tracing.exporter must be one of none, stdout, file, otlp, got %q", c.Tracing.Exporter)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
 }
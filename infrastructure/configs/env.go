@@ -2,30 +2,15 @@ package configs
 
 import (
 	"log"
-	"os"
 
 	"github.com/joho/godotenv"
 )
 
+// LoadEnv adds the variables of a .env file in the working directory to the
+// environment, without overriding variables that are already set.
 func LoadEnv() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Printf("Warning: No .env file found - using system environment variables")
 	}
 }
-
-func GetMongoURI() string {
-	return os.Getenv("MONGODB_URI")
-}
-
-func GetDatabaseName() string {
-	return os.Getenv("DATABASE_NAME")
-}
-
-func GetServerPort() string {
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		return "8080"
-	}
-	return port
-}
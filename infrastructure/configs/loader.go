@@ -0,0 +1,233 @@
+package configs
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the configuration file when no -config flag is given.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// setting binds one configuration key to its environment variable and to a
+// command-line flag named after the key ("storage.upload_timeout" becomes
+// -storage-upload-timeout).
+type setting struct {
+	key   string
+	env   string
+	usage string
+	set   setter
+}
+
+// setter parses the value of a setting into the configuration. Bool
+// settings are also set by their bare flag, as in -server-trust-proxy.
+type setter struct {
+	apply  func(c *Config, value string) error
+	isBool bool
+}
+
+// flagValue holds the text of a setting's flag until it is applied.
+type flagValue struct {
+	text   string
+	isBool bool
+}
+
+func (v *flagValue) String() string     { return v.text }
+func (v *flagValue) Set(s string) error { v.text = s; return nil }
+func (v *flagValue) IsBoolFlag() bool   { return v.isBool }
+
+var settings = []setting{
+	{"server.port", "SERVER_PORT", "HTTP listen port", setString(func(c *Config) *string { return &c.Server.Port })},
+	{"server.public_url", "PUBLIC_URL", "base URL clients reach the API at", setString(func(c *Config) *string { return &c.Server.PublicURL })},
+	{"server.trust_proxy", "TRUST_PROXY", "honor X-Forwarded-Proto and X-Forwarded-Host in links", setBool(func(c *Config) *bool { return &c.Server.TrustProxy })},
+	{"server.default_language", "DEFAULT_LANGUAGE", "language of error messages when the client accepts none we support: en or id", setString(func(c *Config) *string { return &c.Server.DefaultLanguage })},
+	{"server.swagger_ui_dir", "SWAGGER_UI_DIR", "directory with the swagger-ui-dist files to serve instead of loading them from unpkg", setString(func(c *Config) *string { return &c.Server.SwaggerUIDir })},
+	{"mongo.uri", "MONGODB_URI", "MongoDB connection string", setString(func(c *Config) *string { return &c.Mongo.URI })},
+	{"mongo.database", "DATABASE_NAME", "MongoDB database name", setString(func(c *Config) *string { return &c.Mongo.Database })},
+	{"mongo.operation_timeout", "MONGO_OPERATION_TIMEOUT", "timeout of a single MongoDB operation", setDuration(func(c *Config) *time.Duration { return &c.Mongo.OperationTimeout })},
+	{"storage.bucket", "GRIDFS_BUCKET", "GridFS bucket name", setString(func(c *Config) *string { return &c.Storage.Bucket })},
+	{"storage.upload_timeout", "UPLOAD_TIMEOUT", "time allowed to store one upload", setDuration(func(c *Config) *time.Duration { return &c.Storage.UploadTimeout })},
+	{"storage.download_timeout", "DOWNLOAD_TIMEOUT", "time allowed to stream one download", setDuration(func(c *Config) *time.Duration { return &c.Storage.DownloadTimeout })},
+	{"storage.stale_upload_after", "STALE_UPLOAD_AFTER", "age after which orphan chunks are abandoned", setDuration(func(c *Config) *time.Duration { return &c.Storage.StaleUploadAfter })},
+	{"storage.check_interval", "STORAGE_CHECK_INTERVAL", "interval of the storage consistency check", setDuration(func(c *Config) *time.Duration { return &c.Storage.CheckInterval })},
+	{"storage.delete_orphans", "STORAGE_CLEANUP_DELETE_ORPHANS", "delete stale orphan chunks during the storage check", setBool(func(c *Config) *bool { return &c.Storage.DeleteOrphans })},
+	{"upload.max_file_size", "MAX_FILE_SIZE", "largest accepted upload, e.g. 10MB", setByteSize(func(c *Config) *ByteSize { return &c.Upload.MaxFileSize })},
+	{"upload.allowed_mime_types", "ALLOWED_MIME_TYPES", "comma-separated accepted content types", setList(func(c *Config) *[]string { return &c.Upload.AllowedMimeTypes })},
+	{"upload.storage_quota", "STORAGE_QUOTA", "bytes the archive may hold, e.g. 500GiB; 0 for no limit", setByteSize(func(c *Config) *ByteSize { return &c.Upload.StorageQuota })},
+	{"upload.batch_max_files", "BATCH_MAX_FILES", "files accepted in one batch upload", setInt(func(c *Config) *int { return &c.Upload.BatchMaxFiles })},
+	{"upload.batch_concurrency", "BATCH_CONCURRENCY", "files of a batch stored at once", setInt(func(c *Config) *int { return &c.Upload.BatchConcurrency })},
+	{"upload.batch_max_size", "BATCH_MAX_SIZE", "largest accepted batch upload body, e.g. 1GiB", setByteSize(func(c *Config) *ByteSize { return &c.Upload.BatchMaxSize })},
+	{"export.max_files", "EXPORT_MAX_FILES", "files allowed in an archive streamed directly", setInt(func(c *Config) *int { return &c.Export.MaxFiles })},
+	{"export.bucket", "EXPORT_BUCKET", "GridFS bucket of asynchronous exports", setString(func(c *Config) *string { return &c.Export.Bucket })},
+	{"export.retention", "EXPORT_RETENTION", "time asynchronous exports stay downloadable", setDuration(func(c *Config) *time.Duration { return &c.Export.Retention })},
+	{"idempotency.ttl", "IDEMPOTENCY_TTL", "time a response is replayed for retries with the same Idempotency-Key", setDuration(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+	{"idempotency.lease", "IDEMPOTENCY_LEASE", "time a key stays reserved by a request that has not completed", setDuration(func(c *Config) *time.Duration { return &c.Idempotency.Lease })},
+	{"jobs.concurrency", "JOBS_CONCURRENCY", "background jobs an instance runs at once", setInt(func(c *Config) *int { return &c.Jobs.Concurrency })},
+	{"jobs.poll_interval", "JOBS_POLL_INTERVAL", "how often idle job workers look for due jobs", setDuration(func(c *Config) *time.Duration { return &c.Jobs.PollInterval })},
+	{"jobs.lease", "JOBS_LEASE", "time before a job held by an unresponsive worker is retried", setDuration(func(c *Config) *time.Duration { return &c.Jobs.Lease })},
+	{"jobs.max_attempts", "JOBS_MAX_ATTEMPTS", "attempts before a failing job is dead", setInt(func(c *Config) *int { return &c.Jobs.MaxAttempts })},
+	{"jobs.retention", "JOBS_RETENTION", "time succeeded jobs are kept", setDuration(func(c *Config) *time.Duration { return &c.Jobs.Retention })},
+	{"log.dir", "LOG_DIR", "directory of the rotating log files", setString(func(c *Config) *string { return &c.Log.Dir })},
+	{"log.level", "LOG_LEVEL", "minimum level written to the log files", setString(func(c *Config) *string { return &c.Log.Level })},
+	{"log.max_size_mb", "LOG_MAX_SIZE_MB", "size at which a log file is rotated", setInt(func(c *Config) *int { return &c.Log.MaxSizeMB })},
+	{"log.max_backups", "LOG_MAX_BACKUPS", "rotated log files to keep", setInt(func(c *Config) *int { return &c.Log.MaxBackups })},
+	{"log.max_age_days", "LOG_MAX_AGE_DAYS", "days to keep rotated log files", setInt(func(c *Config) *int { return &c.Log.MaxAgeDays })},
+	{"tracing.exporter", "TRACES_EXPORTER", "trace exporter: none, stdout, file or otlp", setString(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"tracing.file", "TRACES_FILE", "output file of the file trace exporter", setString(func(c *Config) *string { return &c.Tracing.File })},
+	{"health.disk_min_free", "DISK_MIN_FREE", "free disk space required to report ready, e.g. 100MB", setByteSize(func(c *Config) *ByteSize { return &c.Health.DiskMinFree })},
+	{"health.scanner_addr", "SCANNER_ADDR", "host:port of the virus scanner, if any", setString(func(c *Config) *string { return &c.Health.ScannerAddr })},
+	{"share.secret", "SHARE_LINK_SECRET", "HMAC key for share links", setString(func(c *Config) *string { return &c.Share.Secret })},
+	{"webhooks.allowed_networks", "WEBHOOK_ALLOWED_NETWORKS", "comma-separated private networks webhooks may reach, e.g. 10.1.0.0/16", setList(func(c *Config) *[]string { return &c.Webhooks.AllowedNetworks })},
+	{"shutdown.timeout", "SHUTDOWN_TIMEOUT", "time allowed for in-flight transfers on shutdown", setDuration(func(c *Config) *time.Duration { return &c.Shutdown.Timeout })},
+	{"shutdown.readiness_delay", "SHUTDOWN_READINESS_DELAY", "time reported not ready before the listener closes", setDuration(func(c *Config) *time.Duration { return &c.Shutdown.ReadinessDelay })},
+}
+
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// Load builds the configuration from, in increasing order of precedence:
+// built-in defaults, a YAML or TOML file, environment variables (including a
+// .env file) and command-line flags. The flags are registered on fs so that
+// callers can add their own before Load parses args.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	LoadEnv()
+
+	configFile := fs.String("config", os.Getenv(ConfigFileEnv), "YAML or TOML configuration file")
+	flagValues := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		value := &flagValue{isBool: s.set.isBool}
+		fs.Var(value, s.flagName(), s.usage+" ("+s.env+")")
+		flagValues[s.flagName()] = value
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.set.apply(cfg, value); err != nil {
+			problems = append(problems, s.env+": "+err.Error())
+		}
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, s := range settings {
+		if !set[s.flagName()] {
+			continue
+		}
+		if err := s.set.apply(cfg, flagValues[s.flagName()].text); err != nil {
+			problems = append(problems, "-"+s.flagName()+": "+err.Error())
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.(*ValidationError).Problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read configuration file")
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), cfg)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = errors.Errorf("unknown keys %v", meta.Undecoded())
+		}
+	default:
+		return errors.Errorf("configuration file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	return errors.Wrapf(err, "invalid configuration file %s", path)
+}
+
+func setString(field func(*Config) *string) setter {
+	return setter{apply: func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}}
+}
+
+func setDuration(field func(*Config) *time.Duration) setter {
+	return setter{apply: func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}}
+}
+
+func setBool(field func(*Config) *bool) setter {
+	return setter{isBool: true, apply: func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}}
+}
+
+func setInt(field func(*Config) *int) setter {
+	return setter{apply: func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}}
+}
+
+func setByteSize(field func(*Config) *ByteSize) setter {
+	return setter{apply: func(c *Config, value string) error {
+		return field(c).UnmarshalText([]byte(value))
+	}}
+}
+
+func setList(field func(*Config) *[]string) setter {
+	return setter{apply: func(c *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}}
+}
@@ -0,0 +1,44 @@
+package configs
+
+import (
+	"flag"
+	"testing"
+)
+
+func load(t *testing.T, args ...string) *Config {
+	t.Helper()
+	t.Setenv("MONGODB_URI", "mongodb://localhost:27017")
+	t.Setenv("DATABASE_NAME", "archive")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := Load(fs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLoadAcceptsBareBoolFlags(t *testing.T) {
+	cfg := load(t, "-server-trust-proxy", "-storage-delete-orphans", "-server-port", "9090")
+	if !cfg.Server.TrustProxy || !cfg.Storage.DeleteOrphans || cfg.Server.Port != "9090" {
+		t.Fatalf("got trust_proxy=%v delete_orphans=%v port=%s", cfg.Server.TrustProxy, cfg.Storage.DeleteOrphans, cfg.Server.Port)
+	}
+}
+
+func TestLoadBoolFlagsOverrideEnvironment(t *testing.T) {
+	t.Setenv("TRUST_PROXY", "true")
+	if cfg := load(t); !cfg.Server.TrustProxy {
+		t.Error("TRUST_PROXY=true ignored")
+	}
+	if cfg := load(t, "-server-trust-proxy=false"); cfg.Server.TrustProxy {
+		t.Error("-server-trust-proxy=false ignored")
+	}
+}
+
+func TestLoadRejectsInvalidBoolFlags(t *testing.T) {
+	t.Setenv("MONGODB_URI", "mongodb://localhost:27017")
+	t.Setenv("DATABASE_NAME", "archive")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := Load(fs, []string{"-server-trust-proxy=maybe"}); err == nil {
+		t.Fatal("invalid bool accepted")
+	}
+}
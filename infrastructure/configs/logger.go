@@ -13,10 +13,15 @@ import (
 
 var Logger *zap.SugaredLogger
 
-func InitializeLogger() {
+func InitializeLogger(cfg LogConfig) error {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
 	// Create logs directory if not exists
-	if err := os.MkdirAll("logs", 0755); err != nil {
-		panic(err)
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return err
 	}
 	// Get the current date in the desired format
 	currentDate := time.Now().Format("02-01-2006") // Format: day-month-year
@@ -24,10 +29,10 @@ func InitializeLogger() {
 
 	// Daily rotating file config
 	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   filepath.Join("logs", logFileName),
-		MaxSize:    100, // megabytes
-		MaxBackups: 30,  // keep 30 days
-		MaxAge:     30,  // days
+		Filename:   filepath.Join(cfg.Dir, logFileName),
+		MaxSize:    cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
 		Compress:   true,
 		LocalTime:  true,
 	})
@@ -44,7 +49,7 @@ func InitializeLogger() {
 		zapcore.NewCore(
 			zapcore.NewJSONEncoder(encoderConfig),
 			fileWriter,
-			zap.InfoLevel,
+			level,
 		),
 		zapcore.NewCore(
 			zapcore.NewConsoleEncoder(encoderConfig),
@@ -56,6 +61,7 @@ func InitializeLogger() {
 	// Create logger
 	logger := zap.New(core, zap.AddCaller())
 	Logger = logger.Sugar()
+	return nil
 }
 
 func SyncLogger() {
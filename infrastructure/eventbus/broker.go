@@ -0,0 +1,115 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// Message is the unit exchanged with a broker. Key identifies the event so
+// consumers can deduplicate redeliveries.
+type Message struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Payload []byte
+}
+
+// Broker is the minimal publish/subscribe surface shared by NATS- or
+// Kafka-style message brokers.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe calls handler for every message on topic until the returned
+	// function is called.
+	Subscribe(topic string, handler func(msg Message)) (unsubscribe func())
+}
+
+// MemoryBroker is an in-memory Broker for development and single-instance
+// deployments.
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[string]map[int]func(msg Message)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[string]map[int]func(msg Message))}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := make([]func(msg Message), 0, len(b.subs[msg.Topic]))
+	for _, handler := range b.subs[msg.Topic] {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(topic string, handler func(msg Message)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[int]func(msg Message))
+	}
+	id := b.nextID
+	b.nextID++
+	b.subs[topic][id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[topic], id)
+	}
+}
+
+type brokerPayload struct {
+	ID         string                 `json:"id"`
+	Type       domain.EventType       `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	FileID     string                 `json:"file_id,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// BrokerSink publishes events as JSON to "<prefix><event type>" topics.
+type BrokerSink struct {
+	broker      Broker
+	topicPrefix string
+}
+
+func NewBrokerSink(broker Broker, topicPrefix string) *BrokerSink {
+	return &BrokerSink{broker: broker, topicPrefix: topicPrefix}
+}
+
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(brokerPayload{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		FileID:     event.FileID,
+		Data:       event.Data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+
+	return s.broker.Publish(ctx, Message{
+		Topic:   s.topicPrefix + string(event.Type),
+		Key:     event.ID,
+		Headers: map[string]string{"content-type": "application/json"},
+		Payload: payload,
+	})
+}
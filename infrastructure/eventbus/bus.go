@@ -0,0 +1,60 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type Handler func(ctx context.Context, event domain.Event) error
+
+// Bus delivers events to in-process subscribers. It is an outbox sink, so
+// handlers run on the dispatcher goroutine and a failing handler causes the
+// event to be retried for every subscriber.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[domain.EventType][]Handler
+	wildcard    []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[domain.EventType][]Handler)}
+}
+
+// Subscribe registers handler for eventType, or for every event when
+// eventType is empty.
+func (b *Bus) Subscribe(eventType domain.EventType, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if eventType == "" {
+		b.wildcard = append(b.wildcard, handler)
+		return
+	}
+	b.subscribers[eventType] = append(b.subscribers[eventType], handler)
+}
+
+func (b *Bus) Name() string {
+	return "in-process"
+}
+
+func (b *Bus) Publish(ctx context.Context, event domain.Event) (err error) {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.subscribers[event.Type]...), b.wildcard...)
+	b.mu.RUnlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func TestBusDeliversToSubscribers(t *testing.T) {
+	bus := NewBus()
+	var uploads, all []domain.EventType
+	bus.Subscribe(domain.EventFileUploaded, func(ctx context.Context, event domain.Event) error {
+		uploads = append(uploads, event.Type)
+		return nil
+	})
+	bus.Subscribe("", func(ctx context.Context, event domain.Event) error {
+		all = append(all, event.Type)
+		return nil
+	})
+
+	for _, eventType := range []domain.EventType{domain.EventFileUploaded, domain.EventFileDeleted} {
+		if err := bus.Publish(context.Background(), domain.NewEvent(eventType, "f001", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(uploads) != 1 || len(all) != 2 {
+		t.Fatalf("upload subscriber got %v, wildcard subscriber got %v", uploads, all)
+	}
+}
+
+func TestBusReportsFailingHandlers(t *testing.T) {
+	event := domain.NewEvent(domain.EventFileUploaded, "f001", nil)
+
+	failing := NewBus()
+	failing.Subscribe("", func(ctx context.Context, event domain.Event) error { return errors.New("index unavailable") })
+	if err := failing.Publish(context.Background(), event); err == nil || err.Error() != "index unavailable" {
+		t.Errorf("failing handler: got %v", err)
+	}
+
+	panicking := NewBus()
+	panicking.Subscribe("", func(ctx context.Context, event domain.Event) error { panic("nil map") })
+	if err := panicking.Publish(context.Background(), event); err == nil {
+		t.Error("panicking handler: got no error")
+	}
+}
+
+func TestBrokerSinkPublishesJSON(t *testing.T) {
+	broker := NewMemoryBroker()
+	var got []Message
+	unsubscribe := broker.Subscribe("archive.file.uploaded", func(msg Message) { got = append(got, msg) })
+	sink := NewBrokerSink(broker, "archive.")
+	event := domain.NewEvent(domain.EventFileUploaded, "f001", map[string]interface{}{"name": "report.pdf"})
+
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Publish(context.Background(), domain.NewEvent(domain.EventFileDeleted, "f001", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Key != event.ID || got[0].Headers["content-type"] != "application/json" {
+		t.Fatalf("messages: %+v", got)
+	}
+	var payload brokerPayload
+	if err := json.Unmarshal(got[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != event.ID || payload.Type != event.Type || payload.FileID != "f001" || payload.Data["name"] != "report.pdf" {
+		t.Errorf("payload: %+v", payload)
+	}
+
+	unsubscribe()
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Error("message delivered after unsubscribing")
+	}
+}
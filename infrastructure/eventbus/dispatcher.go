@@ -0,0 +1,56 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+const dispatchBatchSize = 50
+
+// Dispatcher periodically recovers abandoned staged events and publishes
+// ready outbox events to the configured sinks.
+type Dispatcher struct {
+	dispatchUseCase *usecases.DispatchEventsUseCase
+	recoverUseCase  *usecases.RecoverStagedEventsUseCase
+	interval        time.Duration
+}
+
+func NewDispatcher(
+	dispatchUC *usecases.DispatchEventsUseCase,
+	recoverUC *usecases.RecoverStagedEventsUseCase,
+	interval time.Duration,
+) *Dispatcher {
+	return &Dispatcher{dispatchUseCase: dispatchUC, recoverUseCase: recoverUC, interval: interval}
+}
+
+// Run dispatches events until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := d.recoverUseCase.Execute(ctx, dispatchBatchSize); err != nil {
+			configs.Logger.Errorw("outbox recovery failed", "error", err.Error())
+		}
+
+		for {
+			n, err := d.dispatchUseCase.Execute(ctx, dispatchBatchSize)
+			if err != nil {
+				configs.Logger.Errorw("outbox dispatch failed", "error", err.Error())
+				break
+			}
+			if n < dispatchBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// readyOutbox hands out its ready entries once and records what happened to
+// them.
+type readyOutbox struct {
+	domain.OutboxRepository
+	mu        sync.Mutex
+	ready     []*domain.OutboxEntry
+	published map[string]bool
+}
+
+func (o *readyOutbox) FindStaged(ctx context.Context, olderThan time.Time, limit int64) ([]*domain.OutboxEntry, error) {
+	return nil, nil
+}
+
+func (o *readyOutbox) ClaimReady(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	claimed := o.ready[:min(len(o.ready), limit)]
+	o.ready = o.ready[len(claimed):]
+	return claimed, nil
+}
+
+func (o *readyOutbox) MarkDelivered(ctx context.Context, eventID, sink string) error {
+	return nil
+}
+
+func (o *readyOutbox) MarkPublished(ctx context.Context, eventID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.published[eventID] = true
+	return nil
+}
+
+func TestDispatcherPublishesEveryReadyEvent(t *testing.T) {
+	outbox := &readyOutbox{published: map[string]bool{}}
+	// More than one batch, so the dispatcher has to keep claiming
+	for i := 0; i < dispatchBatchSize+10; i++ {
+		outbox.ready = append(outbox.ready, &domain.OutboxEntry{
+			Event:  domain.NewEvent(domain.EventFileUploaded, "f001", nil),
+			Status: domain.OutboxReady,
+		})
+	}
+	bus := NewBus()
+	received := make(chan domain.Event, dispatchBatchSize+10)
+	bus.Subscribe("", func(ctx context.Context, event domain.Event) error {
+		received <- event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewDispatcher(usecases.NewDispatchEventsUseCase(outbox, bus), usecases.NewRecoverStagedEventsUseCase(outbox, nil, time.Hour), time.Millisecond).Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < dispatchBatchSize+10; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d events dispatched", i)
+		}
+	}
+	cancel()
+	<-done
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+	if len(outbox.published) != dispatchBatchSize+10 {
+		t.Errorf("%d events marked published", len(outbox.published))
+	}
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func check(name string, err error) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error { return err }}
+}
+
+func TestCheckerReadiness(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		checks []Check
+		ready  bool
+		status string
+	}{
+		{name: "no checks", ready: true, status: "ready"},
+		{name: "all pass", checks: []Check{check("mongodb", nil), check("disk", nil)}, ready: true, status: "ready"},
+		{name: "one fails", checks: []Check{check("mongodb", nil), check("disk", errors.New("disk full"))}, status: "not_ready"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker()
+			checker.Add(tc.checks...)
+
+			report := checker.Run(context.Background())
+			if report.Ready != tc.ready || report.Status != tc.status || len(report.Checks) != len(tc.checks) {
+				t.Fatalf("report: %+v", report)
+			}
+			for _, c := range tc.checks {
+				result := report.Checks[c.Name]
+				wantErr := c.Run(context.Background())
+				if (result.Status == "ok") != (wantErr == nil) || (wantErr != nil && result.Error != wantErr.Error()) {
+					t.Errorf("%s: %+v", c.Name, result)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerReportsShutdown(t *testing.T) {
+	checker := NewChecker()
+	ran := false
+	checker.Add(Check{Name: "mongodb", Run: func(ctx context.Context) error {
+		ran = true
+		return nil
+	}})
+
+	checker.SetShuttingDown()
+	report := checker.Run(context.Background())
+	if report.Ready || report.Status != "shutting_down" || !checker.ShuttingDown() {
+		t.Fatalf("report: %+v", report)
+	}
+	if ran {
+		t.Error("checks ran while shutting down")
+	}
+}
+
+func TestCheckerPassesDeadlineToChecks(t *testing.T) {
+	checker := NewChecker()
+	checker.Add(Check{Name: "slow", Run: func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := checker.Run(ctx)
+	if report.Ready || report.Checks["slow"].Error != context.Canceled.Error() {
+		t.Fatalf("report: %+v", report)
+	}
+}
+
+func TestTCPDial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	if err := TCPDial("scanner", address).Run(context.Background()); err != nil {
+		t.Errorf("listening service: %v", err)
+	}
+	listener.Close()
+	if err := TCPDial("scanner", address).Run(context.Background()); err == nil {
+		t.Error("closed port accepted")
+	}
+}
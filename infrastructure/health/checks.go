@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/dustin/go-humanize"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func MongoPing(client *mongo.Client) Check {
+	return Check{
+		Name: "mongodb",
+		Run: func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		},
+	}
+}
+
+// DiskSpace fails when the filesystem holding path has less than minFree
+// bytes available.
+func DiskSpace(name, path string, minFree uint64) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			free, err := freeBytes(path)
+			if err != nil {
+				return err
+			}
+			if free < minFree {
+				return fmt.Errorf("only %s free on %s, need %s",
+					humanize.Bytes(free), path, humanize.Bytes(minFree))
+			}
+			return nil
+		},
+	}
+}
+
+// TCPDial checks that a TCP service such as a virus scanner daemon accepts
+// connections.
+func TCPDial(name, address string) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) error {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
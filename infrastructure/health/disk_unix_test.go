@@ -0,0 +1,20 @@
+//go:build unix
+
+package health
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDiskSpace(t *testing.T) {
+	dir := os.TempDir()
+	if err := DiskSpace("temp", dir, 0).Run(context.Background()); err != nil {
+		t.Errorf("any free space: %v", err)
+	}
+	if err := DiskSpace("temp", dir, math.MaxUint64).Run(context.Background()); err == nil {
+		t.Error("impossible minimum accepted")
+	}
+}
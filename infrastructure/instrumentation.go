@@ -0,0 +1,65 @@
+package infrastructure
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/yhartanto178dev/api-archiven-v2/infrastructure")
+
+// startGridFSOperation starts a span for a repository operation. The returned
+// function ends the span and records the operation's latency metric; defer it
+// with a pointer to the named error result.
+func startGridFSOperation(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "gridfs."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "mongodb")),
+	)
+
+	return ctx, func(err *error) {
+		metrics.ObserveGridFS(operation, start, err)
+		if err != nil && *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// tracedReadCloser keeps a span open while a download stream is read, so slow
+// transfers show up in traces separately from opening the file.
+type tracedReadCloser struct {
+	io.ReadCloser
+	span  trace.Span
+	bytes int64
+}
+
+func newTracedReadCloser(ctx context.Context, rc io.ReadCloser) *tracedReadCloser {
+	_, span := tracer.Start(ctx, "gridfs.read", trace.WithSpanKind(trace.SpanKindClient))
+	return &tracedReadCloser{ReadCloser: rc, span: span}
+}
+
+func (t *tracedReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.bytes += int64(n)
+	if err != nil && err != io.EOF {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	return n, err
+}
+
+func (t *tracedReadCloser) Close() error {
+	err := t.ReadCloser.Close()
+	t.span.SetAttributes(attribute.Int64("bytes_read", t.bytes))
+	t.span.End()
+	return err
+}
@@ -0,0 +1,85 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorder    = tracetest.NewSpanRecorder()
+	installRecorder sync.Once
+)
+
+// recordSpans returns a function listing the spans ended since the call.
+// The package tracer delegates to the first provider installed globally, so
+// every test shares one recorder.
+func recordSpans() func() []sdktrace.ReadOnlySpan {
+	installRecorder.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	before := len(spanRecorder.Ended())
+	return func() []sdktrace.ReadOnlySpan {
+		return spanRecorder.Ended()[before:]
+	}
+}
+
+func TestGridFSOperationSpans(t *testing.T) {
+	ended := recordSpans()
+
+	func() (err error) {
+		_, finish := startGridFSOperation(context.Background(), "find")
+		defer finish(&err)
+		return nil
+	}()
+	func() (err error) {
+		_, finish := startGridFSOperation(context.Background(), "delete")
+		defer finish(&err)
+		return errors.New("not primary")
+	}()
+
+	spans := ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended", len(spans))
+	}
+	if spans[0].Name() != "gridfs.find" || spans[0].Status().Code != codes.Unset {
+		t.Errorf("successful operation: %s, %+v", spans[0].Name(), spans[0].Status())
+	}
+	if spans[1].Name() != "gridfs.delete" || spans[1].Status().Code != codes.Error || spans[1].Status().Description != "not primary" {
+		t.Errorf("failed operation: %s, %+v", spans[1].Name(), spans[1].Status())
+	}
+}
+
+func TestTracedReadCloserSpansTheTransfer(t *testing.T) {
+	ended := recordSpans()
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "DownloadFile")
+
+	content := newTracedReadCloser(ctx, io.NopCloser(strings.NewReader("report content")))
+	if _, err := io.Copy(io.Discard, content); err != nil {
+		t.Fatal(err)
+	}
+	if len(ended()) != 0 {
+		t.Fatal("span ended before the stream was closed")
+	}
+	content.Close()
+	parent.End()
+
+	span := ended()[0]
+	if span.Name() != "gridfs.read" || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("span %s with parent %s", span.Name(), span.Parent().SpanID())
+	}
+	for _, attr := range span.Attributes() {
+		if attr.Key == "bytes_read" && attr.Value.AsInt64() == int64(len("report content")) {
+			return
+		}
+	}
+	t.Errorf("attributes %v lack bytes_read", span.Attributes())
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// Sink turns dispatched events into background jobs.
+type Sink struct {
+	scheduleUseCase *usecases.ScheduleFileJobsUseCase
+}
+
+func NewSink(scheduleUC *usecases.ScheduleFileJobsUseCase) *Sink {
+	return &Sink{scheduleUseCase: scheduleUC}
+}
+
+func (s *Sink) Name() string {
+	return "jobs"
+}
+
+func (s *Sink) Publish(ctx context.Context, event domain.Event) error {
+	return s.scheduleUseCase.Execute(ctx, event)
+}
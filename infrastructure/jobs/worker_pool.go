@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+)
+
+// WorkerPool runs jobs of some types with a fixed number of workers. Every
+// worker polls the queue on its own, so instances can run pools for the same
+// types side by side.
+type WorkerPool struct {
+	processUseCase *usecases.ProcessJobUseCase
+	types          []string
+	concurrency    int
+	pollInterval   time.Duration
+}
+
+// NewWorkerPool returns a pool for the given job types, or for every type
+// the use case has a handler for when types is empty.
+func NewWorkerPool(processUC *usecases.ProcessJobUseCase, concurrency int, pollInterval time.Duration, types ...string) *WorkerPool {
+	return &WorkerPool{processUseCase: processUC, types: types, concurrency: concurrency, pollInterval: pollInterval}
+}
+
+// Run processes jobs until ctx is cancelled and the running jobs have been
+// put back in the queue.
+func (p *WorkerPool) Run(ctx context.Context) {
+	host, _ := os.Hostname()
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		worker := fmt.Sprintf("%s/%d/%d", host, os.Getpid(), i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, worker)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *WorkerPool) work(ctx context.Context, worker string) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep taking jobs while there are due ones
+		for ctx.Err() == nil {
+			job, err := p.processUseCase.Execute(ctx, worker, p.types...)
+			if job != nil && ctx.Err() != nil && job.Status == domain.JobPending {
+				configs.Logger.Infow("job put back in the queue on shutdown", "job_id", job.ID, "job_type", job.Type)
+			} else if job != nil {
+				logJob(job, worker, err)
+			} else if err != nil && ctx.Err() == nil {
+				configs.Logger.Errorw("failed to claim job", "error", err.Error(), "worker", worker)
+			}
+			if job == nil || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func logJob(job *domain.Job, worker string, err error) {
+	if err == domain.ErrJobLeaseLost {
+		configs.Logger.Warnw("job lease lost to another worker",
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"worker", worker,
+		)
+		return
+	}
+	if err != nil {
+		configs.Logger.Errorw("failed to record job outcome",
+			"error", err.Error(),
+			"job_id", job.ID,
+			"job_type", job.Type,
+		)
+		return
+	}
+	metrics.JobsProcessed.WithLabelValues(job.Type, string(job.Status)).Inc()
+
+	switch job.Status {
+	case domain.JobSucceeded:
+		configs.Logger.Infow("job succeeded", "job_id", job.ID, "job_type", job.Type, "attempts", job.Attempts)
+	case domain.JobDead:
+		configs.Logger.Errorw("job failed for good",
+			"error", job.LastError,
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"attempts", job.Attempts,
+		)
+	default:
+		configs.Logger.Warnw("job failed, will retry",
+			"error", job.LastError,
+			"job_id", job.ID,
+			"job_type", job.Type,
+			"attempts", job.Attempts,
+			"next_attempt_at", job.RunAt,
+		)
+	}
+}
@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+// memoryQueue is a job repository that hands out due jobs in order.
+type memoryQueue struct {
+	domain.JobRepository
+	mu   sync.Mutex
+	jobs []*domain.Job
+}
+
+func (q *memoryQueue) Save(ctx context.Context, job *domain.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.ID = fmt.Sprintf("j%03d", len(q.jobs)+1)
+	stored := *job
+	q.jobs = append(q.jobs, &stored)
+	return nil
+}
+
+func (q *memoryQueue) Claim(ctx context.Context, types []string, worker string, now time.Time, lease time.Duration) (*domain.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Status == domain.JobPending && !job.RunAt.After(now) {
+			job.Status = domain.JobRunning
+			job.LockedBy = worker
+			job.Attempts++
+			claimed := *job
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *memoryQueue) Heartbeat(ctx context.Context, id, worker string, until time.Time) error {
+	return nil
+}
+
+func (q *memoryQueue) Finish(ctx context.Context, job *domain.Job, worker string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, stored := range q.jobs {
+		if stored.ID == job.ID {
+			finished := *job
+			finished.LockedBy = ""
+			q.jobs[i] = &finished
+		}
+	}
+	return nil
+}
+
+func (q *memoryQueue) job(i int) domain.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *q.jobs[i]
+}
+
+type poolPayload struct {
+	N int `json:"n"`
+}
+
+func enqueue(t *testing.T, queue *memoryQueue, n int) {
+	t.Helper()
+	enqueueUC := usecases.NewEnqueueJobUseCase(queue, 3)
+	for i := 0; i < n; i++ {
+		if _, err := enqueueUC.Execute(context.Background(), usecases.EnqueueJobCommand{Type: "pool-test", Payload: poolPayload{N: i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkerPoolDrainsDueJobs(t *testing.T) {
+	queue := &memoryQueue{}
+	enqueue(t, queue, 5)
+	succeeded := testutil.ToFloat64(metrics.JobsProcessed.WithLabelValues("pool-test", string(domain.JobSucceeded)))
+
+	done := make(chan struct{}, 5)
+	processUC := usecases.NewProcessJobUseCase(queue, time.Minute, time.Hour, usecases.NewJobHandler("pool-test", func(ctx context.Context, payload poolPayload) error {
+		done <- struct{}{}
+		return nil
+	}))
+	// Due jobs are taken one after another without waiting for a poll
+	pool := NewWorkerPool(processUC, 2, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(stopped)
+	}()
+
+	for i := 0; i < 5; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d of 5 jobs processed", i)
+		}
+	}
+	cancel()
+	<-stopped
+
+	for i := 0; i < 5; i++ {
+		if job := queue.job(i); job.Status != domain.JobSucceeded || job.Attempts != 1 {
+			t.Errorf("job %d: %+v", i, job)
+		}
+	}
+	// The outcome is recorded after the handler returns, so wait for Run
+	if got := testutil.ToFloat64(metrics.JobsProcessed.WithLabelValues("pool-test", string(domain.JobSucceeded))) - succeeded; got != 5 {
+		t.Errorf("%v successes counted, want 5", got)
+	}
+}
+
+func TestWorkerPoolPutsJobsBackOnShutdown(t *testing.T) {
+	queue := &memoryQueue{}
+	enqueue(t, queue, 1)
+
+	started := make(chan struct{})
+	processUC := usecases.NewProcessJobUseCase(queue, time.Minute, time.Hour, usecases.NewJobHandler("pool-test", func(ctx context.Context, payload poolPayload) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewWorkerPool(processUC, 1, time.Hour, "pool-test").Run(ctx)
+		close(stopped)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool did not stop")
+	}
+	if job := queue.job(0); job.Status != domain.JobPending || job.Attempts != 0 || job.LockedBy != "" {
+		t.Errorf("interrupted job: %+v", job)
+	}
+}
+
+func TestSinkSchedulesChecksumJobs(t *testing.T) {
+	queue := &memoryQueue{}
+	sink := NewSink(usecases.NewScheduleFileJobsUseCase(usecases.NewEnqueueJobUseCase(queue, 3)))
+
+	for _, event := range []domain.Event{
+		{Type: domain.EventFileUploaded, FileID: "f001"},
+		{Type: domain.EventFileDeleted, FileID: "f002"},
+	} {
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(queue.jobs) != 1 || queue.jobs[0].Type != usecases.ChecksumJobType || queue.jobs[0].Status != domain.JobPending {
+		t.Fatalf("queued jobs: %+v", queue.jobs)
+	}
+}
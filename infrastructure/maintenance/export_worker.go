@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+// ExportCleanupWorker periodically deletes expired asynchronous exports.
+type ExportCleanupWorker struct {
+	cleanupUseCase *usecases.CleanupExportsUseCase
+	interval       time.Duration
+}
+
+func NewExportCleanupWorker(cleanupUC *usecases.CleanupExportsUseCase, interval time.Duration) *ExportCleanupWorker {
+	return &ExportCleanupWorker{cleanupUseCase: cleanupUC, interval: interval}
+}
+
+// Run deletes expired exports every interval until ctx is cancelled.
+func (w *ExportCleanupWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := w.cleanupUseCase.Execute(ctx)
+		if err != nil {
+			configs.Logger.Errorw("export cleanup failed", "error", err.Error(), "deleted", deleted)
+			continue
+		}
+		if deleted > 0 {
+			configs.Logger.Infow("expired exports deleted", "deleted", deleted)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+)
+
+// StorageWorker periodically checks GridFS storage for orphaned chunks and
+// incomplete files.
+type StorageWorker struct {
+	cleanupUseCase *usecases.CleanupStorageUseCase
+	interval       time.Duration
+	cmd            usecases.CleanupStorageCommand
+}
+
+func NewStorageWorker(cleanupUC *usecases.CleanupStorageUseCase, interval time.Duration, cmd usecases.CleanupStorageCommand) *StorageWorker {
+	return &StorageWorker{cleanupUseCase: cleanupUC, interval: interval, cmd: cmd}
+}
+
+// Run checks storage every interval until ctx is cancelled.
+func (w *StorageWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := w.cleanupUseCase.Execute(ctx, w.cmd)
+		if err != nil {
+			configs.Logger.Errorw("storage check failed", "error", err.Error())
+			continue
+		}
+		LogStorageReport(report)
+	}
+}
+
+// LogStorageReport logs every issue in report and updates the storage
+// gauges.
+func LogStorageReport(report *domain.StorageReport) {
+	for _, issue := range append(report.Orphans, report.Incomplete...) {
+		configs.Logger.Warnw("storage issue",
+			"kind", string(issue.Kind),
+			"file_id", issue.FileID,
+			"file_name", issue.Name,
+			"length", issue.Length,
+			"chunks", issue.Chunks,
+			"expected_chunks", issue.ExpectedChunks,
+			"bytes", issue.Bytes,
+		)
+	}
+	configs.Logger.Infow("storage check completed",
+		"orphan_chunk_sets", len(report.Orphans),
+		"orphan_bytes", report.OrphanBytes,
+		"incomplete_files", len(report.Incomplete),
+		"in_progress", report.InProgress,
+		"deleted_orphans", report.DeletedOrphans,
+		"reclaimed_bytes", report.ReclaimedBytes,
+		"deleted_files", report.DeletedFiles,
+	)
+
+	metrics.StorageOrphanChunkSets.Set(float64(len(report.Orphans) - report.DeletedOrphans))
+	metrics.StorageOrphanBytes.Set(float64(report.OrphanBytes - report.ReclaimedBytes))
+	metrics.StorageIncompleteFiles.Set(float64(len(report.Incomplete) - report.DeletedFiles))
+}
@@ -0,0 +1,129 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+func TestLogStorageReportSetsGauges(t *testing.T) {
+	LogStorageReport(&domain.StorageReport{
+		Orphans:        []*domain.StorageIssue{{FileID: "f001", Bytes: 100}, {FileID: "f002", Bytes: 200}, {FileID: "f003", Bytes: 400}},
+		Incomplete:     []*domain.StorageIssue{{FileID: "f004"}, {FileID: "f005"}},
+		OrphanBytes:    700,
+		DeletedOrphans: 2,
+		ReclaimedBytes: 300,
+		DeletedFiles:   1,
+	})
+
+	// The gauges show what is left after the cleanup
+	for _, gauge := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"orphan chunk sets", testutil.ToFloat64(metrics.StorageOrphanChunkSets), 1},
+		{"orphan bytes", testutil.ToFloat64(metrics.StorageOrphanBytes), 400},
+		{"incomplete files", testutil.ToFloat64(metrics.StorageIncompleteFiles), 1},
+	} {
+		if gauge.got != gauge.want {
+			t.Errorf("%s = %v, want %v", gauge.name, gauge.got, gauge.want)
+		}
+	}
+}
+
+// countingInspector reports one stale orphan and signals every check.
+type countingInspector struct {
+	checks chan struct{}
+}
+
+func (i *countingInspector) FindOrphanChunks(ctx context.Context) ([]*domain.StorageIssue, error) {
+	return []*domain.StorageIssue{{FileID: "f001", Bytes: 100, CreatedAt: time.Now().Add(-time.Hour)}}, nil
+}
+
+func (i *countingInspector) FindIncompleteFiles(ctx context.Context) ([]*domain.StorageIssue, error) {
+	i.checks <- struct{}{}
+	return nil, nil
+}
+
+func (i *countingInspector) DeleteOrphanChunks(ctx context.Context, fileID string) (int64, error) {
+	return 1, nil
+}
+
+func TestStorageWorkerChecksEveryInterval(t *testing.T) {
+	inspector := &countingInspector{checks: make(chan struct{})}
+	worker := NewStorageWorker(usecases.NewCleanupStorageUseCase(inspector, time.Minute), time.Millisecond,
+		usecases.CleanupStorageCommand{DeleteOrphans: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-inspector.checks:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d checks ran", i)
+		}
+	}
+	cancel()
+	// Drain a check that started before the cancellation
+	select {
+	case <-done:
+	case <-inspector.checks:
+		<-done
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
+
+// expiringExports holds exports that are all expired.
+type expiringExports struct {
+	domain.ExportRepository
+	ids     []string
+	deleted chan string
+}
+
+func (r *expiringExports) FindExpired(ctx context.Context, now time.Time) ([]*domain.Export, error) {
+	var expired []*domain.Export
+	for _, id := range r.ids {
+		expired = append(expired, &domain.Export{ID: id})
+	}
+	r.ids = nil
+	return expired, nil
+}
+
+func (r *expiringExports) Delete(ctx context.Context, id string) error {
+	r.deleted <- id
+	return nil
+}
+
+func TestExportCleanupWorkerDeletesExpiredExports(t *testing.T) {
+	exports := &expiringExports{ids: []string{"e001", "e002"}, deleted: make(chan string, 2)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewExportCleanupWorker(usecases.NewCleanupExportsUseCase(exports), time.Millisecond).Run(ctx)
+
+	for _, want := range []string{"e001", "e002"} {
+		select {
+		case id := <-exports.deleted:
+			if id != want {
+				t.Errorf("deleted %s, want %s", id, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s not deleted", want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+// archiveStatsTTL limits how often scrapes hit MongoDB for archive totals.
+const archiveStatsTTL = 30 * time.Second
+
+var (
+	filesDesc = prometheus.NewDesc(namespace+"_files", "Number of files in the archive.", nil, nil)
+	bytesDesc = prometheus.NewDesc(namespace+"_files_bytes", "Total size of files in the archive.", nil, nil)
+)
+
+// ArchiveCollector exposes archive-wide totals as gauges.
+type ArchiveCollector struct {
+	repo domain.FileRepository
+
+	mu        sync.Mutex
+	stats     domain.ArchiveStats
+	fetchedAt time.Time
+}
+
+func NewArchiveCollector(repo domain.FileRepository) *ArchiveCollector {
+	return &ArchiveCollector{repo: repo}
+}
+
+func (c *ArchiveCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- filesDesc
+	ch <- bytesDesc
+}
+
+func (c *ArchiveCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) > archiveStatsTTL {
+		stats, err := c.repo.Stats(context.Background())
+		if err != nil {
+			configs.Logger.Errorw("failed to collect archive stats", "error", err.Error())
+		} else {
+			c.stats = *stats
+			c.fetchedAt = time.Now()
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(filesDesc, prometheus.GaugeValue, float64(c.stats.Files))
+	ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.GaugeValue, float64(c.stats.Bytes))
+}
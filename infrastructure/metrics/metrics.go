@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "archive"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	UploadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes of file content stored.",
+	})
+
+	DownloadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "Bytes of file content streamed to clients.",
+	})
+
+	GridFSOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gridfs_operation_duration_seconds",
+		Help:      "Latency of GridFS repository operations by operation and outcome.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"operation", "outcome"})
+
+	ValidationRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_rejections_total",
+		Help:      "Uploads rejected by validation, by reason.",
+	}, []string{"reason"})
+
+	StorageOrphanChunkSets = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_orphan_chunk_sets",
+		Help:      "Stale GridFS chunk sets without a files document, as of the last storage check.",
+	})
+
+	StorageOrphanBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_orphan_bytes",
+		Help:      "Bytes held by stale orphan GridFS chunks, as of the last storage check.",
+	})
+
+	StorageIncompleteFiles = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_incomplete_files",
+		Help:      "Files with missing or truncated chunks, as of the last storage check.",
+	})
+
+	JobsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_processed_total",
+		Help:      "Background job attempts by job type and resulting status.",
+	}, []string{"type", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		UploadedBytes,
+		DownloadedBytes,
+		GridFSOperationDuration,
+		ValidationRejections,
+		StorageOrphanChunkSets,
+		StorageOrphanBytes,
+		StorageIncompleteFiles,
+		JobsProcessed,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware records request counts and latencies labelled with the route
+// template (e.g. "/api/v1/files/:id") rather than the raw path.
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+		status := c.Response().Status
+
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := prometheus.Labels{
+			"method": c.Request().Method,
+			"route":  route,
+			"status": strconv.Itoa(status),
+		}
+		HTTPRequests.With(labels).Inc()
+		HTTPRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
+
+// ObserveGridFS records the duration of a repository operation. Call it
+// deferred with a pointer to the operation's named error result.
+func ObserveGridFS(operation string, start time.Time, err *error) {
+	outcome := "success"
+	if err != nil && *err != nil {
+		outcome = "error"
+	}
+	GridFSOperationDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
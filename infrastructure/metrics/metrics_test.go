@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"go.uber.org/zap"
+)
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware)
+	e.GET("/api/v1/files/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.ErrNotFound
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	before := map[string]float64{}
+	for _, status := range []string{"200", "404"} {
+		before[status] = testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/api/v1/files/:id", status))
+	}
+	unmatched := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404"))
+
+	for _, path := range []string{"/api/v1/files/f001", "/api/v1/files/f002", "/api/v1/files/missing", "/nowhere"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	for status, want := range map[string]float64{"200": 2, "404": 1} {
+		got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "/api/v1/files/:id", status)) - before[status]
+		if got != want {
+			t.Errorf("status %s counted %v times, want %v", status, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")) - unmatched; got != 1 {
+		t.Errorf("unmatched path counted %v times, want 1", got)
+	}
+}
+
+func TestObserveGridFSRecordsOutcome(t *testing.T) {
+	success := `archive_gridfs_operation_duration_seconds_count{operation="test",outcome="success"}`
+	failure := `archive_gridfs_operation_duration_seconds_count{operation="test",outcome="error"}`
+	successes, failures := sampleCount(t, success), sampleCount(t, failure)
+
+	func() (err error) {
+		defer ObserveGridFS("test", time.Now(), &err)
+		return nil
+	}()
+	func() (err error) {
+		defer ObserveGridFS("test", time.Now(), &err)
+		return errors.New("not primary")
+	}()
+
+	if got := sampleCount(t, success) - successes; got != 1 {
+		t.Errorf("%v successes observed, want 1", got)
+	}
+	if got := sampleCount(t, failure) - failures; got != 1 {
+		t.Errorf("%v failures observed, want 1", got)
+	}
+}
+
+// sampleCount returns the value of series in the exposition, 0 when absent.
+func sampleCount(t *testing.T, series string) float64 {
+	t.Helper()
+	for _, line := range strings.Split(scrape(t), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// statsRepository counts the Stats calls of a collector.
+type statsRepository struct {
+	domain.FileRepository
+	calls int
+	err   error
+}
+
+func (r *statsRepository) Stats(ctx context.Context) (*domain.ArchiveStats, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &domain.ArchiveStats{Files: 3, Bytes: 2048}, nil
+}
+
+func TestArchiveCollectorCachesStats(t *testing.T) {
+	repo := &statsRepository{}
+	collector := NewArchiveCollector(repo)
+	want := `
+# HELP archive_files Number of files in the archive.
+# TYPE archive_files gauge
+archive_files 3
+# HELP archive_files_bytes Total size of files in the archive.
+# TYPE archive_files_bytes gauge
+archive_files_bytes 2048
+`
+	for i := 0; i < 2; i++ {
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("stats fetched %d times within the TTL", repo.calls)
+	}
+
+	// Expired totals are refetched; on failure the last ones are kept
+	collector.fetchedAt = time.Now().Add(-archiveStatsTTL - time.Second)
+	repo.err = errors.New("connection refused")
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+	if repo.calls != 2 {
+		t.Errorf("expired stats fetched %d times", repo.calls)
+	}
+}
+
+// scrape returns the exposition of the registry.
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/metrics", nil), rec)
+	if err := Handler()(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics status %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestHandlerExposesArchiveMetrics(t *testing.T) {
+	UploadedBytes.Add(0)
+	body := scrape(t)
+	for _, name := range []string{"archive_uploaded_bytes_total", "archive_storage_orphan_chunk_sets", "go_goroutines"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("%s missing from the exposition", name)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCollection = "audit_log"
+
+type auditDocument struct {
+	Sequence     int64                 `bson:"seq"`
+	Actor        string                `bson:"actor"`
+	Action       string                `bson:"action"`
+	FileID       string                `bson:"file_id,omitempty"`
+	CollectionID string                `bson:"collection_id,omitempty"`
+	IP           string                `bson:"ip"`
+	Timestamp    time.Time             `bson:"timestamp"`
+	Outcome      string                `bson:"outcome"`
+	Status       int                   `bson:"status"`
+	Changes      []auditChangeDocument `bson:"changes,omitempty"`
+	// HashVersion is absent from entries written before it existed
+	HashVersion int    `bson:"hash_version,omitempty"`
+	PrevHash    string `bson:"prev_hash"`
+	Hash        string `bson:"hash"`
+}
+
+type auditChangeDocument struct {
+	Field string `bson:"field"`
+	From  string `bson:"from"`
+	To    string `bson:"to"`
+}
+
+func (d *auditDocument) toDomain() *domain.AuditEntry {
+	var changes []domain.AuditChange
+	for _, change := range d.Changes {
+		changes = append(changes, domain.AuditChange{Field: change.Field, From: change.From, To: change.To})
+	}
+	return &domain.AuditEntry{
+		Sequence:     d.Sequence,
+		Actor:        d.Actor,
+		Action:       domain.AuditAction(d.Action),
+		FileID:       d.FileID,
+		CollectionID: d.CollectionID,
+		IP:           d.IP,
+		Timestamp:    d.Timestamp,
+		Outcome:      domain.AuditOutcome(d.Outcome),
+		Status:       d.Status,
+		Changes:      changes,
+		HashVersion:  d.HashVersion,
+		PrevHash:     d.PrevHash,
+		Hash:         d.Hash,
+	}
+}
+
+type MongoAuditRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoAuditRepository(db *mongo.Database) *MongoAuditRepository {
+	return &MongoAuditRepository{db: db}
+}
+
+func (r *MongoAuditRepository) collection() *mongo.Collection {
+	return r.db.Collection(auditCollection)
+}
+
+// EnsureIndexes creates the unique sequence index that keeps the chain linear
+// when several instances append concurrently.
+func (r *MongoAuditRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "file_id", Value: 1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}}},
+	})
+	return errors.Wrap(err, "failed to create audit indexes")
+}
+
+func (r *MongoAuditRepository) Last(ctx context.Context) (*domain.AuditEntry, error) {
+	var doc auditDocument
+	err := r.collection().FindOne(
+		ctx,
+		bson.M{},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find last audit entry")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	doc := auditDocument{
+		Sequence:     entry.Sequence,
+		Actor:        entry.Actor,
+		Action:       string(entry.Action),
+		FileID:       entry.FileID,
+		CollectionID: entry.CollectionID,
+		IP:           entry.IP,
+		Timestamp:    entry.Timestamp,
+		Outcome:      string(entry.Outcome),
+		Status:       entry.Status,
+		HashVersion:  entry.HashVersion,
+		PrevHash:     entry.PrevHash,
+		Hash:         entry.Hash,
+	}
+	for _, change := range entry.Changes {
+		doc.Changes = append(doc.Changes, auditChangeDocument{Field: change.Field, From: change.From, To: change.To})
+	}
+	_, err := r.collection().InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAuditSequenceConflict
+	}
+	return errors.Wrap(err, "failed to append audit entry")
+}
+
+func auditFilterQuery(filter domain.AuditFilter) bson.M {
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = string(filter.Action)
+	}
+	if filter.FileID != "" {
+		query["file_id"] = filter.FileID
+	}
+	return query
+}
+
+func (r *MongoAuditRepository) FindAll(ctx context.Context, filter domain.AuditFilter, skip, limit int64) ([]*domain.AuditEntry, error) {
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	cursor, err := r.collection().Find(ctx, auditFilterQuery(filter), findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find audit entries")
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*domain.AuditEntry
+	for cursor.Next(ctx) {
+		var doc auditDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode audit entry")
+		}
+		entries = append(entries, doc.toDomain())
+	}
+	return entries, errors.Wrap(cursor.Err(), "failed to iterate audit entries")
+}
+
+func (r *MongoAuditRepository) Count(ctx context.Context, filter domain.AuditFilter) (int64, error) {
+	count, err := r.collection().CountDocuments(ctx, auditFilterQuery(filter))
+	return count, errors.Wrap(err, "failed to count audit entries")
+}
+
+func (r *MongoAuditRepository) Walk(ctx context.Context, fn func(entry *domain.AuditEntry) bool) error {
+	cursor, err := r.collection().Find(
+		ctx,
+		bson.M{},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read audit log")
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc auditDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return errors.Wrap(err, "failed to decode audit entry")
+		}
+		if !fn(doc.toDomain()) {
+			return nil
+		}
+	}
+	return errors.Wrap(cursor.Err(), "failed to iterate audit log")
+}
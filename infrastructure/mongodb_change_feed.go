@@ -0,0 +1,296 @@
+package infrastructure
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cursor prefixes tell which source a Last-Event-ID came from.
+const (
+	changeStreamCursorPrefix = "cs:"
+	outboxCursorPrefix       = "ob:"
+)
+
+// Server error codes meaning change streams are not available at all, as on
+// a standalone server.
+const (
+	codeIllegalOperation         = 20
+	codeChangeStreamNotSupported = 40573
+)
+
+const (
+	changeFeedPollInterval = 2 * time.Second
+	// changeFeedPollLag keeps the poller slightly behind the present so
+	// events committed concurrently are not skipped.
+	changeFeedPollLag   = time.Second
+	changeFeedPollBatch = 100
+)
+
+// MongoChangeFeed streams archive changes from a change stream on the GridFS
+// files collection. Standalone servers do not support change streams; there
+// the feed falls back to polling committed events in the outbox.
+type MongoChangeFeed struct {
+	db         *mongo.Database
+	bucketName string
+	// changeStreamsUnsupported is set once the server rejected a change
+	// stream so later watchers go straight to polling.
+	changeStreamsUnsupported atomic.Bool
+}
+
+func NewMongoChangeFeed(db *mongo.Database, bucketName string) *MongoChangeFeed {
+	return &MongoChangeFeed{db: db, bucketName: bucketName}
+}
+
+// Watch follows the change stream, or polls the outbox once the server has
+// rejected change streams. A cursor the chosen source cannot resume from
+// (malformed, expired, or from the other source) starts the watcher from now
+// with a reset change.
+func (f *MongoChangeFeed) Watch(ctx context.Context, cursor string, fn func(change domain.Change) error) error {
+	if !f.changeStreamsUnsupported.Load() && !strings.HasPrefix(cursor, outboxCursorPrefix) {
+		resumeToken, ok := strings.CutPrefix(cursor, changeStreamCursorPrefix)
+		reset := cursor != "" && (!ok || resumeToken == "")
+		if reset {
+			resumeToken = ""
+		}
+
+		stream, err := f.openChangeStream(ctx, resumeToken)
+		if err != nil && resumeToken != "" && isServerError(err) && !changeStreamsNotSupported(err) {
+			// The server refused the resume token; only this watcher
+			// starts over
+			configs.Logger.Infow("cannot resume change stream - restarting from now",
+				"error", err.Error(),
+			)
+			stream, err = f.openChangeStream(ctx, "")
+			reset = true
+		}
+		if err == nil {
+			if reset {
+				token, _ := stream.ResumeToken().Lookup("_data").StringValueOK()
+				if err := fn(resetChange(changeStreamCursorPrefix, token)); err != nil {
+					stream.Close(context.Background())
+					return err
+				}
+			}
+			return f.watchChangeStream(ctx, stream, fn)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !changeStreamsNotSupported(err) {
+			return errors.Wrap(err, "failed to open change stream")
+		}
+		f.changeStreamsUnsupported.Store(true)
+		configs.Logger.Warnw("change streams unavailable - falling back to polling",
+			"error", err.Error(),
+		)
+	}
+
+	return f.poll(ctx, cursor, fn)
+}
+
+func isServerError(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr)
+}
+
+func changeStreamsNotSupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(codeChangeStreamNotSupported) || serverErr.HasErrorCode(codeIllegalOperation))
+}
+
+// resetChange tells the watcher that changes may have been missed. Its
+// cursor, when known, resumes from the point the feed restarted at.
+func resetChange(prefix, position string) domain.Change {
+	change := domain.Change{Reset: true}
+	if position != "" {
+		change.Cursor = prefix + position
+	}
+	return change
+}
+
+func (f *MongoChangeFeed) openChangeStream(ctx context.Context, resumeToken string) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+		}}},
+	}
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != "" {
+		streamOptions.SetResumeAfter(bson.M{"_data": resumeToken})
+	}
+
+	return f.db.Collection(f.bucketName+".files").Watch(ctx, pipeline, streamOptions)
+}
+
+func (f *MongoChangeFeed) watchChangeStream(ctx context.Context, stream *mongo.ChangeStream, fn func(change domain.Change) error) error {
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var doc changeStreamDocument
+		if err := stream.Decode(&doc); err != nil {
+			return errors.Wrap(err, "failed to decode change event")
+		}
+
+		token, _ := stream.ResumeToken().Lookup("_data").StringValueOK()
+		change, ok := doc.toChange(token)
+		if !ok {
+			continue
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.Wrap(stream.Err(), "change stream failed")
+}
+
+// changeStreamDocument is a change event on the GridFS files collection.
+type changeStreamDocument struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	UpdateDescription *struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	FullDocument *gridFSFileDocument `bson:"fullDocument"`
+	WallTime     *time.Time          `bson:"wallTime"`
+	ClusterTime  primitive.Timestamp `bson:"clusterTime"`
+}
+
+// checksumOnly reports whether the change only records the checksum of an
+// upload, which the upload's own event already announced.
+func (d *changeStreamDocument) checksumOnly() bool {
+	if d.OperationType != "update" || d.UpdateDescription == nil || len(d.UpdateDescription.RemovedFields) > 0 {
+		return false
+	}
+	for field := range d.UpdateDescription.UpdatedFields {
+		if field != "metadata.sha256" {
+			return false
+		}
+	}
+	return len(d.UpdateDescription.UpdatedFields) > 0
+}
+
+// toChange maps the event to a change resuming at token. Checksum updates
+// are dropped.
+func (d *changeStreamDocument) toChange(token string) (domain.Change, bool) {
+	if d.checksumOnly() {
+		return domain.Change{}, false
+	}
+
+	eventType := domain.EventFileUpdated
+	switch d.OperationType {
+	case "insert":
+		eventType = domain.EventFileUploaded
+	case "delete":
+		eventType = domain.EventFileDeleted
+	}
+
+	occurredAt := time.Unix(int64(d.ClusterTime.T), 0).UTC()
+	if d.WallTime != nil {
+		occurredAt = d.WallTime.UTC()
+	}
+
+	var data map[string]interface{}
+	if d.FullDocument != nil && eventType != domain.EventFileDeleted {
+		data = domain.FileEventData(d.FullDocument.toDomain())
+	}
+
+	return domain.Change{
+		Cursor: changeStreamCursorPrefix + token,
+		Event: domain.Event{
+			ID:         token,
+			Type:       eventType,
+			OccurredAt: occurredAt,
+			FileID:     d.DocumentKey.ID.Hex(),
+			Data:       data,
+		},
+	}, true
+}
+
+// poll reads committed outbox events in (committed_at, _id) order. The
+// cursor has the form "ob:<unix millis>:<event id>"; any other cursor,
+// including change stream cursors issued before the fallback, starts from
+// now with a reset change.
+func (f *MongoChangeFeed) poll(ctx context.Context, cursor string, fn func(change domain.Change) error) error {
+	after, afterID := time.Now().UTC(), ""
+	position, ok := strings.CutPrefix(cursor, outboxCursorPrefix)
+	millis, id, _ := strings.Cut(position, ":")
+	if ms, err := strconv.ParseInt(millis, 10, 64); ok && err == nil {
+		after, afterID = time.UnixMilli(ms).UTC(), id
+	} else if cursor != "" {
+		if err := fn(resetChange(outboxCursorPrefix, strconv.FormatInt(after.UnixMilli(), 10)+":")); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(changeFeedPollInterval)
+	defer ticker.Stop()
+
+	outbox := f.db.Collection(outboxCollection)
+	for {
+		filter := bson.M{
+			"status":       bson.M{"$in": bson.A{string(domain.OutboxReady), string(domain.OutboxPublished)}},
+			"committed_at": bson.M{"$lte": time.Now().UTC().Add(-changeFeedPollLag)},
+			"$or": bson.A{
+				bson.M{"committed_at": bson.M{"$gt": after}},
+				bson.M{"committed_at": after, "_id": bson.M{"$gt": afterID}},
+			},
+		}
+
+		found, err := outbox.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "committed_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(changeFeedPollBatch))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to poll outbox")
+		}
+
+		var docs []outboxDocument
+		if err := found.All(ctx, &docs); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read outbox")
+		}
+
+		for _, doc := range docs {
+			after, afterID = doc.CommittedAt.UTC(), doc.ID
+			change := domain.Change{
+				Cursor: outboxCursorPrefix + strconv.FormatInt(after.UnixMilli(), 10) + ":" + afterID,
+				Event:  doc.toDomain().Event,
+			}
+			if err := fn(change); err != nil {
+				return err
+			}
+		}
+
+		if len(docs) == changeFeedPollBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestChangeStreamsNotSupported(t *testing.T) {
+	for name, test := range map[string]struct {
+		err  error
+		want bool
+	}{
+		"standalone":        {mongo.CommandError{Code: 40573, Name: "Location40573"}, true},
+		"illegal operation": {mongo.CommandError{Code: 20, Name: "IllegalOperation"}, true},
+		"history lost":      {mongo.CommandError{Code: 286, Name: "ChangeStreamHistoryLost"}, false},
+		"network":           {errors.New("connection reset"), false},
+	} {
+		if got := changeStreamsNotSupported(test.err); got != test.want {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+// watchFirst returns the first change the feed produces for cursor; the feed
+// is stopped before it reaches the database.
+func watchFirst(t *testing.T, feed *MongoChangeFeed, cursor string) domain.Change {
+	t.Helper()
+	stop := errors.New("stop")
+	var first domain.Change
+	err := feed.Watch(context.Background(), cursor, func(change domain.Change) error {
+		first = change
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("cursor %q: got %v, want a change", cursor, err)
+	}
+	return first
+}
+
+func TestPollingFeedResetsForeignCursors(t *testing.T) {
+	feed := NewMongoChangeFeed(nil, "files")
+	feed.changeStreamsUnsupported.Store(true)
+
+	for _, cursor := range []string{"cs:8263A1F2000000012B", "ob:yesterday:x", "garbage"} {
+		change := watchFirst(t, feed, cursor)
+		if !change.Reset || !strings.HasPrefix(change.Cursor, outboxCursorPrefix) {
+			t.Errorf("cursor %q: got %+v, want a reset with an outbox cursor", cursor, change)
+		}
+	}
+}
+
+func TestChangeStreamMapping(t *testing.T) {
+	id := primitive.NewObjectID()
+	wallTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := bson.M{
+		"_id":        id,
+		"filename":   "report.pdf",
+		"length":     int64(7),
+		"uploadDate": wallTime,
+		"metadata":   bson.M{"contentType": "application/pdf", "sha256": "abc"},
+	}
+	event := func(operation string, update bson.M) bson.M {
+		doc := bson.M{
+			"operationType": operation,
+			"documentKey":   bson.M{"_id": id},
+			"wallTime":      wallTime,
+			"clusterTime":   primitive.Timestamp{T: uint32(wallTime.Unix())},
+		}
+		if operation != "delete" {
+			doc["fullDocument"] = file
+		}
+		if update != nil {
+			doc["updateDescription"] = update
+		}
+		return doc
+	}
+
+	tests := []struct {
+		name  string
+		event bson.M
+		want  domain.EventType
+	}{
+		{"upload", event("insert", nil), domain.EventFileUploaded},
+		{"rename", event("update", bson.M{"updatedFields": bson.M{"filename": "minutes.pdf"}, "removedFields": bson.A{}}), domain.EventFileUpdated},
+		{"checksum", event("update", bson.M{"updatedFields": bson.M{"metadata.sha256": "abc"}, "removedFields": bson.A{}}), ""},
+		{"checksum and tags", event("update", bson.M{"updatedFields": bson.M{"metadata.sha256": "abc", "metadata.tags": bson.A{"q1"}}, "removedFields": bson.A{}}), domain.EventFileUpdated},
+		{"checksum and removed field", event("update", bson.M{"updatedFields": bson.M{"metadata.sha256": "abc"}, "removedFields": bson.A{"metadata.description"}}), domain.EventFileUpdated},
+		{"replace", event("replace", nil), domain.EventFileUpdated},
+		{"delete", event("delete", nil), domain.EventFileDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := bson.Marshal(tt.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc changeStreamDocument
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			change, ok := doc.toChange("8263A1F2")
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("got %+v, want the change dropped", change)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("change dropped")
+			}
+			if change.Cursor != changeStreamCursorPrefix+"8263A1F2" || change.Event.ID != "8263A1F2" ||
+				change.Event.Type != tt.want || change.Event.FileID != id.Hex() || !change.Event.OccurredAt.Equal(wallTime) {
+				t.Errorf("change: %+v", change)
+			}
+			if hasData := change.Event.Data != nil; hasData != (tt.want != domain.EventFileDeleted) {
+				t.Errorf("data: %+v", change.Event.Data)
+			} else if hasData && change.Event.Data["name"] != "report.pdf" {
+				t.Errorf("data name = %v", change.Event.Data["name"])
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package infrastructure
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const collectionCollection = "collections"
+
+type collectionDocument struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Name string             `bson:"name"`
+	// NameKey is the lower-cased name, unique among siblings
+	NameKey   string    `bson:"name_key"`
+	ParentID  string    `bson:"parent_id"`
+	Ancestors []string  `bson:"ancestors"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func newCollectionDocument(id primitive.ObjectID, c *domain.Collection) collectionDocument {
+	ancestors := c.Ancestors
+	if ancestors == nil {
+		ancestors = []string{}
+	}
+	return collectionDocument{
+		ID:        id,
+		Name:      c.Name,
+		NameKey:   strings.ToLower(c.Name),
+		ParentID:  c.ParentID,
+		Ancestors: ancestors,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func (d *collectionDocument) toDomain() *domain.Collection {
+	return &domain.Collection{
+		ID:        d.ID.Hex(),
+		Name:      d.Name,
+		ParentID:  d.ParentID,
+		Ancestors: d.Ancestors,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+// MongoCollectionRepository stores the collection tree with the path of
+// ancestors in every collection, so that subtrees are found with one query.
+type MongoCollectionRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoCollectionRepository(db *mongo.Database) *MongoCollectionRepository {
+	return &MongoCollectionRepository{db: db}
+}
+
+func (r *MongoCollectionRepository) collection() *mongo.Collection {
+	return r.db.Collection(collectionCollection)
+}
+
+// EnsureIndexes creates the unique sibling name index and the index used to
+// find subtrees.
+func (r *MongoCollectionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "parent_id", Value: 1}, {Key: "name_key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "ancestors", Value: 1}}},
+	})
+	return errors.Wrap(err, "failed to create collection indexes")
+}
+
+func (r *MongoCollectionRepository) Save(ctx context.Context, collection *domain.Collection) error {
+	id := primitive.NewObjectID()
+	_, err := r.collection().InsertOne(ctx, newCollectionDocument(id, collection))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrCollectionNameTaken
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to save collection")
+	}
+	collection.ID = id.Hex()
+	return nil
+}
+
+func (r *MongoCollectionRepository) Update(ctx context.Context, collection *domain.Collection) error {
+	id, err := primitive.ObjectIDFromHex(collection.ID)
+	if err != nil {
+		return domain.ErrCollectionNotFound
+	}
+	result, err := r.collection().ReplaceOne(ctx, bson.M{"_id": id}, newCollectionDocument(id, collection))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrCollectionNameTaken
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to update collection")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (r *MongoCollectionRepository) FindByID(ctx context.Context, id string) (*domain.Collection, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrCollectionNotFound
+	}
+
+	var doc collectionDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find collection")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoCollectionRepository) FindByIDs(ctx context.Context, ids []string) ([]*domain.Collection, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return nil, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+}
+
+func (r *MongoCollectionRepository) FindChildren(ctx context.Context, parentID string) ([]*domain.Collection, error) {
+	return r.find(ctx, bson.M{"parent_id": parentID})
+}
+
+func (r *MongoCollectionRepository) find(ctx context.Context, filter bson.M) ([]*domain.Collection, error) {
+	cursor, err := r.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name_key", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find collections")
+	}
+	defer cursor.Close(ctx)
+
+	var collections []*domain.Collection
+	for cursor.Next(ctx) {
+		var doc collectionDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode collection")
+		}
+		collections = append(collections, doc.toDomain())
+	}
+	return collections, errors.Wrap(cursor.Err(), "failed to read collections")
+}
+
+func (r *MongoCollectionRepository) FindDescendantIDs(ctx context.Context, id string) ([]string, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{"ancestors": id}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find collections")
+	}
+	defer cursor.Close(ctx)
+
+	var ids []string
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode collection")
+		}
+		ids = append(ids, doc.ID.Hex())
+	}
+	return ids, errors.Wrap(cursor.Err(), "failed to read collections")
+}
+
+func (r *MongoCollectionRepository) MoveDescendants(ctx context.Context, id string, oldAncestors, newAncestors []string) error {
+	if newAncestors == nil {
+		newAncestors = []string{}
+	}
+	// Keep everything from id downwards and put the new path in front of it
+	_, err := r.collection().UpdateMany(ctx, bson.M{"ancestors": id}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ancestors": bson.M{"$concatArrays": bson.A{
+				newAncestors,
+				bson.M{"$slice": bson.A{"$ancestors", len(oldAncestors), bson.M{"$size": "$ancestors"}}},
+			}},
+			"updated_at": time.Now().UTC(),
+		}}},
+	})
+	return errors.Wrap(err, "failed to move collections")
+}
+
+// Lock sets a fresh lock value, as writes that leave the document unchanged
+// take no lock.
+func (r *MongoCollectionRepository) Lock(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrCollectionNotFound
+	}
+	result, err := r.collection().UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"lock": primitive.NewObjectID()}})
+	if err != nil {
+		return errors.Wrap(err, "failed to lock collection")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (r *MongoCollectionRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrCollectionNotFound
+	}
+	result, err := r.collection().DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete collection")
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package infrastructure
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exportCollection = "exports"
+
+type exportFilterDocument struct {
+	IDs          []string  `bson:"ids,omitempty"`
+	ContentType  string    `bson:"content_type,omitempty"`
+	UploadedFrom time.Time `bson:"uploaded_from,omitempty"`
+	UploadedTo   time.Time `bson:"uploaded_to,omitempty"`
+	// CollectionIDs is the collection scope, resolved when the export was
+	// requested.
+	CollectionIDs []string `bson:"collection_ids,omitempty"`
+}
+
+type exportDocument struct {
+	ID         primitive.ObjectID   `bson:"_id"`
+	Filter     exportFilterDocument `bson:"filter"`
+	Status     string               `bson:"status"`
+	Files      int                  `bson:"files"`
+	Missing    []string             `bson:"missing,omitempty"`
+	Size       int64                `bson:"size"`
+	Error      string               `bson:"error,omitempty"`
+	CreatedBy  string               `bson:"created_by"`
+	CreatedAt  time.Time            `bson:"created_at"`
+	FinishedAt time.Time            `bson:"finished_at,omitempty"`
+	ExpiresAt  time.Time            `bson:"expires_at"`
+}
+
+func newExportDocument(id primitive.ObjectID, e *domain.Export) exportDocument {
+	return exportDocument{
+		ID: id,
+		Filter: exportFilterDocument{
+			IDs:           e.Filter.IDs,
+			ContentType:   e.Filter.ContentType,
+			UploadedFrom:  e.Filter.UploadedFrom,
+			UploadedTo:    e.Filter.UploadedTo,
+			CollectionIDs: e.Filter.CollectionIDs,
+		},
+		Status:     string(e.Status),
+		Files:      e.Files,
+		Missing:    e.Missing,
+		Size:       e.Size,
+		Error:      e.Error,
+		CreatedBy:  e.CreatedBy,
+		CreatedAt:  e.CreatedAt,
+		FinishedAt: e.FinishedAt,
+		ExpiresAt:  e.ExpiresAt,
+	}
+}
+
+func (d *exportDocument) toDomain() *domain.Export {
+	return &domain.Export{
+		ID: d.ID.Hex(),
+		Filter: domain.FileFilter{
+			IDs:           d.Filter.IDs,
+			ContentType:   d.Filter.ContentType,
+			UploadedFrom:  d.Filter.UploadedFrom,
+			UploadedTo:    d.Filter.UploadedTo,
+			CollectionIDs: d.Filter.CollectionIDs,
+		},
+		Status:     domain.ExportStatus(d.Status),
+		Files:      d.Files,
+		Missing:    d.Missing,
+		Size:       d.Size,
+		Error:      d.Error,
+		CreatedBy:  d.CreatedBy,
+		CreatedAt:  d.CreatedAt,
+		FinishedAt: d.FinishedAt,
+		ExpiresAt:  d.ExpiresAt,
+	}
+}
+
+// MongoExportRepository keeps export state in the exports collection and the
+// archives in their own GridFS bucket, under the ID of the export.
+type MongoExportRepository struct {
+	db         *mongo.Database
+	bucketName string
+}
+
+func NewMongoExportRepository(db *mongo.Database, bucketName string) *MongoExportRepository {
+	return &MongoExportRepository{db: db, bucketName: bucketName}
+}
+
+func (r *MongoExportRepository) collection() *mongo.Collection {
+	return r.db.Collection(exportCollection)
+}
+
+func (r *MongoExportRepository) Save(ctx context.Context, export *domain.Export) error {
+	id := primitive.NewObjectID()
+	if _, err := r.collection().InsertOne(ctx, newExportDocument(id, export)); err != nil {
+		return errors.Wrap(err, "failed to save export")
+	}
+	export.ID = id.Hex()
+	return nil
+}
+
+func (r *MongoExportRepository) Update(ctx context.Context, export *domain.Export) error {
+	id, err := primitive.ObjectIDFromHex(export.ID)
+	if err != nil {
+		return domain.ErrExportNotFound
+	}
+	_, err = r.collection().ReplaceOne(ctx, bson.M{"_id": id}, newExportDocument(id, export))
+	return errors.Wrap(err, "failed to update export")
+}
+
+func (r *MongoExportRepository) FindByID(ctx context.Context, id string) (*domain.Export, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrExportNotFound
+	}
+
+	var doc exportDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find export")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoExportRepository) FindExpired(ctx context.Context, now time.Time) ([]*domain.Export, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{"expires_at": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find expired exports")
+	}
+	defer cursor.Close(ctx)
+
+	var exports []*domain.Export
+	for cursor.Next(ctx) {
+		var doc exportDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode export")
+		}
+		exports = append(exports, doc.toDomain())
+	}
+	return exports, errors.Wrap(cursor.Err(), "failed to read expired exports")
+}
+
+func (r *MongoExportRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrExportNotFound
+	}
+
+	bucket, err := openBucket(r.db, r.bucketName)
+	if err != nil {
+		return err
+	}
+	if err := bucket.DeleteContext(ctx, objID); err != nil && err != gridfs.ErrFileNotFound {
+		return errors.Wrap(err, "failed to delete export archive")
+	}
+	_, err = r.collection().DeleteOne(ctx, bson.M{"_id": objID})
+	return errors.Wrap(err, "failed to delete export")
+}
+
+func (r *MongoExportRepository) WriteContent(ctx context.Context, id string, content io.Reader) (int64, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, domain.ErrExportNotFound
+	}
+
+	bucket, err := openBucket(r.db, r.bucketName)
+	if err != nil {
+		return 0, err
+	}
+	uploadStream, err := bucket.OpenUploadStreamWithID(objID, "export-"+id+".zip",
+		options.GridFSUpload().SetMetadata(bson.M{"contentType": "application/zip"}))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open export upload stream")
+	}
+
+	size, err := io.Copy(uploadStream, &contextReader{ctx: ctx, r: content})
+	if err != nil {
+		_ = uploadStream.Abort()
+		return 0, errors.Wrap(err, "failed to write export archive")
+	}
+	if err := uploadStream.Close(); err != nil {
+		_ = uploadStream.Abort()
+		return 0, errors.Wrap(err, "failed to finalize export archive")
+	}
+	return size, nil
+}
+
+func (r *MongoExportRepository) OpenContent(ctx context.Context, id string) (io.ReadCloser, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrExportNotFound
+	}
+
+	bucket, err := openBucket(r.db, r.bucketName)
+	if err != nil {
+		return nil, err
+	}
+	downloadStream, err := bucket.OpenDownloadStream(objID)
+	if err == gridfs.ErrFileNotFound {
+		return nil, domain.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open export archive")
+	}
+	return newTracedReadCloser(ctx, downloadStream), nil
+}
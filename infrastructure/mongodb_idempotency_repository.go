@@ -0,0 +1,123 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyCollection = "idempotency_keys"
+
+type idempotencyDocument struct {
+	Key         string            `bson:"_id"`
+	Fingerprint string            `bson:"fingerprint"`
+	Completed   bool              `bson:"completed"`
+	Status      int               `bson:"status,omitempty"`
+	Header      map[string]string `bson:"header,omitempty"`
+	Body        []byte            `bson:"body,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at"`
+	ExpiresAt   time.Time         `bson:"expires_at"`
+}
+
+func newIdempotencyDocument(r *domain.IdempotencyRecord) idempotencyDocument {
+	return idempotencyDocument{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		Status:      r.Status,
+		Header:      r.Header,
+		Body:        r.Body,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+}
+
+func (d *idempotencyDocument) toDomain() *domain.IdempotencyRecord {
+	return &domain.IdempotencyRecord{
+		Key:         d.Key,
+		Fingerprint: d.Fingerprint,
+		Completed:   d.Completed,
+		Status:      d.Status,
+		Header:      d.Header,
+		Body:        d.Body,
+		CreatedAt:   d.CreatedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
+
+// MongoIdempotencyRepository keeps one document per idempotency key, using
+// the key as _id so that concurrent requests cannot both reserve it.
+type MongoIdempotencyRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoIdempotencyRepository(db *mongo.Database) *MongoIdempotencyRepository {
+	return &MongoIdempotencyRepository{db: db}
+}
+
+func (r *MongoIdempotencyRepository) collection() *mongo.Collection {
+	return r.db.Collection(idempotencyCollection)
+}
+
+// EnsureIndexes lets MongoDB remove records once they expire. Until the TTL
+// monitor gets to them, expired records are ignored by Reserve.
+func (r *MongoIdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return errors.Wrap(err, "failed to create idempotency indexes")
+}
+
+// reserveAttempts bounds how often Reserve retries a key that is released or
+// expires between its two steps.
+const reserveAttempts = 3
+
+func (r *MongoIdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	// Replacing only an expired record, with upsert, either takes a free or
+	// expired key or fails with a duplicate key error when it is taken
+	filter := bson.M{"_id": record.Key, "expires_at": bson.M{"$lte": record.CreatedAt}}
+	for attempt := 0; attempt < reserveAttempts; attempt++ {
+		_, err := r.collection().ReplaceOne(ctx, filter, newIdempotencyDocument(record), options.Replace().SetUpsert(true))
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, errors.Wrap(err, "failed to reserve idempotency key")
+		}
+
+		var doc idempotencyDocument
+		err = r.collection().FindOne(ctx, bson.M{"_id": record.Key}).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			// Released or expired in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find idempotency key")
+		}
+		return doc.toDomain(), nil
+	}
+	return nil, domain.ErrIdempotencyInProgress
+}
+
+func (r *MongoIdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	filter := bson.M{"_id": record.Key, "fingerprint": record.Fingerprint, "completed": false}
+	result, err := r.collection().ReplaceOne(ctx, filter, newIdempotencyDocument(record))
+	if err != nil {
+		return errors.Wrap(err, "failed to store idempotent response")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrIdempotencyInProgress
+	}
+	return nil
+}
+
+func (r *MongoIdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.collection().DeleteOne(ctx, bson.M{"_id": key, "completed": false})
+	return errors.Wrap(err, "failed to release idempotency key")
+}
@@ -0,0 +1,266 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const jobCollection = "jobs"
+
+type jobDocument struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Type        string             `bson:"type"`
+	Payload     bson.M             `bson:"payload,omitempty"`
+	Status      string             `bson:"status"`
+	Attempts    int                `bson:"attempts"`
+	MaxAttempts int                `bson:"max_attempts"`
+	RunAt       time.Time          `bson:"run_at"`
+	LockedBy    string             `bson:"locked_by,omitempty"`
+	LockedUntil time.Time          `bson:"locked_until,omitempty"`
+	LastError   string             `bson:"last_error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+	FinishedAt  time.Time          `bson:"finished_at,omitempty"`
+	ExpiresAt   time.Time          `bson:"expires_at,omitempty"`
+}
+
+func (d *jobDocument) toDomain() *domain.Job {
+	return &domain.Job{
+		ID:          d.ID.Hex(),
+		Type:        d.Type,
+		Payload:     d.Payload,
+		Status:      domain.JobStatus(d.Status),
+		Attempts:    d.Attempts,
+		MaxAttempts: d.MaxAttempts,
+		RunAt:       d.RunAt,
+		LockedBy:    d.LockedBy,
+		LockedUntil: d.LockedUntil,
+		LastError:   d.LastError,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+		FinishedAt:  d.FinishedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
+
+// MongoJobRepository keeps the job queue in the jobs collection. Workers
+// claim jobs with an atomic update that leases them for a while, so a job
+// held by a worker that died is claimed again once its lease runs out.
+type MongoJobRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoJobRepository(db *mongo.Database) *MongoJobRepository {
+	return &MongoJobRepository{db: db}
+}
+
+func (r *MongoJobRepository) collection() *mongo.Collection {
+	return r.db.Collection(jobCollection)
+}
+
+// EnsureIndexes creates the indexes used to claim due jobs and expired
+// leases, and the TTL index that removes succeeded jobs.
+func (r *MongoJobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "locked_until", Value: 1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return errors.Wrap(err, "failed to create job indexes")
+}
+
+func (r *MongoJobRepository) Save(ctx context.Context, job *domain.Job) error {
+	doc := jobDocument{
+		ID:          primitive.NewObjectID(),
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LastError:   job.LastError,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+	if _, err := r.collection().InsertOne(ctx, doc); err != nil {
+		return errors.Wrap(err, "failed to save job")
+	}
+	job.ID = doc.ID.Hex()
+	return nil
+}
+
+func (r *MongoJobRepository) FindByID(ctx context.Context, id string) (*domain.Job, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrJobNotFound
+	}
+
+	var doc jobDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find job")
+	}
+	return doc.toDomain(), nil
+}
+
+func jobFilterQuery(filter domain.JobFilter) bson.M {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = string(filter.Status)
+	}
+	return query
+}
+
+func (r *MongoJobRepository) FindAll(ctx context.Context, filter domain.JobFilter, skip, limit int64) ([]*domain.Job, error) {
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection().Find(ctx, jobFilterQuery(filter), findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find jobs")
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []*domain.Job
+	for cursor.Next(ctx) {
+		var doc jobDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode job")
+		}
+		jobs = append(jobs, doc.toDomain())
+	}
+	return jobs, errors.Wrap(cursor.Err(), "failed to read jobs")
+}
+
+func (r *MongoJobRepository) Count(ctx context.Context, filter domain.JobFilter) (int64, error) {
+	count, err := r.collection().CountDocuments(ctx, jobFilterQuery(filter))
+	return count, errors.Wrap(err, "failed to count jobs")
+}
+
+func (r *MongoJobRepository) Claim(ctx context.Context, types []string, worker string, now time.Time, lease time.Duration) (*domain.Job, error) {
+	filter := bson.M{
+		"type": bson.M{"$in": types},
+		"$or": bson.A{
+			bson.M{"status": string(domain.JobPending), "run_at": bson.M{"$lte": now}},
+			bson.M{"status": string(domain.JobRunning), "locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       string(domain.JobRunning),
+			"locked_by":    worker,
+			"locked_until": now.Add(lease),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	claimOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var doc jobDocument
+	err := r.collection().FindOneAndUpdate(ctx, filter, update, claimOptions).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to claim job")
+	}
+	return doc.toDomain(), nil
+}
+
+// leasedBy matches the job id while worker holds its lease.
+func leasedBy(id, worker string) (bson.M, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrJobNotFound
+	}
+	return bson.M{"_id": objID, "status": string(domain.JobRunning), "locked_by": worker}, nil
+}
+
+func (r *MongoJobRepository) Heartbeat(ctx context.Context, id, worker string, until time.Time) error {
+	filter, err := leasedBy(id, worker)
+	if err != nil {
+		return err
+	}
+	result, err := r.collection().UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"locked_until": until, "updated_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to extend job lease")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (r *MongoJobRepository) Finish(ctx context.Context, job *domain.Job, worker string) error {
+	filter, err := leasedBy(job.ID, worker)
+	if err != nil {
+		return err
+	}
+	set := bson.M{
+		"status":     string(job.Status),
+		"attempts":   job.Attempts,
+		"run_at":     job.RunAt,
+		"last_error": job.LastError,
+		"updated_at": job.UpdatedAt,
+	}
+	unset := bson.M{"locked_by": "", "locked_until": ""}
+	for key, value := range map[string]time.Time{"finished_at": job.FinishedAt, "expires_at": job.ExpiresAt} {
+		if value.IsZero() {
+			unset[key] = ""
+		} else {
+			set[key] = value
+		}
+	}
+	result, err := r.collection().UpdateOne(ctx, filter, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return errors.Wrap(err, "failed to finish job")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (r *MongoJobRepository) Retry(ctx context.Context, id string, now time.Time) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrJobNotFound
+	}
+	result, err := r.collection().UpdateOne(ctx,
+		bson.M{"_id": objID, "status": string(domain.JobDead)},
+		bson.M{
+			"$set":   bson.M{"status": string(domain.JobPending), "attempts": 0, "run_at": now, "updated_at": now},
+			"$unset": bson.M{"finished_at": ""},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retry job")
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrJobNotRetryable
+	}
+	return nil
+}
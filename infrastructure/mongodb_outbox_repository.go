@@ -0,0 +1,217 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	outboxCollection = "outbox"
+	// outboxRetention is how long published events are kept before MongoDB
+	// expires them.
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+type outboxDocument struct {
+	ID            string                 `bson:"_id"`
+	Type          string                 `bson:"type"`
+	OccurredAt    time.Time              `bson:"occurred_at"`
+	FileID        string                 `bson:"file_id,omitempty"`
+	Data          map[string]interface{} `bson:"data,omitempty"`
+	Status        string                 `bson:"status"`
+	DeliveredTo   []string               `bson:"delivered_to"`
+	Attempts      int                    `bson:"attempts"`
+	NextAttemptAt time.Time              `bson:"next_attempt_at"`
+	LastError     string                 `bson:"last_error,omitempty"`
+	CreatedAt     time.Time              `bson:"created_at"`
+	CommittedAt   *time.Time             `bson:"committed_at,omitempty"`
+	PublishedAt   *time.Time             `bson:"published_at,omitempty"`
+}
+
+func (d *outboxDocument) toDomain() *domain.OutboxEntry {
+	return &domain.OutboxEntry{
+		Event: domain.Event{
+			ID:         d.ID,
+			Type:       domain.EventType(d.Type),
+			OccurredAt: d.OccurredAt,
+			FileID:     d.FileID,
+			Data:       d.Data,
+		},
+		Status:        domain.OutboxStatus(d.Status),
+		DeliveredTo:   d.DeliveredTo,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+type MongoOutboxRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoOutboxRepository(db *mongo.Database) *MongoOutboxRepository {
+	return &MongoOutboxRepository{db: db}
+}
+
+func (r *MongoOutboxRepository) collection() *mongo.Collection {
+	return r.db.Collection(outboxCollection)
+}
+
+func (r *MongoOutboxRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "committed_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(outboxRetention.Seconds())),
+		},
+	})
+	return errors.Wrap(err, "failed to create outbox indexes")
+}
+
+func (r *MongoOutboxRepository) Stage(ctx context.Context, event domain.Event) error {
+	now := time.Now().UTC()
+	doc := outboxDocument{
+		ID:            event.ID,
+		Type:          string(event.Type),
+		OccurredAt:    event.OccurredAt,
+		FileID:        event.FileID,
+		Data:          event.Data,
+		Status:        string(domain.OutboxStaged),
+		DeliveredTo:   []string{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	_, err := r.collection().InsertOne(ctx, doc)
+	return errors.Wrap(err, "failed to stage outbox event")
+}
+
+// Commit marks a staged event ready. If the staged entry is gone, because
+// recovery discarded it while the change was still being made, the event is
+// inserted ready instead; an event committed already makes the upsert fail
+// with a duplicate key and is left as it is.
+func (r *MongoOutboxRepository) Commit(ctx context.Context, event domain.Event) error {
+	now := time.Now().UTC()
+	_, err := r.collection().UpdateOne(
+		ctx,
+		bson.M{"_id": event.ID, "status": string(domain.OutboxStaged)},
+		bson.M{
+			"$set": bson.M{
+				"status":          string(domain.OutboxReady),
+				"data":            event.Data,
+				"next_attempt_at": now,
+				"committed_at":    now,
+			},
+			"$setOnInsert": bson.M{
+				"type":         string(event.Type),
+				"occurred_at":  event.OccurredAt,
+				"file_id":      event.FileID,
+				"delivered_to": []string{},
+				"attempts":     0,
+				"created_at":   now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		configs.Logger.Errorw("failed to commit outbox event",
+			"error", err.Error(),
+			"event_id", event.ID,
+			"event_type", string(event.Type),
+			"file_id", event.FileID,
+		)
+		return errors.Wrap(err, "failed to commit outbox event")
+	}
+	return nil
+}
+
+func (r *MongoOutboxRepository) Discard(ctx context.Context, eventID string) error {
+	_, err := r.collection().DeleteOne(ctx, bson.M{"_id": eventID, "status": string(domain.OutboxStaged)})
+	return errors.Wrap(err, "failed to discard outbox event")
+}
+
+func (r *MongoOutboxRepository) FindStaged(ctx context.Context, olderThan time.Time, limit int64) ([]*domain.OutboxEntry, error) {
+	cursor, err := r.collection().Find(
+		ctx,
+		bson.M{"status": string(domain.OutboxStaged), "created_at": bson.M{"$lt": olderThan}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find staged outbox events")
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*domain.OutboxEntry
+	for cursor.Next(ctx) {
+		var doc outboxDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode outbox event")
+		}
+		entries = append(entries, doc.toDomain())
+	}
+	return entries, errors.Wrap(cursor.Err(), "failed to iterate outbox events")
+}
+
+func (r *MongoOutboxRepository) ClaimReady(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.OutboxEntry, error) {
+	filter := bson.M{
+		"status":          string(domain.OutboxReady),
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	claimOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var entries []*domain.OutboxEntry
+	for len(entries) < limit {
+		var doc outboxDocument
+		err := r.collection().FindOneAndUpdate(ctx, filter, update, claimOptions).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return entries, errors.Wrap(err, "failed to claim outbox event")
+		}
+		entries = append(entries, doc.toDomain())
+	}
+	return entries, nil
+}
+
+func (r *MongoOutboxRepository) MarkDelivered(ctx context.Context, eventID, sink string) error {
+	_, err := r.collection().UpdateOne(
+		ctx,
+		bson.M{"_id": eventID},
+		bson.M{"$addToSet": bson.M{"delivered_to": sink}},
+	)
+	return errors.Wrap(err, "failed to mark outbox event delivered")
+}
+
+func (r *MongoOutboxRepository) MarkPublished(ctx context.Context, eventID string) error {
+	_, err := r.collection().UpdateOne(
+		ctx,
+		bson.M{"_id": eventID},
+		bson.M{"$set": bson.M{"status": string(domain.OutboxPublished), "published_at": time.Now().UTC()}},
+	)
+	return errors.Wrap(err, "failed to mark outbox event published")
+}
+
+func (r *MongoOutboxRepository) Reschedule(ctx context.Context, eventID string, attempts int, next time.Time, lastError string) error {
+	_, err := r.collection().UpdateOne(
+		ctx,
+		bson.M{"_id": eventID},
+		bson.M{"$set": bson.M{"attempts": attempts, "next_attempt_at": next, "last_error": lastError}},
+	)
+	return errors.Wrap(err, "failed to reschedule outbox event")
+}
@@ -16,37 +16,143 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// gridFSFileDocument is the shape of a document in the GridFS files
+// collection.
+type gridFSFileDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Name       string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   bson.M             `bson:"metadata"`
+}
+
+func (d *gridFSFileDocument) toDomain() *domain.File {
+	file := &domain.File{
+		ID:         d.ID.Hex(),
+		Name:       d.Name,
+		Size:       d.Length,
+		UploadDate: d.UploadDate,
+	}
+	if d.Metadata == nil {
+		return file
+	}
+
+	file.ContentType, _ = d.Metadata["contentType"].(string)
+	file.CollectionID, _ = d.Metadata["collectionId"].(string)
+	file.Description, _ = d.Metadata["description"].(string)
+	file.Category, _ = d.Metadata["category"].(string)
+	file.SHA256, _ = d.Metadata["sha256"].(string)
+	if tags, ok := d.Metadata["tags"].(primitive.A); ok {
+		for _, tag := range tags {
+			if tag, ok := tag.(string); ok {
+				file.Tags = append(file.Tags, tag)
+			}
+		}
+	}
+	switch version := d.Metadata["version"].(type) {
+	case int32:
+		file.Version = int64(version)
+	case int64:
+		file.Version = version
+	}
+	return file
+}
+
+// fileMetadata is the GridFS metadata stored for file. Empty fields are left
+// out.
+func fileMetadata(file *domain.File) bson.M {
+	metadata := bson.M{"contentType": file.ContentType}
+	for key, value := range describedMetadata(file) {
+		if value != nil {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// describedMetadata maps the metadata keys that can change after upload to
+// their values, nil for empty ones.
+func describedMetadata(file *domain.File) bson.M {
+	metadata := bson.M{"collectionId": nil, "description": nil, "tags": nil, "category": nil}
+	if file.CollectionID != "" {
+		metadata["collectionId"] = file.CollectionID
+	}
+	if file.Description != "" {
+		metadata["description"] = file.Description
+	}
+	if len(file.Tags) > 0 {
+		metadata["tags"] = file.Tags
+	}
+	if file.Category != "" {
+		metadata["category"] = file.Category
+	}
+	return metadata
+}
+
 type MongoFileRepository struct {
 	// client *mongo.Client
-	db *mongo.Database
+	db              *mongo.Database
+	bucketName      string
+	uploadTimeout   time.Duration
+	downloadTimeout time.Duration
 }
 
-func NewMongoFileRepository(db *mongo.Database) *MongoFileRepository {
-	return &MongoFileRepository{db: db}
+func NewMongoFileRepository(db *mongo.Database, cfg configs.StorageConfig) *MongoFileRepository {
+	return &MongoFileRepository{
+		db:              db,
+		bucketName:      cfg.Bucket,
+		uploadTimeout:   cfg.UploadTimeout,
+		downloadTimeout: cfg.DownloadTimeout,
+	}
 }
 
 func (r *MongoFileRepository) gridFSBucket() (*gridfs.Bucket, error) {
-	bucket, err := gridfs.NewBucket(r.db, options.GridFSBucket().SetName("files"))
+	return openBucket(r.db, r.bucketName)
+}
+
+func openBucket(db *mongo.Database, name string) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(name))
 	return bucket, errors.Wrap(err, "failed to create GridFS bucket")
 }
 
-func (r *MongoFileRepository) Save(file *domain.File, content io.Reader) error {
+func (r *MongoFileRepository) NextID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+func (r *MongoFileRepository) Save(ctx context.Context, file *domain.File, content io.Reader) (err error) {
+	ctx, finish := startGridFSOperation(ctx, "save")
+	defer finish(&err)
+
 	bucket, err := r.gridFSBucket()
 	if err != nil {
 		return err
 	}
 
+	fileID := primitive.NewObjectID()
+	if file.ID != "" {
+		if fileID, err = primitive.ObjectIDFromHex(file.ID); err != nil {
+			return errors.Wrap(err, "invalid file id")
+		}
+	}
+
 	uploadOpts := options.GridFSUpload().
-		SetMetadata(bson.M{"contentType": file.ContentType})
+		SetMetadata(fileMetadata(file))
+
+	ctx, cancel := context.WithTimeout(ctx, r.uploadTimeout)
+	defer cancel()
 
-	uploadStream, err := bucket.OpenUploadStream(file.Name, uploadOpts)
+	uploadStream, err := bucket.OpenUploadStreamWithID(fileID, file.Name, uploadOpts)
 	if err != nil {
 		return errors.Wrap(err, "failed to open upload stream")
 	}
-	defer uploadStream.Close()
+	deadline, _ := ctx.Deadline()
+	_ = uploadStream.SetWriteDeadline(deadline)
 
-	size, err := io.Copy(uploadStream, content)
+	size, err := io.Copy(uploadStream, &contextReader{ctx: ctx, r: content})
 	if err != nil {
+		// Abort removes the chunks written so far instead of finalizing a
+		// truncated file
+		_ = uploadStream.Abort()
 		configs.Logger.Errorw("failed to save file to GridFS",
 			"error", err.Error(),
 			"file_name", file.Name,
@@ -54,6 +160,10 @@ func (r *MongoFileRepository) Save(file *domain.File, content io.Reader) error {
 		)
 		return errors.Wrap(err, "failed to write content to GridFS")
 	}
+	if err := uploadStream.Close(); err != nil {
+		_ = uploadStream.Abort()
+		return errors.Wrap(err, "failed to finalize GridFS upload")
+	}
 
 	configs.Logger.Infow("file saved successfully",
 		"file_id", file.ID,
@@ -65,7 +175,10 @@ func (r *MongoFileRepository) Save(file *domain.File, content io.Reader) error {
 	return nil
 }
 
-func (r *MongoFileRepository) FindByID(id string) (*domain.File, io.ReadCloser, error) {
+func (r *MongoFileRepository) FindByID(ctx context.Context, id string) (_ *domain.File, _ io.ReadCloser, err error) {
+	ctx, finish := startGridFSOperation(ctx, "find_by_id")
+	defer finish(&err)
+
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, nil, domain.ErrFileNotFound
@@ -76,8 +189,12 @@ func (r *MongoFileRepository) FindByID(id string) (*domain.File, io.ReadCloser,
 		return nil, nil, err
 	}
 
+	// The download context outlives this call: it is released when the
+	// caller closes the returned stream.
+	downloadCtx, cancel := context.WithTimeout(ctx, r.downloadTimeout)
 	downloadStream, err := bucket.OpenDownloadStream(objID)
 	if err != nil {
+		cancel()
 		if err == gridfs.ErrFileNotFound {
 			return nil, nil, domain.ErrFileNotFound
 		}
@@ -85,23 +202,41 @@ func (r *MongoFileRepository) FindByID(id string) (*domain.File, io.ReadCloser,
 	}
 
 	fileDoc := downloadStream.GetFile()
-	file := &domain.File{
-		ID:          fileDoc.ID.(primitive.ObjectID).Hex(),
-		Name:        fileDoc.Name,
-		Size:        fileDoc.Length,
-		ContentType: fileDoc.Metadata.Lookup("contentType").StringValue(),
-		UploadDate:  fileDoc.UploadDate,
+	doc := gridFSFileDocument{
+		ID:         fileDoc.ID.(primitive.ObjectID),
+		Name:       fileDoc.Name,
+		Length:     fileDoc.Length,
+		UploadDate: fileDoc.UploadDate,
+	}
+	if len(fileDoc.Metadata) > 0 {
+		if err := bson.Unmarshal(fileDoc.Metadata, &doc.Metadata); err != nil {
+			_ = downloadStream.Close()
+			cancel()
+			return nil, nil, errors.Wrap(err, "failed to decode file metadata")
+		}
 	}
+	file := doc.toDomain()
 
-	return file, downloadStream, nil
+	deadline, _ := downloadCtx.Deadline()
+	_ = downloadStream.SetReadDeadline(deadline)
+
+	content := &contextReadCloser{
+		contextReader: contextReader{ctx: downloadCtx, r: downloadStream},
+		closer:        downloadStream,
+		cancel:        cancel,
+	}
+	return file, newTracedReadCloser(ctx, content), nil
 }
 
-func (r *MongoFileRepository) FindAll(skip, limit int64) ([]*domain.File, error) {
+func (r *MongoFileRepository) FindAll(ctx context.Context, filter domain.FileFilter, skip, limit int64) (_ []*domain.File, err error) {
+	ctx, finish := startGridFSOperation(ctx, "find_all")
+	defer finish(&err)
+
 	// Add pagination to the query
 	findOptions := options.Find()
 	findOptions.SetSkip(skip)
 	findOptions.SetLimit(limit)
-	findOptions.SetSort(bson.D{{Key: "uploadDate", Value: -1}}) // Sort by newest first
+	findOptions.SetSort(listingSort(-1)) // Sort by newest first
 
 	// cursor, err := r.db.Collection("fs.files").Find(
 	// 	context.Background(),
@@ -113,48 +248,327 @@ func (r *MongoFileRepository) FindAll(skip, limit int64) ([]*domain.File, error)
 		return nil, err
 	}
 
-	cursor, err := bucket.GetFilesCollection().Find(context.Background(), bson.M{}, findOptions)
+	cursor, err := bucket.GetFilesCollection().Find(ctx, fileFilter(filter), findOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find files")
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var files []*domain.File
-	for cursor.Next(context.Background()) {
-		var fileDoc struct {
-			ID         primitive.ObjectID `bson:"_id"`
-			Name       string             `bson:"filename"`
-			Length     int64              `bson:"length"`
-			UploadDate time.Time          `bson:"uploadDate"`
-			Metadata   bson.M             `bson:"metadata"`
+	for cursor.Next(ctx) {
+		var fileDoc gridFSFileDocument
+		if err := cursor.Decode(&fileDoc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode file document")
+		}
+		files = append(files, fileDoc.toDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read files")
+	}
+
+	return files, nil
+}
+
+func (r *MongoFileRepository) FindPage(ctx context.Context, query domain.FilePageQuery) (_ []*domain.File, err error) {
+	ctx, finish := startGridFSOperation(ctx, "find_page")
+	defer finish(&err)
+
+	// Backwards pages are read in ascending order from the cursor and
+	// reversed afterwards
+	conditions, direction := bson.A{fileFilter(query.Filter)}, -1
+	switch {
+	case query.After != nil:
+		position, err := cursorFilter(query.After, "$lt")
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, position)
+	case query.Before != nil:
+		position, err := cursorFilter(query.Before, "$gt")
+		if err != nil {
+			return nil, err
 		}
+		conditions = append(conditions, position)
+		direction = 1
+	}
+	filter := bson.M{"$and": conditions}
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(listingSort(direction)).SetLimit(query.Limit)
+	cursor, err := bucket.GetFilesCollection().Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find files")
+	}
+	defer cursor.Close(ctx)
 
+	var files []*domain.File
+	for cursor.Next(ctx) {
+		var fileDoc gridFSFileDocument
 		if err := cursor.Decode(&fileDoc); err != nil {
 			return nil, errors.Wrap(err, "failed to decode file document")
 		}
+		files = append(files, fileDoc.toDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read files")
+	}
+
+	if direction == 1 {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+	return files, nil
+}
+
+// listingSort orders files by upload date with the ID as tie-breaker, so that
+// files uploaded in the same millisecond keep a stable position.
+func listingSort(direction int) bson.D {
+	return bson.D{{Key: "uploadDate", Value: direction}, {Key: "_id", Value: direction}}
+}
 
-		contentType := ""
-		if fileDoc.Metadata != nil {
-			contentType, _ = fileDoc.Metadata["contentType"].(string)
+// fileFilter translates f into a query on the files collection. IDs that are
+// not valid ObjectIDs cannot match any file and are left out.
+func fileFilter(f domain.FileFilter) bson.M {
+	filter := bson.M{}
+	if f.IDs != nil {
+		ids := make([]primitive.ObjectID, 0, len(f.IDs))
+		for _, id := range f.IDs {
+			if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+				ids = append(ids, objID)
+			}
 		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if f.ContentType != "" {
+		filter["metadata.contentType"] = f.ContentType
+	}
+	uploadDate := bson.M{}
+	if !f.UploadedFrom.IsZero() {
+		uploadDate["$gte"] = f.UploadedFrom
+	}
+	if !f.UploadedTo.IsZero() {
+		uploadDate["$lt"] = f.UploadedTo
+	}
+	if len(uploadDate) > 0 {
+		filter["uploadDate"] = uploadDate
+	}
+	if f.CollectionIDs != nil {
+		filter["metadata.collectionId"] = bson.M{"$in": f.CollectionIDs}
+	}
+	return filter
+}
 
-		files = append(files, &domain.File{
-			ID:          fileDoc.ID.Hex(),
-			Name:        fileDoc.Name,
-			Size:        fileDoc.Length,
-			ContentType: contentType,
-			UploadDate:  fileDoc.UploadDate,
-		})
+// cursorFilter matches the files on one side of c in listing order; op is
+// "$lt" for older files and "$gt" for newer ones.
+func cursorFilter(c *domain.FileCursor, op string) (bson.M, error) {
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
 	}
+	return bson.M{"$or": bson.A{
+		bson.M{"uploadDate": bson.M{op: c.UploadDate}},
+		bson.M{"uploadDate": c.UploadDate, "_id": bson.M{op: id}},
+	}}, nil
+}
 
-	return files, nil
+// EnsureIndexes creates the indexes that serve the file listing, overall and
+// by collection.
+func (r *MongoFileRepository) EnsureIndexes(ctx context.Context) error {
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+	_, err = bucket.GetFilesCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: listingSort(-1)},
+		{Keys: append(bson.D{{Key: "metadata.collectionId", Value: 1}}, listingSort(-1)...)},
+	})
+	return errors.Wrap(err, "failed to create file listing indexes")
 }
 
-func (r *MongoFileRepository) Count() (int64, error) {
+func (r *MongoFileRepository) Count(ctx context.Context, filter domain.FileFilter) (_ int64, err error) {
+	ctx, finish := startGridFSOperation(ctx, "count")
+	defer finish(&err)
+
 	bucket, err := r.gridFSBucket()
 	if err != nil {
 		return 0, err
 	}
-	cursor, _ := bucket.GetFilesCollection().CountDocuments(context.Background(), bson.M{})
-	return cursor, nil
+	count, err := bucket.GetFilesCollection().CountDocuments(ctx, fileFilter(filter))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count files")
+	}
+	return count, nil
+}
+
+func (r *MongoFileRepository) UpdateMetadata(ctx context.Context, file *domain.File) (err error) {
+	ctx, finish := startGridFSOperation(ctx, "update_metadata")
+	defer finish(&err)
+
+	objID, err := primitive.ObjectIDFromHex(file.ID)
+	if err != nil {
+		return domain.ErrFileNotFound
+	}
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"filename": file.Name}
+	unset := bson.M{}
+	for key, value := range describedMetadata(file) {
+		if value != nil {
+			set["metadata."+key] = value
+		} else {
+			unset["metadata."+key] = ""
+		}
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"metadata.version": int64(1)}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// Files that were never changed have no version, which null matches
+	var version interface{}
+	if file.Version > 0 {
+		version = file.Version
+	}
+	result, err := bucket.GetFilesCollection().UpdateOne(ctx, bson.M{"_id": objID, "metadata.version": version}, update)
+	if err != nil {
+		return errors.Wrap(err, "failed to update file")
+	}
+	if result.MatchedCount == 0 {
+		exists, err := r.Exists(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.ErrFileNotFound
+		}
+		return domain.ErrFileModified
+	}
+	file.Version++
+	return nil
+}
+
+func (r *MongoFileRepository) SetChecksum(ctx context.Context, id, sha256 string) (err error) {
+	ctx, finish := startGridFSOperation(ctx, "set_checksum")
+	defer finish(&err)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrFileNotFound
+	}
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+
+	result, err := bucket.GetFilesCollection().UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"metadata.sha256": sha256}})
+	if err != nil {
+		return errors.Wrap(err, "failed to record checksum")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrFileNotFound
+	}
+	return nil
+}
+
+func (r *MongoFileRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, finish := startGridFSOperation(ctx, "delete")
+	defer finish(&err)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrFileNotFound
+	}
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.DeleteContext(ctx, objID); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return domain.ErrFileNotFound
+		}
+		return errors.Wrap(err, "failed to delete file")
+	}
+
+	configs.Logger.Infow("file deleted successfully",
+		"file_id", id,
+	)
+	return nil
+}
+
+func (r *MongoFileRepository) Exists(ctx context.Context, id string) (_ bool, err error) {
+	ctx, finish := startGridFSOperation(ctx, "exists")
+	defer finish(&err)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, nil
+	}
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return false, err
+	}
+
+	count, err := bucket.GetFilesCollection().CountDocuments(ctx, bson.M{"_id": objID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to look up file")
+	}
+	return count > 0, nil
+}
+
+func (r *MongoFileRepository) Stats(ctx context.Context) (_ *domain.ArchiveStats, err error) {
+	ctx, finish := startGridFSOperation(ctx, "stats")
+	defer finish(&err)
+
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := bucket.GetFilesCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"files": bson.M{"$sum": 1},
+			"bytes": bson.M{"$sum": "$length"},
+		}}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate file stats")
+	}
+	defer cursor.Close(ctx)
+
+	stats := &domain.ArchiveStats{}
+	if cursor.Next(ctx) {
+		var doc struct {
+			Files int64 `bson:"files"`
+			Bytes int64 `bson:"bytes"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode file stats")
+		}
+		stats.Files, stats.Bytes = doc.Files, doc.Bytes
+	}
+	return stats, errors.Wrap(cursor.Err(), "failed to read file stats")
+}
+
+// Ping checks that the GridFS files collection can be queried.
+func (r *MongoFileRepository) Ping(ctx context.Context) error {
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+	_, err = bucket.GetFilesCollection().EstimatedDocumentCount(ctx)
+	return errors.Wrap(err, "failed to query GridFS files collection")
 }
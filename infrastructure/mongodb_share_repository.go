@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const shareLinkCollection = "share_links"
+
+type shareLinkDocument struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	FileID       string             `bson:"file_id"`
+	CreatedBy    string             `bson:"created_by"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	ExpiresAt    time.Time          `bson:"expires_at"`
+	MaxDownloads int64              `bson:"max_downloads"`
+	Downloads    int64              `bson:"downloads"`
+	PasswordHash string             `bson:"password_hash,omitempty"`
+	Revoked      bool               `bson:"revoked"`
+}
+
+func (d *shareLinkDocument) toDomain() *domain.ShareLink {
+	return &domain.ShareLink{
+		ID:           d.ID.Hex(),
+		FileID:       d.FileID,
+		CreatedBy:    d.CreatedBy,
+		CreatedAt:    d.CreatedAt,
+		ExpiresAt:    d.ExpiresAt,
+		MaxDownloads: d.MaxDownloads,
+		Downloads:    d.Downloads,
+		PasswordHash: d.PasswordHash,
+		Revoked:      d.Revoked,
+	}
+}
+
+type MongoShareLinkRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoShareLinkRepository(db *mongo.Database) *MongoShareLinkRepository {
+	return &MongoShareLinkRepository{db: db}
+}
+
+func (r *MongoShareLinkRepository) collection() *mongo.Collection {
+	return r.db.Collection(shareLinkCollection)
+}
+
+func (r *MongoShareLinkRepository) Save(ctx context.Context, link *domain.ShareLink) error {
+	id := primitive.NewObjectID()
+	doc := shareLinkDocument{
+		ID:           id,
+		FileID:       link.FileID,
+		CreatedBy:    link.CreatedBy,
+		CreatedAt:    link.CreatedAt,
+		ExpiresAt:    link.ExpiresAt,
+		MaxDownloads: link.MaxDownloads,
+		PasswordHash: link.PasswordHash,
+	}
+	if _, err := r.collection().InsertOne(ctx, doc); err != nil {
+		return errors.Wrap(err, "failed to save share link")
+	}
+	link.ID = id.Hex()
+	return nil
+}
+
+func (r *MongoShareLinkRepository) FindByID(ctx context.Context, id string) (*domain.ShareLink, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrShareLinkNotFound
+	}
+
+	var doc shareLinkDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find share link")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoShareLinkRepository) FindByFileID(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	cursor, err := r.collection().Find(
+		ctx,
+		bson.M{"file_id": fileID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find share links")
+	}
+	defer cursor.Close(ctx)
+
+	var links []*domain.ShareLink
+	for cursor.Next(ctx) {
+		var doc shareLinkDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode share link")
+		}
+		links = append(links, doc.toDomain())
+	}
+	return links, errors.Wrap(cursor.Err(), "failed to iterate share links")
+}
+
+func (r *MongoShareLinkRepository) IncrementDownloads(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrShareLinkNotFound
+	}
+
+	filter := bson.M{
+		"_id": objID,
+		"$or": bson.A{
+			bson.M{"max_downloads": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$downloads", "$max_downloads"}}},
+		},
+	}
+	result, err := r.collection().UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"downloads": 1}})
+	if err != nil {
+		return errors.Wrap(err, "failed to count share link download")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrShareLinkExhausted
+	}
+	return nil
+}
+
+func (r *MongoShareLinkRepository) Revoke(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrShareLinkNotFound
+	}
+
+	result, err := r.collection().UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke share link")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrShareLinkNotFound
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStorageInspector compares the GridFS files and chunks collections of
+// the archive bucket.
+type MongoStorageInspector struct {
+	db         *mongo.Database
+	bucketName string
+}
+
+func NewMongoStorageInspector(db *mongo.Database, bucketName string) *MongoStorageInspector {
+	return &MongoStorageInspector{db: db, bucketName: bucketName}
+}
+
+func (r *MongoStorageInspector) bucket() (*gridfs.Bucket, error) {
+	return openBucket(r.db, r.bucketName)
+}
+
+// storedChunks sums the chunks of one file as they are actually stored.
+var storedChunks = bson.M{
+	"$group": bson.M{
+		"_id":    "$files_id",
+		"chunks": bson.M{"$sum": 1},
+		"bytes":  bson.M{"$sum": bson.M{"$binarySize": "$data"}},
+	},
+}
+
+func (r *MongoStorageInspector) FindOrphanChunks(ctx context.Context) (_ []*domain.StorageIssue, err error) {
+	ctx, finish := startGridFSOperation(ctx, "find_orphan_chunks")
+	defer finish(&err)
+
+	bucket, err := r.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := bucket.GetChunksCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: storedChunks["$group"]}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         bucket.GetFilesCollection().Name(),
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "file",
+		}}},
+		{{Key: "$match", Value: bson.M{"file": bson.M{"$size": 0}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate orphan chunks")
+	}
+	defer cursor.Close(ctx)
+
+	var issues []*domain.StorageIssue
+	for cursor.Next(ctx) {
+		var doc struct {
+			FileID interface{} `bson:"_id"`
+			Chunks int64       `bson:"chunks"`
+			Bytes  int64       `bson:"bytes"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode orphan chunks")
+		}
+		issue := &domain.StorageIssue{
+			Kind:   domain.StorageIssueOrphanChunks,
+			Chunks: doc.Chunks,
+			Bytes:  doc.Bytes,
+		}
+		issue.FileID, issue.CreatedAt = storageFileID(doc.FileID)
+		issues = append(issues, issue)
+	}
+	return issues, errors.Wrap(cursor.Err(), "failed to read orphan chunks")
+}
+
+func (r *MongoStorageInspector) FindIncompleteFiles(ctx context.Context) (_ []*domain.StorageIssue, err error) {
+	ctx, finish := startGridFSOperation(ctx, "find_incomplete_files")
+	defer finish(&err)
+
+	bucket, err := r.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := bucket.GetFilesCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from": bucket.GetChunksCollection().Name(),
+			"let":  bson.M{"id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$files_id", "$$id"}}}},
+				storedChunks,
+			},
+			"as": "stored",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"filename": 1,
+			"length":   1,
+			"expected": bson.M{"$ceil": bson.M{"$divide": bson.A{"$length", "$chunkSize"}}},
+			"stored": bson.M{"$ifNull": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$stored", 0}},
+				bson.M{"chunks": 0, "bytes": 0},
+			}},
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$or": bson.A{
+			bson.M{"$ne": bson.A{"$stored.bytes", "$length"}},
+			bson.M{"$ne": bson.A{"$stored.chunks", "$expected"}},
+		}}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate incomplete files")
+	}
+	defer cursor.Close(ctx)
+
+	var issues []*domain.StorageIssue
+	for cursor.Next(ctx) {
+		var doc struct {
+			FileID   interface{} `bson:"_id"`
+			Name     string      `bson:"filename"`
+			Length   int64       `bson:"length"`
+			Expected int64       `bson:"expected"`
+			Stored   struct {
+				Chunks int64 `bson:"chunks"`
+				Bytes  int64 `bson:"bytes"`
+			} `bson:"stored"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode incomplete file")
+		}
+		issue := &domain.StorageIssue{
+			Kind:           domain.StorageIssueIncompleteFile,
+			Name:           doc.Name,
+			Length:         doc.Length,
+			Chunks:         doc.Stored.Chunks,
+			ExpectedChunks: doc.Expected,
+			Bytes:          doc.Stored.Bytes,
+		}
+		issue.FileID, issue.CreatedAt = storageFileID(doc.FileID)
+		issues = append(issues, issue)
+	}
+	return issues, errors.Wrap(cursor.Err(), "failed to read incomplete files")
+}
+
+func (r *MongoStorageInspector) DeleteOrphanChunks(ctx context.Context, fileID string) (_ int64, err error) {
+	ctx, finish := startGridFSOperation(ctx, "delete_orphan_chunks")
+	defer finish(&err)
+
+	bucket, err := r.bucket()
+	if err != nil {
+		return 0, err
+	}
+
+	var id interface{} = fileID
+	if objID, err := primitive.ObjectIDFromHex(fileID); err == nil {
+		id = objID
+	}
+
+	// Never remove chunks that belong to a file, even if it was finalized
+	// after the orphan was reported
+	count, err := bucket.GetFilesCollection().CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to look up file")
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	result, err := bucket.GetChunksCollection().DeleteMany(ctx, bson.M{"files_id": id})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to delete orphan chunks")
+	}
+	return result.DeletedCount, nil
+}
+
+// storageFileID renders a GridFS file ID and, for ObjectIDs, the time the
+// upload started.
+func storageFileID(id interface{}) (string, time.Time) {
+	if objID, ok := id.(primitive.ObjectID); ok {
+		return objID.Hex(), objID.Timestamp()
+	}
+	return fmt.Sprint(id), time.Time{}
+}
@@ -0,0 +1,50 @@
+package infrastructure
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoTransactor runs functions in MongoDB transactions. Standalone servers
+// have no transactions; there functions run without one, as they did before
+// transactions were used.
+type MongoTransactor struct {
+	client                  *mongo.Client
+	transactionsUnsupported atomic.Bool
+}
+
+func NewMongoTransactor(db *mongo.Database) *MongoTransactor {
+	return &MongoTransactor{client: db.Client()}
+}
+
+// WithinTransaction retries fn while the transaction conflicts with another
+// one, until ctx is done.
+func (t *MongoTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.transactionsUnsupported.Load() {
+		return fn(ctx)
+	}
+
+	err := t.client.UseSession(ctx, func(sessionCtx mongo.SessionContext) error {
+		_, err := sessionCtx.WithTransaction(sessionCtx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sessionCtx)
+		})
+		return err
+	})
+	if transactionsNotSupported(err) {
+		// The first statement of the transaction failed, so nothing was
+		// written yet
+		t.transactionsUnsupported.Store(true)
+		configs.Logger.Warnw("transactions unavailable - running without them", "error", err.Error())
+		return fn(ctx)
+	}
+	return err
+}
+
+func transactionsNotSupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(codeIllegalOperation)
+}
@@ -0,0 +1,93 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const userCollection = "users"
+
+type userDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Name      string             `bson:"name"`
+	TokenHash string             `bson:"token_hash"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (d *userDocument) toDomain() *domain.User {
+	return &domain.User{
+		ID:        d.ID.Hex(),
+		Name:      d.Name,
+		TokenHash: d.TokenHash,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+type MongoUserRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{db: db}
+}
+
+func (r *MongoUserRepository) collection() *mongo.Collection {
+	return r.db.Collection(userCollection)
+}
+
+// EnsureIndexes creates the unique name index and the token lookup index.
+func (r *MongoUserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return errors.Wrap(err, "failed to create user indexes")
+}
+
+func (r *MongoUserRepository) Save(ctx context.Context, user *domain.User) error {
+	id := primitive.NewObjectID()
+	_, err := r.collection().InsertOne(ctx, userDocument{
+		ID:        id,
+		Name:      user.Name,
+		TokenHash: user.TokenHash,
+		CreatedAt: user.CreatedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrUserNameTaken
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to save user")
+	}
+	user.ID = id.Hex()
+	return nil
+}
+
+func (r *MongoUserRepository) FindByTokenHash(ctx context.Context, hash string) (*domain.User, error) {
+	var doc userDocument
+	err := r.collection().FindOne(ctx, bson.M{"token_hash": hash}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find user")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoUserRepository) DeleteByName(ctx context.Context, name string) error {
+	result, err := r.collection().DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete user")
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
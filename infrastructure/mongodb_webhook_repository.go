@@ -0,0 +1,342 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookCollection         = "webhooks"
+	webhookDeliveryCollection = "webhook_deliveries"
+)
+
+type webhookDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	URL       string             `bson:"url"`
+	Events    []string           `bson:"events"`
+	Secret    string             `bson:"secret"`
+	Active    bool               `bson:"active"`
+	CreatedBy string             `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (d *webhookDocument) toDomain() *domain.Webhook {
+	events := make([]domain.EventType, len(d.Events))
+	for i, e := range d.Events {
+		events[i] = domain.EventType(e)
+	}
+	return &domain.Webhook{
+		ID:        d.ID.Hex(),
+		URL:       d.URL,
+		Events:    events,
+		Secret:    d.Secret,
+		Active:    d.Active,
+		CreatedBy: d.CreatedBy,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+type MongoWebhookRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoWebhookRepository(db *mongo.Database) *MongoWebhookRepository {
+	return &MongoWebhookRepository{db: db}
+}
+
+func (r *MongoWebhookRepository) collection() *mongo.Collection {
+	return r.db.Collection(webhookCollection)
+}
+
+func (r *MongoWebhookRepository) Save(ctx context.Context, webhook *domain.Webhook) error {
+	id := primitive.NewObjectID()
+	events := make([]string, len(webhook.Events))
+	for i, e := range webhook.Events {
+		events[i] = string(e)
+	}
+	doc := webhookDocument{
+		ID:        id,
+		URL:       webhook.URL,
+		Events:    events,
+		Secret:    webhook.Secret,
+		Active:    webhook.Active,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt,
+	}
+	if _, err := r.collection().InsertOne(ctx, doc); err != nil {
+		return errors.Wrap(err, "failed to save webhook")
+	}
+	webhook.ID = id.Hex()
+	return nil
+}
+
+func (r *MongoWebhookRepository) FindByID(ctx context.Context, id string) (*domain.Webhook, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrWebhookNotFound
+	}
+
+	var doc webhookDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find webhook")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoWebhookRepository) find(ctx context.Context, filter bson.M) ([]*domain.Webhook, error) {
+	cursor, err := r.collection().Find(
+		ctx,
+		filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find webhooks")
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []*domain.Webhook
+	for cursor.Next(ctx) {
+		var doc webhookDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode webhook")
+		}
+		webhooks = append(webhooks, doc.toDomain())
+	}
+	return webhooks, errors.Wrap(cursor.Err(), "failed to iterate webhooks")
+}
+
+func (r *MongoWebhookRepository) FindAll(ctx context.Context) ([]*domain.Webhook, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *MongoWebhookRepository) FindByEvent(ctx context.Context, eventType domain.EventType) ([]*domain.Webhook, error) {
+	return r.find(ctx, bson.M{
+		"active": true,
+		"$or": bson.A{
+			bson.M{"events": string(eventType)},
+			bson.M{"events": bson.M{"$size": 0}},
+		},
+	})
+}
+
+func (r *MongoWebhookRepository) Delete(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrWebhookNotFound
+	}
+
+	result, err := r.collection().DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete webhook")
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+type webhookDeliveryDocument struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	WebhookID      string             `bson:"webhook_id"`
+	EventID        string             `bson:"event_id"`
+	EventType      string             `bson:"event_type"`
+	RedeliveryOf   string             `bson:"redelivery_of"`
+	Payload        []byte             `bson:"payload"`
+	Status         string             `bson:"status"`
+	Attempts       int                `bson:"attempts"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at"`
+	LastError      string             `bson:"last_error,omitempty"`
+	ResponseStatus int                `bson:"response_status,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+}
+
+func newWebhookDeliveryDocument(delivery *domain.WebhookDelivery, id primitive.ObjectID) webhookDeliveryDocument {
+	return webhookDeliveryDocument{
+		ID:             id,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		RedeliveryOf:   delivery.RedeliveryOf,
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		ResponseStatus: delivery.ResponseStatus,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func (d *webhookDeliveryDocument) toDomain() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             d.ID.Hex(),
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      domain.EventType(d.EventType),
+		RedeliveryOf:   d.RedeliveryOf,
+		Payload:        d.Payload,
+		Status:         domain.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		ResponseStatus: d.ResponseStatus,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+type MongoWebhookDeliveryRepository struct {
+	db *mongo.Database
+}
+
+func NewMongoWebhookDeliveryRepository(db *mongo.Database) *MongoWebhookDeliveryRepository {
+	return &MongoWebhookDeliveryRepository{db: db}
+}
+
+func (r *MongoWebhookDeliveryRepository) collection() *mongo.Collection {
+	return r.db.Collection(webhookDeliveryCollection)
+}
+
+func (r *MongoWebhookDeliveryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		// One delivery per webhook and event, however often the event is
+		// dispatched; redeliveries are exempt
+		{
+			Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"redelivery_of": ""}),
+		},
+	})
+	return errors.Wrap(err, "failed to create webhook delivery indexes")
+}
+
+// Save inserts a redelivery, or the delivery of an event unless the webhook
+// already has one, e.g. because the event was dispatched again after a
+// crash. delivery.ID is then set to the existing delivery.
+func (r *MongoWebhookDeliveryRepository) Save(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	doc := newWebhookDeliveryDocument(delivery, primitive.NewObjectID())
+	if delivery.RedeliveryOf != "" {
+		if _, err := r.collection().InsertOne(ctx, doc); err != nil {
+			return errors.Wrap(err, "failed to save webhook delivery")
+		}
+		delivery.ID = doc.ID.Hex()
+		return nil
+	}
+
+	filter := bson.M{"webhook_id": doc.WebhookID, "event_id": doc.EventID, "redelivery_of": ""}
+	var saved webhookDeliveryDocument
+	err := r.collection().FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$setOnInsert": doc},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		return errors.Wrap(err, "failed to save webhook delivery")
+	}
+	delivery.ID = saved.ID.Hex()
+	return nil
+}
+
+func (r *MongoWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	objID, err := primitive.ObjectIDFromHex(delivery.ID)
+	if err != nil {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+
+	doc := newWebhookDeliveryDocument(delivery, objID)
+	result, err := r.collection().ReplaceOne(ctx, bson.M{"_id": objID}, doc)
+	if err != nil {
+		return errors.Wrap(err, "failed to update webhook delivery")
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *MongoWebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+
+	var doc webhookDeliveryDocument
+	err = r.collection().FindOne(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find webhook delivery")
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoWebhookDeliveryRepository) FindByWebhookID(ctx context.Context, webhookID string, skip, limit int64) ([]*domain.WebhookDelivery, error) {
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection().Find(ctx, bson.M{"webhook_id": webhookID}, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find webhook deliveries")
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*domain.WebhookDelivery
+	for cursor.Next(ctx) {
+		var doc webhookDeliveryDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, errors.Wrap(err, "failed to decode webhook delivery")
+		}
+		deliveries = append(deliveries, doc.toDomain())
+	}
+	return deliveries, errors.Wrap(cursor.Err(), "failed to iterate webhook deliveries")
+}
+
+func (r *MongoWebhookDeliveryRepository) CountByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	count, err := r.collection().CountDocuments(ctx, bson.M{"webhook_id": webhookID})
+	return count, errors.Wrap(err, "failed to count webhook deliveries")
+}
+
+func (r *MongoWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          string(domain.DeliveryPending),
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	claimOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var deliveries []*domain.WebhookDelivery
+	for len(deliveries) < limit {
+		var doc webhookDeliveryDocument
+		err := r.collection().FindOneAndUpdate(ctx, filter, update, claimOptions).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return deliveries, errors.Wrap(err, "failed to claim webhook delivery")
+		}
+		deliveries = append(deliveries, doc.toDomain())
+	}
+	return deliveries, nil
+}
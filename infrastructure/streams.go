@@ -0,0 +1,33 @@
+package infrastructure
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader fails reads once ctx is done, so copying to or from GridFS
+// stops when the client disconnects or the operation times out.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// contextReadCloser is a contextReader over a stream whose lifetime owns the
+// context; closing it releases the context.
+type contextReadCloser struct {
+	contextReader
+	closer io.Closer
+	cancel context.CancelFunc
+}
+
+func (c *contextReadCloser) Close() error {
+	c.cancel()
+	return c.closer.Close()
+}
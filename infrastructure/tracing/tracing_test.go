@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestInitWithoutExporter(t *testing.T) {
+	for _, name := range []string{"", "none"} {
+		shutdown, err := Init(name, "")
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("%q: shutdown: %v", name, err)
+		}
+	}
+}
+
+func TestInitRejectsUnknownExporter(t *testing.T) {
+	if _, err := Init("jaeger", ""); err == nil || !strings.Contains(err.Error(), `"jaeger"`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestInitFileExporterWritesSpans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.json")
+	shutdown, err := Init("file", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "UploadFileUseCase.Execute")
+	// Outgoing requests carry the trace in a W3C traceparent header
+	header := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.Contains(header.Get("traceparent"), traceID) {
+		t.Errorf("traceparent %q does not carry trace %s", header.Get("traceparent"), traceID)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"UploadFileUseCase.Execute", traceID, ServiceName} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("traces file lacks %q:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package apidocs serves the OpenAPI specification of the API and an
+// interactive Swagger UI for it.
+package apidocs
+
+import (
+	_ "embed"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix is where the documentation is served. Routes below it are not part
+// of the API and are not expected in the specification.
+const Prefix = "/swagger"
+
+//go:embed openapi.yaml
+var spec []byte
+
+// cdnAssets is where the UI assets are loaded from unless they are served
+// from a local directory, so that the binary does not carry them.
+const cdnAssets = "https://unpkg.com/swagger-ui-dist@5"
+
+// uiAssets are the files of the swagger-ui-dist package the page needs.
+var uiAssets = []string{"swagger-ui.css", "swagger-ui-bundle.js"}
+
+func uiPage(assets string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API Archiven v2</title>
+  <link rel="stylesheet" href="` + assets + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="` + assets + `/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "` + Prefix + `/openapi.yaml", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+}
+
+// Register serves the UI at /swagger and the specification at
+// /swagger/openapi.yaml. With uiDir, a copy of the swagger-ui-dist package,
+// the UI assets are served from /swagger/assets instead of a CDN.
+func Register(e *echo.Echo, uiDir string) error {
+	assets := cdnAssets
+	if uiDir != "" {
+		for _, name := range uiAssets {
+			if _, err := os.Stat(filepath.Join(uiDir, name)); err != nil {
+				return errors.Wrap(err, "Swagger UI directory is incomplete")
+			}
+		}
+		e.Static(Prefix+"/assets", uiDir)
+		assets = Prefix + "/assets"
+	}
+
+	page := uiPage(assets)
+	ui := func(c echo.Context) error {
+		return c.HTML(http.StatusOK, page)
+	}
+	e.GET(Prefix, ui)
+	e.GET(Prefix+"/", ui)
+	e.GET(Prefix+"/openapi.yaml", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/yaml", spec)
+	})
+	return nil
+}
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+// CheckRoutes compares the routes registered on e with the operations in the
+// specification and reports any route missing from either side.
+func CheckRoutes(e *echo.Echo) error {
+	var doc struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return errors.Wrap(err, "failed to parse OpenAPI specification")
+	}
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, r := range e.Routes() {
+		if strings.HasPrefix(r.Path, Prefix) {
+			continue
+		}
+		registered[r.Method+" "+pathParam.ReplaceAllString(r.Path, "{$1}")] = true
+	}
+
+	var problems []string
+	for route := range registered {
+		if !documented[route] {
+			problems = append(problems, "undocumented route "+route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			problems = append(problems, "documented route not registered: "+route)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("OpenAPI specification out of sync: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
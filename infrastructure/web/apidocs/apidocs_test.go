@@ -0,0 +1,58 @@
+package apidocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func get(e *echo.Echo, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestRegisterServesLocalAssets(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range uiAssets {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("/* "+name+" */"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := echo.New()
+	if err := Register(e, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	page := get(e, Prefix).Body.String()
+	if strings.Contains(page, "unpkg.com") || !strings.Contains(page, Prefix+"/assets/swagger-ui-bundle.js") {
+		t.Fatalf("page does not use the local assets:\n%s", page)
+	}
+	if asset := get(e, Prefix+"/assets/swagger-ui-bundle.js"); asset.Code != http.StatusOK || asset.Body.String() != "/* swagger-ui-bundle.js */" {
+		t.Fatalf("asset: got %d %q", asset.Code, asset.Body)
+	}
+}
+
+func TestRegisterRejectsIncompleteAssetDirectory(t *testing.T) {
+	if err := Register(echo.New(), t.TempDir()); err == nil {
+		t.Fatal("empty directory accepted")
+	}
+}
+
+func TestRegisterDefaultsToCDN(t *testing.T) {
+	e := echo.New()
+	if err := Register(e, ""); err != nil {
+		t.Fatal(err)
+	}
+	if page := get(e, Prefix+"/").Body.String(); !strings.Contains(page, cdnAssets+"/swagger-ui-bundle.js") {
+		t.Fatalf("page does not load the CDN assets:\n%s", page)
+	}
+	if spec := get(e, Prefix+"/openapi.yaml"); spec.Code != http.StatusOK || !strings.HasPrefix(spec.Body.String(), "openapi:") {
+		t.Fatalf("specification: got %d", spec.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type AuditHandlers struct {
+	getAuditLogUseCase *usecases.GetAuditLogUseCase
+	verifyUseCase      *usecases.VerifyAuditLogUseCase
+}
+
+func NewAuditHandlers(
+	getAuditLogUC *usecases.GetAuditLogUseCase,
+	verifyUC *usecases.VerifyAuditLogUseCase,
+) *AuditHandlers {
+	return &AuditHandlers{
+		getAuditLogUseCase: getAuditLogUC,
+		verifyUseCase:      verifyUC,
+	}
+}
+
+func (h *AuditHandlers) GetAuditLog(c echo.Context) error {
+	page, _ := c.Get("page").(int)
+	perPage, _ := c.Get("per_page").(int)
+
+	result, err := h.getAuditLogUseCase.Execute(c.Request().Context(), usecases.GetAuditLogQuery{
+		Filter: domain.AuditFilter{
+			Actor:  c.QueryParam("actor"),
+			Action: domain.AuditAction(c.QueryParam("action")),
+			FileID: c.QueryParam("file_id"),
+		},
+		Page:    page,
+		PerPage: perPage,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to read audit log")
+	}
+
+	response := map[string]interface{}{
+		"data": responses.BuildAuditEntriesResponse(result.Entries),
+		"pagination": map[string]interface{}{
+			"total":       result.Total,
+			"page":        result.Page,
+			"per_page":    result.PerPage,
+			"total_pages": result.TotalPages,
+		},
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// VerifyAuditLog checks the chain, and that it still contains each anchor
+// given as "<seq>:<hash>" in the anchor query parameter.
+func (h *AuditHandlers) VerifyAuditLog(c echo.Context) error {
+	var anchors []domain.AuditAnchor
+	for _, value := range c.QueryParams()["anchor"] {
+		anchor, err := domain.ParseAuditAnchor(value)
+		if err != nil {
+			return err
+		}
+		anchors = append(anchors, anchor)
+	}
+
+	result, err := h.verifyUseCase.Execute(c.Request().Context(), anchors...)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify audit log")
+	}
+
+	response := map[string]interface{}{
+		"valid":     result.Valid,
+		"checked":   result.Checked,
+		"last_hash": result.LastHash,
+		"head":      fmt.Sprintf("%d:%s", result.Checked, result.LastHash),
+	}
+	if !result.Valid {
+		response["broken_at"] = result.BrokenAt
+		response["reason"] = result.Reason
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type BatchUploadHandlers struct {
+	batchUseCase  *usecases.BatchUploadUseCase
+	recordAuditUC *usecases.RecordAuditUseCase
+	catalog       *i18n.Catalog
+	links         *links.Builder
+}
+
+func NewBatchUploadHandlers(
+	batchUC *usecases.BatchUploadUseCase,
+	recordAuditUC *usecases.RecordAuditUseCase,
+	catalog *i18n.Catalog,
+	linkBuilder *links.Builder,
+) *BatchUploadHandlers {
+	return &BatchUploadHandlers{
+		batchUseCase:  batchUC,
+		recordAuditUC: recordAuditUC,
+		catalog:       catalog,
+		links:         linkBuilder,
+	}
+}
+
+// BatchUpload stores every file part of a multipart request. ZIP archives are
+// unpacked and their entries stored as separate files unless unpack=false.
+// The response reports the outcome of each file: 201 when all were stored,
+// 207 when some were rejected.
+func (h *BatchUploadHandlers) BatchUpload(c echo.Context) error {
+	form, err := c.MultipartForm()
+	if middleware.IsBodyTooLarge(err) {
+		return echo.ErrStatusRequestEntityTooLarge
+	}
+	if err != nil {
+		return domain.ErrInvalidRequest
+	}
+	unpack := true
+	if v := c.QueryParam("unpack"); v != "" {
+		if unpack, err = strconv.ParseBool(v); err != nil {
+			return domain.ErrInvalidRequest
+		}
+	}
+
+	// Parts are taken in field name order, then in the order they were sent
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var items []usecases.BatchUploadItem
+	for _, field := range fields {
+		for _, header := range form.File[field] {
+			if unpack && isZipPart(header) {
+				entries, closeArchive, err := zipItems(header)
+				if err != nil {
+					return err
+				}
+				defer closeArchive()
+				items = append(items, entries...)
+				continue
+			}
+			items = append(items, usecases.BatchUploadItem{
+				Name:        header.Filename,
+				ContentType: header.Header.Get(echo.HeaderContentType),
+				Size:        header.Size,
+				Open: func() (io.ReadCloser, error) {
+					return header.Open()
+				},
+			})
+		}
+	}
+
+	results, err := h.batchUseCase.Execute(c.Request().Context(), usecases.BatchUploadCommand{
+		Items:        items,
+		CollectionID: c.FormValue("collection_id"),
+	})
+	if err != nil {
+		return err
+	}
+
+	l := h.links.For(c)
+	localizer := h.catalog.For(c.Request().Header.Get("Accept-Language"))
+	response := responses.BuildBatchUploadResponse(results, l, localizer)
+	for i, result := range results {
+		if result.Err != nil {
+			configs.Logger.Errorw("file upload failed",
+				"error", result.Err.Error(),
+				"filename", result.Name,
+				"batch", true,
+			)
+		}
+		h.recordFile(c, response.Results[i])
+	}
+
+	status := http.StatusCreated
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	return c.JSON(status, response)
+}
+
+// recordFile does for one file of a batch what the Audit middleware and
+// UploadFile do for a single upload.
+func (h *BatchUploadHandlers) recordFile(c echo.Context, result responses.BatchUploadResult) {
+	outcome := domain.AuditOutcomeSuccess
+	fileID := ""
+	if result.File != nil {
+		fileID = result.File.ID
+		metrics.UploadedBytes.Add(float64(result.File.Size))
+	} else {
+		outcome = domain.AuditOutcomeFailure
+		switch result.Error.Code {
+		case domain.ErrFileTooLarge.Code:
+			metrics.ValidationRejections.WithLabelValues("file_size").Inc()
+		case domain.ErrFileType.Code:
+			metrics.ValidationRejections.WithLabelValues("mime_type").Inc()
+		case domain.ErrQuotaExceeded.Code:
+			metrics.ValidationRejections.WithLabelValues("quota").Inc()
+		}
+	}
+
+	_, err := h.recordAuditUC.Execute(context.WithoutCancel(c.Request().Context()), usecases.RecordAuditCommand{
+		Actor:   middleware.Actor(c),
+		Action:  domain.AuditActionUpload,
+		FileID:  fileID,
+		IP:      c.RealIP(),
+		Outcome: outcome,
+		Status:  result.Status,
+	})
+	if err != nil {
+		configs.Logger.Errorw("failed to record audit entry",
+			"error", err.Error(),
+			"action", domain.AuditActionUpload,
+			"file_id", fileID,
+		)
+	}
+}
+
+var zipContentTypes = map[string]bool{
+	"application/zip":              true,
+	"application/x-zip-compressed": true,
+}
+
+func isZipPart(header *multipart.FileHeader) bool {
+	return zipContentTypes[header.Header.Get(echo.HeaderContentType)] ||
+		strings.EqualFold(path.Ext(header.Filename), ".zip")
+}
+
+// zipItems lists the files in an uploaded ZIP archive. The archive stays open
+// until the returned close function is called. Content types are derived from
+// the entry names, as archives do not record them.
+func zipItems(header *multipart.FileHeader) ([]usecases.BatchUploadItem, func(), error) {
+	archive, err := header.Open()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to open uploaded archive")
+	}
+	reader, err := zip.NewReader(archive, header.Size)
+	if err != nil {
+		archive.Close()
+		return nil, nil, domain.ErrInvalidRequest.WithFields(domain.FieldError{
+			Field:   header.Filename,
+			Code:    "zip",
+			Message: "not a valid ZIP archive",
+			Params:  []string{header.Filename},
+		})
+	}
+
+	var items []usecases.BatchUploadItem
+	for _, entry := range reader.File {
+		name := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		contentType, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(name)))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		items = append(items, usecases.BatchUploadItem{
+			Name:        name,
+			ContentType: contentType,
+			Size:        int64(entry.UncompressedSize64),
+			Open:        entry.Open,
+		})
+	}
+	return items, func() { archive.Close() }, nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// filePart returns the header of a file part as a parsed multipart form
+// holds it.
+func filePart(t *testing.T, name, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="files"; filename="`+name+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["files"][0]
+}
+
+func zipArchive(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(name, "/") {
+			f.Write([]byte("content of " + name))
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsZipPart(t *testing.T) {
+	for _, tc := range []struct {
+		name, contentType string
+		want              bool
+	}{
+		{"box-17.zip", "application/zip", true},
+		{"box-17.bin", "application/x-zip-compressed", true},
+		{"BOX-17.ZIP", "application/octet-stream", true},
+		{"scan.pdf", "application/pdf", false},
+	} {
+		if got := isZipPart(filePart(t, tc.name, tc.contentType, nil)); got != tc.want {
+			t.Errorf("isZipPart(%s, %s) = %v, want %v", tc.name, tc.contentType, got, tc.want)
+		}
+	}
+}
+
+func TestZipItems(t *testing.T) {
+	archive := zipArchive(t,
+		"box-17/",
+		"box-17/scan-001.pdf",
+		"box-17/photos/scan-002.PNG",
+		"box-17/.DS_Store",
+		"__MACOSX/box-17/._scan-001.pdf",
+		"readme",
+	)
+	items, closeArchive, err := zipItems(filePart(t, "box-17.zip", "application/zip", archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeArchive()
+
+	want := []struct {
+		name, contentType string
+	}{
+		{"scan-001.pdf", "application/pdf"},
+		{"scan-002.PNG", "image/png"},
+		{"readme", "application/octet-stream"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("%d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i, item := range items {
+		if item.Name != want[i].name || item.ContentType != want[i].contentType {
+			t.Errorf("item %d = %s (%s), want %s (%s)", i, item.Name, item.ContentType, want[i].name, want[i].contentType)
+		}
+		content, err := item.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), item.Name) || item.Size != int64(len(data)) {
+			t.Errorf("%s: content %q, size %d", item.Name, data, item.Size)
+		}
+	}
+}
+
+func TestZipItemsRejectsInvalidArchives(t *testing.T) {
+	_, _, err := zipItems(filePart(t, "box-17.zip", "application/zip", []byte("not a zip")))
+	domainErr, ok := domain.AsError(err)
+	if !ok || domainErr.Code != domain.ErrInvalidRequest.Code || len(domainErr.Fields) != 1 ||
+		domainErr.Fields[0].Code != "zip" || domainErr.Fields[0].Field != "box-17.zip" {
+		t.Fatalf("got %#v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type CollectionHandlers struct {
+	createUseCase *usecases.CreateCollectionUseCase
+	getUseCase    *usecases.GetCollectionsUseCase
+	updateUseCase *usecases.UpdateCollectionUseCase
+	deleteUseCase *usecases.DeleteCollectionUseCase
+	links         *links.Builder
+}
+
+func NewCollectionHandlers(
+	createUC *usecases.CreateCollectionUseCase,
+	getUC *usecases.GetCollectionsUseCase,
+	updateUC *usecases.UpdateCollectionUseCase,
+	deleteUC *usecases.DeleteCollectionUseCase,
+	linkBuilder *links.Builder,
+) *CollectionHandlers {
+	return &CollectionHandlers{
+		createUseCase: createUC,
+		getUseCase:    getUC,
+		updateUseCase: updateUC,
+		deleteUseCase: deleteUC,
+		links:         linkBuilder,
+	}
+}
+
+type createCollectionRequest struct {
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id"`
+}
+
+func (h *CollectionHandlers) CreateCollection(c echo.Context) error {
+	var req createCollectionRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	collection, err := h.createUseCase.Execute(c.Request().Context(), usecases.CreateCollectionCommand{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		return err
+	}
+	c.Set("collection_id", collection.ID)
+	c.Set("audit_changes", domain.CollectionChanges(&domain.Collection{}, collection))
+
+	response := responses.BuildCollectionResponse(collection, h.links.For(c))
+	c.Response().Header().Set(echo.HeaderLocation, response.Links["self"])
+	return c.JSON(http.StatusCreated, response)
+}
+
+// GetCollections lists the collections directly below parent_id, or the
+// top-level collections without it.
+func (h *CollectionHandlers) GetCollections(c echo.Context) error {
+	collections, err := h.getUseCase.Children(c.Request().Context(), c.QueryParam("parent_id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": responses.BuildCollectionsResponse(collections, h.links.For(c)),
+	})
+}
+
+func (h *CollectionHandlers) GetCollection(c echo.Context) error {
+	collection, err := h.getUseCase.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, responses.BuildCollectionResponse(collection, h.links.For(c)))
+}
+
+type updateCollectionRequest struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// UpdateCollection renames and/or moves a collection; an empty parent_id
+// moves it to the top level.
+func (h *CollectionHandlers) UpdateCollection(c echo.Context) error {
+	var req updateCollectionRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	collection, changes, err := h.updateUseCase.Execute(c.Request().Context(), usecases.UpdateCollectionCommand{
+		ID:       c.Param("id"),
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		return err
+	}
+	c.Set("audit_changes", changes)
+
+	return c.JSON(http.StatusOK, responses.BuildCollectionResponse(collection, h.links.For(c)))
+}
+
+func (h *CollectionHandlers) DeleteCollection(c echo.Context) error {
+	collection, err := h.deleteUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	c.Set("audit_changes", domain.CollectionChanges(collection, &domain.Collection{}))
+
+	return c.NoContent(http.StatusNoContent)
+}
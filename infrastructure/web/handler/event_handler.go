@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+// sseHeartbeatInterval keeps idle connections open through proxies.
+const sseHeartbeatInterval = 15 * time.Second
+
+type EventHandlers struct {
+	watchUseCase *usecases.WatchChangesUseCase
+	stopping     <-chan struct{}
+}
+
+// NewEventHandlers creates the SSE handlers; open streams are closed when
+// stopping is closed so they do not hold up a graceful shutdown.
+func NewEventHandlers(watchUC *usecases.WatchChangesUseCase, stopping <-chan struct{}) *EventHandlers {
+	return &EventHandlers{watchUseCase: watchUC, stopping: stopping}
+}
+
+type changeEventData struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	FileID      string      `json:"file_id"`
+	OccurredAt  string      `json:"occurred_at"`
+	Name        interface{} `json:"name,omitempty"`
+	Size        interface{} `json:"size,omitempty"`
+	ContentType interface{} `json:"content_type,omitempty"`
+}
+
+// StreamEvents streams archive changes as Server-Sent Events. Clients resume
+// after a disconnect by sending the last received id in Last-Event-ID.
+func (h *EventHandlers) StreamEvents(c echo.Context) error {
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	cursor := c.Request().Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.QueryParam("last_event_id")
+	}
+
+	changes := make(chan domain.Change)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- h.watchUseCase.Execute(ctx, cursor, func(change domain.Change) error {
+			select {
+			case changes <- change:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-h.stopping:
+			return nil
+		case err := <-watchErr:
+			if err != nil && ctx.Err() == nil {
+				configs.Logger.Errorw("change feed failed", "error", err.Error())
+			}
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case change := <-changes:
+			if change.Reset {
+				// Clients should reload what they show, as changes were missed
+				if _, err := fmt.Fprintf(res, "id: %s\nevent: reset\ndata: {}\n\n", change.Cursor); err != nil {
+					return nil
+				}
+				res.Flush()
+				continue
+			}
+			event := change.Event
+			data, _ := json.Marshal(changeEventData{
+				ID:          event.ID,
+				Type:        string(event.Type),
+				FileID:      event.FileID,
+				OccurredAt:  event.OccurredAt.Format(time.RFC3339Nano),
+				Name:        event.Data["name"],
+				Size:        event.Data["size"],
+				ContentType: event.Data["content_type"],
+			})
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", change.Cursor, event.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
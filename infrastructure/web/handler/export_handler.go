@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type ExportHandlers struct {
+	archiveUseCase  *usecases.ExportArchiveUseCase
+	startUseCase    *usecases.StartExportUseCase
+	getUseCase      *usecases.GetExportUseCase
+	downloadUseCase *usecases.DownloadExportUseCase
+	maxFiles        int
+	links           *links.Builder
+}
+
+func NewExportHandlers(
+	archiveUC *usecases.ExportArchiveUseCase,
+	startUC *usecases.StartExportUseCase,
+	getUC *usecases.GetExportUseCase,
+	downloadUC *usecases.DownloadExportUseCase,
+	maxFiles int,
+	linkBuilder *links.Builder,
+) *ExportHandlers {
+	return &ExportHandlers{
+		archiveUseCase:  archiveUC,
+		startUseCase:    startUC,
+		getUseCase:      getUC,
+		downloadUseCase: downloadUC,
+		maxFiles:        maxFiles,
+		links:           linkBuilder,
+	}
+}
+
+type archiveFilterRequest struct {
+	ContentType  string    `json:"content_type"`
+	UploadedFrom time.Time `json:"uploaded_from"`
+	UploadedTo   time.Time `json:"uploaded_to"`
+	CollectionID string    `json:"collection_id"`
+	Recursive    bool      `json:"recursive"`
+}
+
+type archiveRequest struct {
+	IDs    []string              `json:"ids"`
+	Filter *archiveFilterRequest `json:"filter"`
+	Async  bool                  `json:"async"`
+}
+
+// CreateArchive selects files by ID or by a listing filter and either streams
+// them back as a ZIP archive or, with async, starts an export to download
+// later.
+func (h *ExportHandlers) CreateArchive(c echo.Context) error {
+	var req archiveRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+	if len(req.IDs) == 0 && req.Filter == nil {
+		return domain.ErrInvalidRequest.WithFields(domain.FieldError{
+			Field:   "ids",
+			Code:    "required",
+			Message: "ids or filter is required",
+			Params:  []string{"ids"},
+		})
+	}
+	if async, _ := strconv.ParseBool(c.QueryParam("async")); async {
+		req.Async = true
+	}
+
+	var filter domain.FileFilter
+	if len(req.IDs) > 0 {
+		filter.IDs = req.IDs
+	}
+	if req.Filter != nil {
+		filter.ContentType = req.Filter.ContentType
+		filter.UploadedFrom = req.Filter.UploadedFrom
+		filter.UploadedTo = req.Filter.UploadedTo
+		if req.Filter.CollectionID != "" {
+			var err error
+			filter, err = h.archiveUseCase.ScopeToCollection(c.Request().Context(), filter, req.Filter.CollectionID, req.Filter.Recursive)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if req.Async {
+		export, err := h.startUseCase.Execute(c.Request().Context(), usecases.StartExportCommand{
+			Filter:    filter,
+			CreatedBy: middleware.Actor(c),
+		})
+		if err != nil {
+			return err
+		}
+		response := responses.BuildExportResponse(export, h.links.For(c))
+		c.Response().Header().Set(echo.HeaderLocation, response.Links["self"])
+		return c.JSON(http.StatusAccepted, response)
+	}
+
+	plan, err := h.archiveUseCase.Plan(c.Request().Context(), filter, h.maxFiles)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition,
+		"attachment; filename=\"archive-"+time.Now().UTC().Format("20060102T150405Z")+".zip\"")
+	res.WriteHeader(http.StatusOK)
+
+	manifest, err := h.archiveUseCase.Write(c.Request().Context(), plan, res)
+	if err != nil {
+		// Headers are already sent; the client sees a truncated archive
+		configs.Logger.Errorw("failed to stream archive",
+			"error", err.Error(),
+			"files", len(plan.Files),
+		)
+		return err
+	}
+	for _, entry := range manifest.Files {
+		metrics.DownloadedBytes.Add(float64(entry.Size))
+	}
+	return nil
+}
+
+func (h *ExportHandlers) GetExport(c echo.Context) error {
+	export, err := h.getUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, responses.BuildExportResponse(export, h.links.For(c)))
+}
+
+func (h *ExportHandlers) DownloadExport(c echo.Context) error {
+	export, content, err := h.downloadUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, "attachment; filename=\"export-"+export.ID+".zip\"")
+	res.Header().Set(echo.HeaderContentLength, strconv.FormatInt(export.Size, 10))
+
+	written, err := io.Copy(res, content)
+	metrics.DownloadedBytes.Add(float64(written))
+	if err != nil {
+		configs.Logger.Errorw("failed to stream export",
+			"error", err.Error(),
+			"export_id", export.ID,
+		)
+		return err
+	}
+	return nil
+}
@@ -1,33 +1,54 @@
 package handlers
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
 	"github.com/yhartanto178dev/api-archiven-v2/domain"
 	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
 	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+	"golang.org/x/text/unicode/norm"
 )
 
 type FileHandlers struct {
 	uploadUseCase  *usecases.UploadFileUseCase
 	getFileUseCase *usecases.GetFileUseCase
 	getAllUseCase  *usecases.GetAllFilesUseCase
+	deleteUseCase  *usecases.DeleteFileUseCase
+	moveUseCase    *usecases.MoveFileUseCase
+	updateUseCase  *usecases.UpdateFileUseCase
+	links          *links.Builder
 }
 
 func NewFileHandlers(
 	uploadUC *usecases.UploadFileUseCase,
 	getUC *usecases.GetFileUseCase,
 	getAllUC *usecases.GetAllFilesUseCase,
+	deleteUC *usecases.DeleteFileUseCase,
+	moveUC *usecases.MoveFileUseCase,
+	updateUC *usecases.UpdateFileUseCase,
+	linkBuilder *links.Builder,
 ) *FileHandlers {
 	return &FileHandlers{
 		uploadUseCase:  uploadUC,
 		getFileUseCase: getUC,
 		getAllUseCase:  getAllUC,
+		deleteUseCase:  deleteUC,
+		moveUseCase:    moveUC,
+		updateUseCase:  updateUC,
+		links:          linkBuilder,
 	}
 }
 
@@ -46,51 +67,62 @@ func (h *FileHandlers) UploadFile(c echo.Context) error {
 	// }
 	// Get the file from the form
 	fileHeader, err := c.FormFile("file")
+	if middleware.IsBodyTooLarge(err) {
+		metrics.ValidationRejections.WithLabelValues("file_size").Inc()
+		return echo.ErrStatusRequestEntityTooLarge
+	}
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "file is required"})
+		metrics.ValidationRejections.WithLabelValues("missing_file").Inc()
+		return domain.ErrFileRequired.WithFields(domain.FieldError{
+			Field:   "file",
+			Code:    "required",
+			Message: "file is required",
+			Params:  []string{"file"},
+		})
 	}
 
 	// Validate file size
 	if err := domain.ValidateFileSize(fileHeader.Size); err != nil {
+		metrics.ValidationRejections.WithLabelValues("file_size").Inc()
 		configs.Logger.Errorw("file upload failed",
 			"error", err.Error(),
 			"filename", fileHeader.Filename,
 			"content_type", fileHeader.Header.Get("Content-Type"),
 			"time", time.Now().Format(time.RFC3339),
 		)
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error":   "file validation failed",
-			"details": domain.FormatValidationErrors(err),
-		})
+		return err
 	}
 
 	// Validate content type
 	if err := domain.ValidateMimeType(fileHeader.Header.Get("Content-Type")); err != nil {
+		metrics.ValidationRejections.WithLabelValues("mime_type").Inc()
 		configs.Logger.Errorw("file upload failed",
 			"error", err.Error(),
 			"filename", fileHeader.Filename,
 			"content_type", fileHeader.Header.Get("Content-Type"),
 			"time", time.Now().Format(time.RFC3339),
 		)
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error":   "file type not allowed",
-			"details": domain.FormatValidationErrors(err),
-		})
+		return err
 	}
 
 	src, err := fileHeader.Open()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open file"})
+		return errors.Wrap(err, "failed to open uploaded file")
 	}
 	defer src.Close()
 	//	// Create a new file entity
 	cmd := usecases.UploadFileCommand{
-		Name:        fileHeader.Filename,
-		ContentType: fileHeader.Header.Get("Content-Type"),
-		Content:     src,
+		Name:         fileHeader.Filename,
+		ContentType:  fileHeader.Header.Get("Content-Type"),
+		Content:      src,
+		Size:         fileHeader.Size,
+		CollectionID: c.FormValue("collection_id"),
 	}
 	// Save the file using the use case
-	uploadedFile, err := h.uploadUseCase.Execute(cmd)
+	uploadedFile, err := h.uploadUseCase.Execute(c.Request().Context(), cmd)
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		metrics.ValidationRejections.WithLabelValues("quota").Inc()
+	}
 	if err != nil {
 		configs.Logger.Errorw("file upload failed",
 			"error", err.Error(),
@@ -98,43 +130,32 @@ func (h *FileHandlers) UploadFile(c echo.Context) error {
 			"content_type", fileHeader.Header.Get("Content-Type"),
 			"time", time.Now().Format(time.RFC3339),
 		)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+		return err
 	}
+	c.Set("file_id", uploadedFile.ID)
+	metrics.UploadedBytes.Add(float64(uploadedFile.Size))
 	/// Logger
 	configs.Logger.Infow("file uploaded successfully",
 		"file_id", uploadedFile.ID,
 		"file_name", uploadedFile.Name,
 		"file_size", uploadedFile.Size,
 	)
-	fileResponse := responses.FileResponse{
-		Name:        uploadedFile.Name,
-		Size:        uploadedFile.Size,
-		ContentType: uploadedFile.ContentType,
-		UploadDate:  uploadedFile.UploadDate.Format(time.RFC3339),
-		DownloadURL: "http://localhost:8080/files/" + uploadedFile.ID + "/download",
-	}
+	fileResponse := responses.BuildFileResponse(uploadedFile, h.links.For(c))
+	c.Response().Header().Set(echo.HeaderLocation, fileResponse.Links["self"])
 
 	return c.JSON(http.StatusCreated, fileResponse)
 }
 
 func (h *FileHandlers) GetFileByID(c echo.Context) error {
 	id := c.Param("id")
-	file, _, err := h.getFileUseCase.Execute(id)
+	file, content, err := h.getFileUseCase.Execute(c.Request().Context(), id)
 	if err != nil {
-		if err == domain.ErrFileNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "file not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-	fileResponse := responses.FileResponse{
-		Name:        file.Name,
-		Size:        file.Size,
-		ContentType: file.ContentType,
-		UploadDate:  file.UploadDate.Format(time.RFC3339),
-		DownloadURL: "http://localhost:8080/files/" + file.ID + "/download",
+		return err
 	}
+	content.Close()
 
-	return c.JSON(http.StatusOK, fileResponse)
+	c.Response().Header().Set(headerETag, fileETag(file))
+	return c.JSON(http.StatusOK, responses.BuildFileResponse(file, h.links.For(c)))
 }
 
 func (h *FileHandlers) GetAllFiles(c echo.Context) error {
@@ -149,47 +170,249 @@ func (h *FileHandlers) GetAllFiles(c echo.Context) error {
 		perPage = 10
 	}
 
-	result, err := h.getAllUseCase.Execute(usecases.GetAllFilesQuery{
-		Page:    page,
-		PerPage: perPage,
+	// The presence of "cursor", even empty, selects cursor mode
+	_, cursorMode := c.QueryParams()["cursor"]
+	includeTotal, _ := strconv.ParseBool(c.QueryParam("include_total"))
+
+	// Listing a collection, optionally with everything below it
+	scope := url.Values{}
+	collectionID := c.QueryParam("collection_id")
+	recursive, _ := strconv.ParseBool(c.QueryParam("recursive"))
+	if collectionID != "" {
+		scope.Set("collection_id", collectionID)
+		if recursive {
+			scope.Set("recursive", "true")
+		}
+	}
+
+	result, err := h.getAllUseCase.Execute(c.Request().Context(), usecases.GetAllFilesQuery{
+		Page:         page,
+		PerPage:      perPage,
+		CursorMode:   cursorMode,
+		Cursor:       c.QueryParam("cursor"),
+		IncludeTotal: includeTotal,
+		CollectionID: collectionID,
+		Recursive:    recursive,
 	})
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
+	}
+
+	l := h.links.For(c)
+	if cursorMode {
+		return c.JSON(http.StatusOK, responses.BuildCursorPageResponse(result, includeTotal, scope, l))
 	}
 
 	response := map[string]interface{}{
-		"data": responses.BuildFilesResponse(result.Files, c),
+		"data": responses.BuildFilesResponse(result.Files, l),
 		"pagination": map[string]interface{}{
 			"total":       result.Total,
 			"page":        result.Page,
 			"per_page":    result.PerPage,
 			"total_pages": result.TotalPages,
 		},
+		"_links": responses.PageLinks(result, scope, l),
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
 func (h *FileHandlers) DownloadFile(c echo.Context) error {
-	id := c.Param("id")
-	file, content, err := h.getFileUseCase.Execute(id)
+	disposition, err := parseDisposition(c)
 	if err != nil {
-		if err == domain.ErrFileNotFound {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "file not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
+	}
+
+	file, content, err := h.getFileUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
 	}
 	defer content.Close()
 
-	c.Response().Header().Set("Content-Type", file.ContentType)
-	c.Response().Header().Set("Content-Disposition", "attachment; filename=\""+file.Name+"\"")
-	c.Response().Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	return streamFile(c, file, content, disposition)
+}
+
+// parseDisposition reads whether a download is saved (attachment, the
+// default) or shown in the browser where it can be (inline).
+func parseDisposition(c echo.Context) (string, error) {
+	disposition := c.QueryParam("disposition")
+	if disposition == "" {
+		return dispositionAttachment, nil
+	}
+	if disposition != dispositionAttachment && disposition != dispositionInline {
+		return "", domain.ErrInvalidRequest.WithFields(domain.FieldError{
+			Field:   "disposition",
+			Code:    "one_of",
+			Message: "disposition must be one of: inline, attachment",
+			Params:  []string{"disposition", dispositionInline + ", " + dispositionAttachment},
+		})
+	}
+	return disposition, nil
+}
+
+// streamFile sends the content of a file as the response.
+func streamFile(c echo.Context, file *domain.File, content io.Reader, disposition string) error {
+	c.Response().Header().Set(echo.HeaderContentType, file.ContentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, contentDisposition(disposition, file.Name))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(file.Size, 10))
+	c.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
 
-	_, err = io.Copy(c.Response().Writer, content)
+	written, err := io.Copy(c.Response().Writer, content)
+	metrics.DownloadedBytes.Add(float64(written))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to stream file"})
+		// Headers are already sent; all that is left is to log and drop
+		// the connection
+		configs.Logger.Errorw("failed to stream file",
+			"error", err.Error(),
+			"file_id", file.ID,
+		)
+		return err
 	}
 
 	return nil
 }
+
+type moveFileRequest struct {
+	CollectionID string `json:"collection_id"`
+}
+
+// MoveFile files the file in a collection, or takes it out of its collection
+// when collection_id is empty.
+func (h *FileHandlers) MoveFile(c echo.Context) error {
+	var req moveFileRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	file, changes, err := h.moveUseCase.Execute(c.Request().Context(), usecases.MoveFileCommand{
+		FileID:       c.Param("id"),
+		CollectionID: req.CollectionID,
+		IfMatch:      parseIfMatch(c.Request().Header.Get(headerIfMatch)),
+	})
+	if err != nil {
+		return err
+	}
+	c.Set("audit_changes", changes)
+
+	c.Response().Header().Set(headerETag, fileETag(file))
+	return c.JSON(http.StatusOK, responses.BuildFileResponse(file, h.links.For(c)))
+}
+
+// updateFileRequest holds the fields to change; absent fields are kept.
+type updateFileRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Tags        *[]string `json:"tags"`
+	Category    *string   `json:"category"`
+}
+
+// UpdateFile renames a file or changes its description, tags or category.
+func (h *FileHandlers) UpdateFile(c echo.Context) error {
+	var req updateFileRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	file, changes, err := h.updateUseCase.Execute(c.Request().Context(), usecases.UpdateFileCommand{
+		FileID:      c.Param("id"),
+		Name:        req.Name,
+		Description: req.Description,
+		Tags:        req.Tags,
+		Category:    req.Category,
+		IfMatch:     parseIfMatch(c.Request().Header.Get(headerIfMatch)),
+	})
+	if err != nil {
+		return err
+	}
+	c.Set("audit_changes", changes)
+
+	c.Response().Header().Set(headerETag, fileETag(file))
+	return c.JSON(http.StatusOK, responses.BuildFileResponse(file, h.links.For(c)))
+}
+
+func (h *FileHandlers) DeleteFile(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.deleteUseCase.Execute(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// fileETag is the entity tag of a file, which changes whenever its metadata
+// does. The content of a file never changes.
+func fileETag(file *domain.File) string {
+	return `"` + strconv.FormatInt(file.Version, 10) + `"`
+}
+
+// parseIfMatch returns the file versions listed in an If-Match header, or nil
+// when the header is absent or "*". Weak tags never match, as If-Match
+// requires a strong comparison.
+func parseIfMatch(header string) []int64 {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+const (
+	dispositionAttachment = "attachment"
+	dispositionInline     = "inline"
+)
+
+// contentDisposition formats a Content-Disposition header the way RFC 6266
+// recommends: a quoted ASCII filename for older clients, followed by the
+// exact name as a UTF-8 filename* parameter (RFC 5987) when the two differ.
+func contentDisposition(disposition, name string) string {
+	// Accented letters fall back to their base letter, anything else that
+	// cannot be sent as plain quoted text to "_"
+	var fallback strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r < 0x20, r == 0x7f:
+		case r > 0x7e, r == '"', r == '\\':
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	header := disposition + `; filename="` + fallback.String() + `"`
+	if fallback.String() != name {
+		header += "; filename*=UTF-8''" + encodeExtValue(name)
+	}
+	return header
+}
+
+// encodeExtValue percent-encodes s as the value of an RFC 5987 parameter,
+// leaving only attr-char bytes as they are.
+func encodeExtValue(s string) string {
+	const attrChars = "!#$&+-.^_`|~"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte(attrChars, c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
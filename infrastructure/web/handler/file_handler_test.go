@@ -0,0 +1,18 @@
+package handlers
+
+import "testing"
+
+func TestContentDisposition(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+	}{
+		{"report.pdf", `attachment; filename="report.pdf"`},
+		{"Résumé.pdf", `attachment; filename="Resume.pdf"; filename*=UTF-8''R%C3%A9sum%C3%A9.pdf`},
+		{`say "hi".pdf`, `attachment; filename="say _hi_.pdf"; filename*=UTF-8''say%20%22hi%22.pdf`},
+		{"文件.pdf", `attachment; filename="__.pdf"; filename*=UTF-8''%E6%96%87%E4%BB%B6.pdf`},
+	} {
+		if got := contentDisposition(dispositionAttachment, tc.name); got != tc.want {
+			t.Errorf("contentDisposition(%q)\n got %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/health"
+)
+
+type HealthHandlers struct {
+	checker *health.Checker
+}
+
+func NewHealthHandlers(checker *health.Checker) *HealthHandlers {
+	return &HealthHandlers{checker: checker}
+}
+
+// Healthz reports that the process is alive. It never checks dependencies,
+// so a MongoDB outage does not get the instance restarted.
+func (h *HealthHandlers) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the instance can serve traffic.
+func (h *HealthHandlers) Readyz(c echo.Context) error {
+	report := h.checker.Run(c.Request().Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, report)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/health"
+)
+
+func TestReadyz(t *testing.T) {
+	mongoDown := health.Check{Name: "mongodb", Run: func(ctx context.Context) error { return errors.New("server selection timeout") }}
+	mongoUp := health.Check{Name: "mongodb", Run: func(ctx context.Context) error { return nil }}
+
+	for _, tc := range []struct {
+		name         string
+		check        health.Check
+		shuttingDown bool
+		code         int
+		status       string
+	}{
+		{name: "ready", check: mongoUp, code: http.StatusOK, status: "ready"},
+		{name: "dependency down", check: mongoDown, code: http.StatusServiceUnavailable, status: "not_ready"},
+		{name: "shutting down", check: mongoUp, shuttingDown: true, code: http.StatusServiceUnavailable, status: "shutting_down"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := health.NewChecker()
+			checker.Add(tc.check)
+			if tc.shuttingDown {
+				checker.SetShuttingDown()
+			}
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)
+
+			if err := NewHealthHandlers(checker).Readyz(c); err != nil {
+				t.Fatal(err)
+			}
+			var report health.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tc.code || report.Status != tc.status {
+				t.Errorf("got %d %+v", rec.Code, report)
+			}
+		})
+	}
+}
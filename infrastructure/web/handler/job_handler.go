@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type JobHandlers struct {
+	getUseCase   *usecases.GetJobsUseCase
+	retryUseCase *usecases.RetryJobUseCase
+	links        *links.Builder
+}
+
+func NewJobHandlers(getUC *usecases.GetJobsUseCase, retryUC *usecases.RetryJobUseCase, linkBuilder *links.Builder) *JobHandlers {
+	return &JobHandlers{getUseCase: getUC, retryUseCase: retryUC, links: linkBuilder}
+}
+
+func (h *JobHandlers) GetJobs(c echo.Context) error {
+	page, _ := c.Get("page").(int)
+	perPage, _ := c.Get("per_page").(int)
+
+	result, err := h.getUseCase.Execute(c.Request().Context(), usecases.GetJobsQuery{
+		Filter: domain.JobFilter{
+			Type:   c.QueryParam("type"),
+			Status: domain.JobStatus(c.QueryParam("status")),
+		},
+		Page:    page,
+		PerPage: perPage,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list jobs")
+	}
+
+	response := map[string]interface{}{
+		"data": responses.BuildJobsResponse(result.Jobs, h.links.For(c)),
+		"pagination": map[string]interface{}{
+			"total":       result.Total,
+			"page":        result.Page,
+			"per_page":    result.PerPage,
+			"total_pages": result.TotalPages,
+		},
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *JobHandlers) GetJob(c echo.Context) error {
+	job, err := h.getUseCase.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, responses.BuildJobResponse(job, h.links.For(c)))
+}
+
+// RetryJob queues a dead job again.
+func (h *JobHandlers) RetryJob(c echo.Context) error {
+	job, err := h.retryUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return errors.Wrap(err, "job retry failed")
+	}
+
+	return c.JSON(http.StatusAccepted, responses.BuildJobResponse(job, h.links.For(c)))
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+// sharePasswordHeader carries the password of a protected share link on
+// GET requests. It is never read from the query string, which ends up in
+// access logs.
+const sharePasswordHeader = "X-Share-Password"
+
+type ShareHandlers struct {
+	createUseCase  *usecases.CreateShareLinkUseCase
+	getUseCase     *usecases.GetShareLinksUseCase
+	revokeUseCase  *usecases.RevokeShareLinkUseCase
+	resolveUseCase *usecases.ResolveShareLinkUseCase
+	links          *links.Builder
+}
+
+func NewShareHandlers(
+	createUC *usecases.CreateShareLinkUseCase,
+	getUC *usecases.GetShareLinksUseCase,
+	revokeUC *usecases.RevokeShareLinkUseCase,
+	resolveUC *usecases.ResolveShareLinkUseCase,
+	linkBuilder *links.Builder,
+) *ShareHandlers {
+	return &ShareHandlers{
+		createUseCase:  createUC,
+		getUseCase:     getUC,
+		revokeUseCase:  revokeUC,
+		resolveUseCase: resolveUC,
+		links:          linkBuilder,
+	}
+}
+
+type createShareLinkRequest struct {
+	ExpiresIn    int64  `json:"expires_in"` // seconds
+	MaxDownloads int64  `json:"max_downloads"`
+	Password     string `json:"password"`
+}
+
+func (h *ShareHandlers) CreateShareLink(c echo.Context) error {
+	var req createShareLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	signed, err := h.createUseCase.Execute(c.Request().Context(), usecases.CreateShareLinkCommand{
+		FileID:       c.Param("id"),
+		CreatedBy:    middleware.Actor(c),
+		TTL:          time.Duration(req.ExpiresIn) * time.Second,
+		MaxDownloads: req.MaxDownloads,
+		Password:     req.Password,
+	})
+	if err != nil {
+		return errors.Wrap(err, "share link creation failed")
+	}
+
+	l := h.links.For(c)
+	shareURL := l.URL(links.RouteShareDownload, signed.Token)
+
+	return c.JSON(http.StatusCreated, responses.BuildShareLinkResponse(signed.Link, shareURL, l))
+}
+
+type shareDownloadRequest struct {
+	Password string `json:"password" form:"password"`
+}
+
+// DownloadShare serves the file of a share link without a session. Password
+// protected links take the password from the X-Share-Password header or,
+// on POST, from a form or JSON body.
+func (h *ShareHandlers) DownloadShare(c echo.Context) error {
+	disposition, err := parseDisposition(c)
+	if err != nil {
+		return err
+	}
+
+	password := c.Request().Header.Get(sharePasswordHeader)
+	if password == "" && c.Request().Method == http.MethodPost {
+		var req shareDownloadRequest
+		if err := c.Bind(&req); err != nil {
+			return domain.ErrInvalidRequest
+		}
+		password = req.Password
+	}
+
+	// Downloads are audited under the link, also when they are refused
+	token := c.Param("token")
+	if linkID, _, _, ok := usecases.ParseToken(token); ok {
+		c.Set("actor", "share:"+linkID)
+	}
+
+	_, file, content, err := h.resolveUseCase.Execute(c.Request().Context(), usecases.ResolveShareLinkCommand{
+		Token:    token,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	c.Set("file_id", file.ID)
+
+	return streamFile(c, file, content, disposition)
+}
+
+func (h *ShareHandlers) GetShareLinks(c echo.Context) error {
+	shareLinks, err := h.getUseCase.Execute(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list share links")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": responses.BuildShareLinksResponse(shareLinks, h.links.For(c)),
+	})
+}
+
+func (h *ShareHandlers) RevokeShareLink(c echo.Context) error {
+	link, err := h.revokeUseCase.Execute(c.Request().Context(), c.Param("share_id"))
+	if err != nil {
+		return errors.Wrap(err, "share link revocation failed")
+	}
+	c.Set("file_id", link.FileID)
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/responses"
+)
+
+type WebhookHandlers struct {
+	registerUseCase      *usecases.RegisterWebhookUseCase
+	getUseCase           *usecases.GetWebhooksUseCase
+	deleteUseCase        *usecases.DeleteWebhookUseCase
+	getDeliveriesUseCase *usecases.GetWebhookDeliveriesUseCase
+	redeliverUseCase     *usecases.RedeliverWebhookUseCase
+	links                *links.Builder
+}
+
+func NewWebhookHandlers(
+	registerUC *usecases.RegisterWebhookUseCase,
+	getUC *usecases.GetWebhooksUseCase,
+	deleteUC *usecases.DeleteWebhookUseCase,
+	getDeliveriesUC *usecases.GetWebhookDeliveriesUseCase,
+	redeliverUC *usecases.RedeliverWebhookUseCase,
+	linkBuilder *links.Builder,
+) *WebhookHandlers {
+	return &WebhookHandlers{
+		registerUseCase:      registerUC,
+		getUseCase:           getUC,
+		deleteUseCase:        deleteUC,
+		getDeliveriesUseCase: getDeliveriesUC,
+		redeliverUseCase:     redeliverUC,
+		links:                linkBuilder,
+	}
+}
+
+type registerWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+func (h *WebhookHandlers) RegisterWebhook(c echo.Context) error {
+	var req registerWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return domain.ErrInvalidRequest
+	}
+
+	webhook, err := h.registerUseCase.Execute(c.Request().Context(), usecases.RegisterWebhookCommand{
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    req.Secret,
+		CreatedBy: middleware.Actor(c),
+	})
+	if err != nil {
+		return errors.Wrap(err, "webhook registration failed")
+	}
+
+	return c.JSON(http.StatusCreated, responses.BuildWebhookResponse(webhook, true, h.links.For(c)))
+}
+
+func (h *WebhookHandlers) GetWebhooks(c echo.Context) error {
+	webhooks, err := h.getUseCase.Execute(c.Request().Context())
+	if err != nil {
+		return errors.Wrap(err, "failed to list webhooks")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": responses.BuildWebhooksResponse(webhooks, h.links.For(c)),
+	})
+}
+
+func (h *WebhookHandlers) DeleteWebhook(c echo.Context) error {
+	if err := h.deleteUseCase.Execute(c.Request().Context(), c.Param("id")); err != nil {
+		return errors.Wrap(err, "webhook delete failed")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandlers) GetDeliveries(c echo.Context) error {
+	page, _ := c.Get("page").(int)
+	perPage, _ := c.Get("per_page").(int)
+
+	result, err := h.getDeliveriesUseCase.Execute(c.Request().Context(), usecases.GetWebhookDeliveriesQuery{
+		WebhookID: c.Param("id"),
+		Page:      page,
+		PerPage:   perPage,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list webhook deliveries")
+	}
+
+	response := map[string]interface{}{
+		"data": responses.BuildWebhookDeliveriesResponse(result.Deliveries, h.links.For(c)),
+		"pagination": map[string]interface{}{
+			"total":       result.Total,
+			"page":        result.Page,
+			"per_page":    result.PerPage,
+			"total_pages": result.TotalPages,
+		},
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandlers) Redeliver(c echo.Context) error {
+	delivery, err := h.redeliverUseCase.Execute(c.Request().Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		return errors.Wrap(err, "webhook redelivery failed")
+	}
+
+	return c.JSON(http.StatusAccepted, responses.BuildWebhookDeliveryResponse(delivery, h.links.For(c)))
+}
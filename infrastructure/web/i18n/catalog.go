@@ -0,0 +1,83 @@
+// Package i18n selects message catalogs by Accept-Language.
+package i18n
+
+import (
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// Catalog holds the messages of every supported language.
+type Catalog struct {
+	uni      *ut.UniversalTranslator
+	fallback ut.Translator
+}
+
+// messages maps each locale to its catalog. Keys are error codes and
+// "field.<code>" for field-level validation messages.
+var messages = map[locales.Translator]map[string]string{
+	en.New(): messagesEN,
+	id.New(): messagesID,
+}
+
+// NewCatalog loads the catalogs. Requests that accept none of the supported
+// languages get defaultLanguage.
+func NewCatalog(defaultLanguage string) (*Catalog, error) {
+	fallbackLocale := en.New()
+	uni := ut.New(fallbackLocale)
+	for locale, catalog := range messages {
+		if err := uni.AddTranslator(locale, true); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %s translator", locale.Locale())
+		}
+		trans, _ := uni.GetTranslator(locale.Locale())
+		for key, text := range catalog {
+			if err := trans.Add(key, text, true); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s message %q", locale.Locale(), key)
+			}
+		}
+	}
+
+	fallback, found := uni.GetTranslator(defaultLanguage)
+	if !found {
+		return nil, errors.Errorf("unsupported default language %q", defaultLanguage)
+	}
+	return &Catalog{uni: uni, fallback: fallback}, nil
+}
+
+// For returns the localizer for an Accept-Language header value.
+func (c *Catalog) For(acceptLanguage string) *Localizer {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err == nil {
+		for _, tag := range tags {
+			base, _ := tag.Base()
+			if trans, found := c.uni.FindTranslator(strings.ReplaceAll(tag.String(), "-", "_"), base.String()); found {
+				return &Localizer{trans: trans}
+			}
+		}
+	}
+	return &Localizer{trans: c.fallback}
+}
+
+// Localizer translates messages into one language.
+type Localizer struct {
+	trans ut.Translator
+}
+
+// Language returns the language tag of the messages, e.g. "id".
+func (l *Localizer) Language() string {
+	return l.trans.Locale()
+}
+
+// Message returns the message for key, or fallback if the catalog has none.
+func (l *Localizer) Message(key, fallback string, params ...string) string {
+	text, err := l.trans.T(key, params...)
+	if err != nil || text == "" {
+		return fallback
+	}
+	return text
+}
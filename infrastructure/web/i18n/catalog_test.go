@@ -0,0 +1,71 @@
+package i18n
+
+import "testing"
+
+func TestCatalogNegotiatesLanguage(t *testing.T) {
+	catalog, err := NewCatalog("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		acceptLanguage, want string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID,id;q=0.9,en;q=0.8", "id"},
+		{"fr-FR,en;q=0.5", "en"},
+		{"fr", "en"},
+		{"en-GB", "en"},
+		{";;not a header", "en"},
+	} {
+		if got := catalog.For(tc.acceptLanguage).Language(); got != tc.want {
+			t.Errorf("For(%q) = %s, want %s", tc.acceptLanguage, got, tc.want)
+		}
+	}
+
+	catalog, err = NewCatalog("id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := catalog.For("fr").Language(); got != "id" {
+		t.Errorf("unsupported language falls back to %s, want id", got)
+	}
+}
+
+func TestNewCatalogRejectsUnsupportedDefault(t *testing.T) {
+	if _, err := NewCatalog("fr"); err == nil {
+		t.Fatal("unsupported default accepted")
+	}
+}
+
+func TestLocalizerMessage(t *testing.T) {
+	catalog, err := NewCatalog("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := catalog.For("id")
+	for _, tc := range []struct {
+		name, got, want string
+	}{
+		{"translated", l.Message("file_not_found", "file not found"), "berkas tidak ditemukan"},
+		{"params", l.Message("field.quota", "quota", "500 GiB", "1.0 GiB"), "kuota penyimpanan 500 GiB telah tercapai, tersisa 1.0 GiB"},
+		{"missing key", l.Message("no_such_code", "fallback text"), "fallback text"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestCatalogsHaveTheSameKeys(t *testing.T) {
+	for key := range messagesEN {
+		if _, ok := messagesID[key]; !ok {
+			t.Errorf("%s missing from the Indonesian catalog", key)
+		}
+	}
+	for key := range messagesID {
+		if _, ok := messagesEN[key]; !ok {
+			t.Errorf("%s missing from the English catalog", key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package i18n
+
+var messagesEN = map[string]string{
+	// Files
+	"file_not_found":         "file not found",
+	"file_required":          "file is required",
+	"file_too_large":         "file is too large",
+	"file_type_not_allowed":  "file type not allowed",
+	"storage_quota_exceeded": "storage quota exceeded",
+	"invalid_request":        "invalid request",
+	"invalid_cursor":         "invalid pagination cursor",
+	"file_modified":          "file has been modified since it was read",
+
+	// Collections
+	"collection_not_found":  "collection not found",
+	"collection_name_taken": "a collection with this name already exists here",
+	"collection_not_empty":  "collection still contains files or collections",
+	"collection_cycle":      "a collection cannot be moved into itself or one of its descendants",
+
+	// Archives and exports
+	"archive_empty":          "no files match the selection",
+	"archive_too_many_files": "too many files for a direct download, request an asynchronous export",
+	"export_not_found":       "export not found",
+	"export_not_ready":       "export is not ready yet",
+	"export_failed":          "export failed",
+
+	// Idempotency
+	"idempotency_key_reused":          "idempotency key was already used for a different request",
+	"idempotency_request_in_progress": "a request with this idempotency key is still in progress",
+
+	// Share links
+	"share_link_not_found":         "share link not found",
+	"share_link_invalid":           "share link signature is invalid",
+	"share_link_expired":           "share link has expired",
+	"share_link_revoked":           "share link has been revoked",
+	"share_link_exhausted":         "share link download limit reached",
+	"share_link_password_required": "share link password required",
+	"share_link_password_invalid":  "share link password is invalid",
+	"share_link_invalid_ttl":       "share link expiry exceeds maximum allowed",
+
+	// Webhooks
+	"webhook_not_found":          "webhook not found",
+	"webhook_delivery_not_found": "webhook delivery not found",
+	"webhook_invalid_url":        "webhook url must be an absolute http(s) url",
+	"webhook_forbidden_address":  "webhook url must not point to a loopback, link-local or private address",
+	"webhook_unknown_event":      "unknown event type",
+
+	// Background jobs
+	"job_not_found":     "job not found",
+	"job_not_retryable": "only dead jobs can be retried",
+
+	// Users
+	"invalid_token": "access token is invalid",
+
+	// Generic
+	"internal_error":           "internal server error",
+	"bad_request":              "bad request",
+	"unauthorized":             "authentication required",
+	"forbidden":                "access denied",
+	"not_found":                "resource not found",
+	"method_not_allowed":       "method not allowed",
+	"request_entity_too_large": "request is too large",
+	"unsupported_media_type":   "unsupported media type",
+	"too_many_requests":        "too many requests",
+	"service_unavailable":      "service unavailable",
+
+	// Field validation
+	"field.required":        "{0} is required",
+	"field.max_size":        "file size exceeds maximum allowed: {0}",
+	"field.mimetype":        "allowed types: {0}",
+	"field.quota":           "storage quota of {0} reached, {1} left",
+	"field.integer":         "{0} must be an integer",
+	"field.max_items":       "at most {0} files are accepted per batch",
+	"field.zip":             "{0} is not a valid ZIP archive",
+	"field.max_length":      "{0} must be at most {1} characters",
+	"field.collection_name": "{0} must not contain slashes or control characters",
+	"field.filename":        "{0} must not contain \\ / : * ? \" < > | or a long extension",
+	"field.max_count":       "{0} must have at most {1} items",
+	"field.one_of":          "{0} must be one of: {1}",
+	"field.anchor":          "{0} must be <sequence>:<hash>",
+}
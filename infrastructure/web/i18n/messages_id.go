@@ -0,0 +1,81 @@
+package i18n
+
+var messagesID = map[string]string{
+	// Files
+	"file_not_found":         "berkas tidak ditemukan",
+	"file_required":          "berkas wajib diunggah",
+	"file_too_large":         "berkas terlalu besar",
+	"file_type_not_allowed":  "jenis berkas tidak diizinkan",
+	"storage_quota_exceeded": "kuota penyimpanan terlampaui",
+	"invalid_request":        "permintaan tidak valid",
+	"invalid_cursor":         "kursor halaman tidak valid",
+	"file_modified":          "berkas telah diubah sejak terakhir dibaca",
+
+	// Collections
+	"collection_not_found":  "koleksi tidak ditemukan",
+	"collection_name_taken": "koleksi dengan nama ini sudah ada di sini",
+	"collection_not_empty":  "koleksi masih berisi berkas atau koleksi",
+	"collection_cycle":      "koleksi tidak dapat dipindahkan ke dalam dirinya sendiri atau turunannya",
+
+	// Archives and exports
+	"archive_empty":          "tidak ada berkas yang cocok dengan pilihan",
+	"archive_too_many_files": "terlalu banyak berkas untuk diunduh langsung, gunakan ekspor asinkron",
+	"export_not_found":       "ekspor tidak ditemukan",
+	"export_not_ready":       "ekspor belum siap",
+	"export_failed":          "ekspor gagal",
+
+	// Idempotency
+	"idempotency_key_reused":          "kunci idempotensi sudah dipakai untuk permintaan lain",
+	"idempotency_request_in_progress": "permintaan dengan kunci idempotensi ini masih diproses",
+
+	// Share links
+	"share_link_not_found":         "tautan berbagi tidak ditemukan",
+	"share_link_invalid":           "tanda tangan tautan berbagi tidak valid",
+	"share_link_expired":           "tautan berbagi sudah kedaluwarsa",
+	"share_link_revoked":           "tautan berbagi sudah dicabut",
+	"share_link_exhausted":         "batas unduhan tautan berbagi sudah tercapai",
+	"share_link_password_required": "kata sandi tautan berbagi diperlukan",
+	"share_link_password_invalid":  "kata sandi tautan berbagi salah",
+	"share_link_invalid_ttl":       "masa berlaku tautan berbagi melebihi batas maksimum",
+
+	// Webhooks
+	"webhook_not_found":          "webhook tidak ditemukan",
+	"webhook_delivery_not_found": "pengiriman webhook tidak ditemukan",
+	"webhook_invalid_url":        "url webhook harus berupa url http(s) lengkap",
+	"webhook_forbidden_address":  "url webhook tidak boleh mengarah ke alamat loopback, link-local, atau privat",
+	"webhook_unknown_event":      "jenis peristiwa tidak dikenal",
+
+	// Background jobs
+	"job_not_found":     "pekerjaan tidak ditemukan",
+	"job_not_retryable": "hanya pekerjaan yang mati yang dapat diulang",
+
+	// Users
+	"invalid_token": "token akses tidak valid",
+
+	// Generic
+	"internal_error":           "terjadi kesalahan pada server",
+	"bad_request":              "permintaan tidak valid",
+	"unauthorized":             "autentikasi diperlukan",
+	"forbidden":                "akses ditolak",
+	"not_found":                "sumber daya tidak ditemukan",
+	"method_not_allowed":       "metode tidak diizinkan",
+	"request_entity_too_large": "permintaan terlalu besar",
+	"unsupported_media_type":   "jenis media tidak didukung",
+	"too_many_requests":        "terlalu banyak permintaan",
+	"service_unavailable":      "layanan tidak tersedia",
+
+	// Field validation
+	"field.required":        "{0} wajib diisi",
+	"field.max_size":        "ukuran berkas melebihi batas maksimum: {0}",
+	"field.mimetype":        "jenis yang diizinkan: {0}",
+	"field.quota":           "kuota penyimpanan {0} telah tercapai, tersisa {1}",
+	"field.integer":         "{0} harus berupa bilangan bulat",
+	"field.max_items":       "paling banyak {0} berkas per unggahan",
+	"field.zip":             "{0} bukan arsip ZIP yang valid",
+	"field.max_length":      "{0} paling banyak {1} karakter",
+	"field.collection_name": "{0} tidak boleh berisi garis miring atau karakter kontrol",
+	"field.filename":        "{0} tidak boleh berisi \\ / : * ? \" < > | atau ekstensi yang panjang",
+	"field.max_count":       "{0} paling banyak berisi {1} butir",
+	"field.one_of":          "{0} harus salah satu dari: {1}",
+	"field.anchor":          "{0} harus berbentuk <urutan>:<hash>",
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/health"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+)
+
+// Lifecycle ties readiness, in-flight transfers and background workers to
+// the server's shutdown sequence.
+type Lifecycle struct {
+	Readiness *health.Checker
+	Transfers *middleware.InFlight
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func NewLifecycle() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		Readiness: health.NewChecker(),
+		Transfers: middleware.NewInFlight(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Go runs a background worker until shutdown.
+func (l *Lifecycle) Go(worker func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		worker(l.ctx)
+	}()
+}
+
+// Stopping is closed when shutdown begins; long-lived streams end on it so
+// they do not hold the server open.
+func (l *Lifecycle) Stopping() <-chan struct{} {
+	return l.ctx.Done()
+}
+
+// Stop signals background workers and long-lived streams to end.
+func (l *Lifecycle) Stop() {
+	l.cancel()
+}
+
+// WaitForWorkers waits for background workers to return, up to ctx.
+func (l *Lifecycle) WaitForWorkers(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,39 @@
+package web
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStopsWorkersAndStreams(t *testing.T) {
+	lifecycle := NewLifecycle()
+	release := make(chan struct{})
+	lifecycle.Go(func(ctx context.Context) {
+		<-ctx.Done()
+	})
+	lifecycle.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		<-release
+	})
+
+	select {
+	case <-lifecycle.Stopping():
+		t.Fatal("stopping before Stop")
+	default:
+	}
+	lifecycle.Stop()
+	<-lifecycle.Stopping()
+
+	// A worker still cleaning up holds the wait until its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if lifecycle.WaitForWorkers(ctx) {
+		t.Fatal("wait returned before every worker did")
+	}
+
+	close(release)
+	if !lifecycle.WaitForWorkers(context.Background()) {
+		t.Fatal("workers did not return")
+	}
+}
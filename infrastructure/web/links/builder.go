@@ -0,0 +1,118 @@
+package links
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Route names used for reverse routing. Links to routes that are not
+// registered are left out of responses.
+const (
+	RouteFiles          = "files.list"
+	RouteFile           = "files.get"
+	RouteFileDownload   = "files.download"
+	RouteFileShares     = "files.shares"
+	RouteFileThumbnail  = "files.thumbnail"
+	RouteFileVersions   = "files.versions"
+	RouteShare          = "shares.revoke"
+	RouteShareDownload  = "shares.download"
+	RouteCollections    = "collections.list"
+	RouteCollection     = "collections.get"
+	RouteExport         = "exports.get"
+	RouteExportDownload = "exports.download"
+	RouteWebhook        = "webhooks.delete"
+	RouteDeliveries     = "webhooks.deliveries"
+	RouteRedeliver      = "webhooks.redeliver"
+	RouteJobs           = "jobs.list"
+	RouteJob            = "jobs.get"
+	RouteJobRetry       = "jobs.retry"
+)
+
+// Builder produces absolute URLs to API routes. With a configured public URL
+// every link starts with it; otherwise links use the address of the request,
+// taking X-Forwarded-Proto and X-Forwarded-Host into account only when the
+// server is configured to trust a proxy.
+type Builder struct {
+	e          *echo.Echo
+	publicURL  string
+	trustProxy bool
+}
+
+func NewBuilder(e *echo.Echo, publicURL string, trustProxy bool) *Builder {
+	return &Builder{e: e, publicURL: strings.TrimRight(publicURL, "/"), trustProxy: trustProxy}
+}
+
+// For returns the links for one request.
+func (b *Builder) For(c echo.Context) *Links {
+	return &Links{e: b.e, base: b.base(c)}
+}
+
+func (b *Builder) base(c echo.Context) string {
+	if b.publicURL != "" {
+		return b.publicURL
+	}
+
+	req := c.Request()
+	scheme, host := "http", req.Host
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if b.trustProxy {
+		if proto := firstValue(req.Header.Get(echo.HeaderXForwardedProto)); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := firstValue(req.Header.Get("X-Forwarded-Host")); forwarded != "" {
+			host = forwarded
+		}
+	}
+	return scheme + "://" + host
+}
+
+// firstValue returns the first entry of a comma-separated header added to by
+// each proxy along the way.
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
+
+// Links resolves route names to absolute URLs for one request.
+type Links struct {
+	e    *echo.Echo
+	base string
+}
+
+// URL returns the absolute URL of the named route, or "" if no such route is
+// registered.
+func (l *Links) URL(route string, params ...string) string {
+	escaped := make([]interface{}, len(params))
+	for i, p := range params {
+		escaped[i] = url.PathEscape(p)
+	}
+	path := l.e.Reverse(route, escaped...)
+	if path == "" {
+		return ""
+	}
+	return l.base + path
+}
+
+// URLWithQuery is URL with query appended.
+func (l *Links) URLWithQuery(route string, query url.Values, params ...string) string {
+	href := l.URL(route, params...)
+	if href == "" || len(query) == 0 {
+		return href
+	}
+	return href + "?" + query.Encode()
+}
+
+// Set is a HATEOAS link relation set, rendered as "_links" in responses.
+type Set map[string]string
+
+// Add adds the named route under rel if the route exists.
+func (s Set) Add(rel string, l *Links, route string, params ...string) Set {
+	if href := l.URL(route, params...); href != "" {
+		s[rel] = href
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package links
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newEcho() *echo.Echo {
+	e := echo.New()
+	noop := func(c echo.Context) error { return nil }
+	e.GET("/api/v1/files", noop).Name = RouteFiles
+	e.GET("/api/v1/files/:id", noop).Name = RouteFile
+	e.GET("/api/v1/files/:id/download", noop).Name = RouteFileDownload
+	return e
+}
+
+func TestBuilderBase(t *testing.T) {
+	e := newEcho()
+	for _, tc := range []struct {
+		name       string
+		publicURL  string
+		trustProxy bool
+		tls        bool
+		headers    map[string]string
+		want       string
+	}{
+		{name: "request address", want: "http://archive.internal:8080"},
+		{name: "tls", tls: true, want: "https://archive.internal:8080"},
+		{name: "public URL", publicURL: "https://arsip.example.go.id/", want: "https://arsip.example.go.id"},
+		{
+			name:      "public URL wins over proxy headers",
+			publicURL: "https://arsip.example.go.id", trustProxy: true,
+			headers: map[string]string{"X-Forwarded-Proto": "http", "X-Forwarded-Host": "evil.example"},
+			want:    "https://arsip.example.go.id",
+		},
+		{
+			name:    "untrusted proxy headers",
+			headers: map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			want:    "http://archive.internal:8080",
+		},
+		{
+			name: "trusted proxy", trustProxy: true,
+			headers: map[string]string{"X-Forwarded-Proto": "https, http", "X-Forwarded-Host": "arsip.example.go.id, lb.internal"},
+			want:    "https://arsip.example.go.id",
+		},
+		{
+			name: "trusted proxy with an odd scheme", trustProxy: true,
+			headers: map[string]string{"X-Forwarded-Proto": "javascript"},
+			want:    "http://archive.internal:8080",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files", nil)
+			req.Host = "archive.internal:8080"
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			l := NewBuilder(e, tc.publicURL, tc.trustProxy).For(e.NewContext(req, httptest.NewRecorder()))
+
+			if got, want := l.URL(RouteFile, "f001"), tc.want+"/api/v1/files/f001"; got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	e := newEcho()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files", nil)
+	l := NewBuilder(e, "https://arsip.example.go.id/archive", false).For(e.NewContext(req, httptest.NewRecorder()))
+
+	for _, tc := range []struct {
+		name, got, want string
+	}{
+		{"escaped params", l.URL(RouteFileDownload, "a/b c"), "https://arsip.example.go.id/archive/api/v1/files/a%2Fb%20c/download"},
+		{"query", l.URLWithQuery(RouteFiles, url.Values{"cursor": {"abc="}, "per_page": {"20"}}), "https://arsip.example.go.id/archive/api/v1/files?cursor=abc%3D&per_page=20"},
+		{"empty query", l.URLWithQuery(RouteFiles, nil), "https://arsip.example.go.id/archive/api/v1/files"},
+		{"unregistered route", l.URL(RouteJobs), ""},
+		{"unregistered route with query", l.URLWithQuery(RouteJobs, url.Values{"status": {"dead"}}), ""},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+
+	set := Set{}.Add("self", l, RouteFile, "f001").Add("jobs", l, RouteJobs)
+	if len(set) != 1 || set["self"] != "https://arsip.example.go.id/archive/api/v1/files/f001" {
+		t.Errorf("set: %v", set)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+// ActorHeader carries the authenticated user name set by the upstream
+// authenticating proxy.
+const ActorHeader = "X-User-ID"
+
+const anonymousActor = "anonymous"
+
+// Actor returns the user the request is attributed to in the audit log.
+// Handlers may override it through the "actor" context value, e.g. for
+// downloads made through a share link.
+func Actor(c echo.Context) string {
+	if actor, ok := c.Get("actor").(string); ok && actor != "" {
+		return actor
+	}
+	if actor := c.Request().Header.Get(ActorHeader); actor != "" {
+		return actor
+	}
+	return anonymousActor
+}
+
+// Audit records the outcome of the wrapped handler in the audit log. The file
+// ID, or the collection ID for collection actions, is taken from the ":id"
+// route parameter, or from the "file_id" or "collection_id" context value for
+// handlers that create them. Handlers that change a file or collection list
+// the changes in the "audit_changes" context value.
+func Audit(uc *usecases.RecordAuditUseCase, action domain.AuditAction) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Let the error handler write the response so that the entry
+			// records the status the client actually received
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			status := c.Response().Status
+
+			outcome := domain.AuditOutcomeSuccess
+			if err != nil || status >= http.StatusBadRequest {
+				outcome = domain.AuditOutcomeFailure
+			}
+
+			fileID, collectionID := c.Param("id"), ""
+			if action.OnCollection() {
+				fileID, collectionID = "", c.Param("id")
+			}
+			if id, ok := c.Get("file_id").(string); ok && id != "" {
+				fileID = id
+			}
+			if id, ok := c.Get("collection_id").(string); ok && id != "" {
+				collectionID = id
+			}
+
+			changes, _ := c.Get("audit_changes").([]domain.AuditChange)
+
+			// Record the entry even when the client has already disconnected
+			entry, auditErr := uc.Execute(context.WithoutCancel(c.Request().Context()), usecases.RecordAuditCommand{
+				Actor:        Actor(c),
+				Action:       action,
+				FileID:       fileID,
+				CollectionID: collectionID,
+				IP:           c.RealIP(),
+				Outcome:      outcome,
+				Status:       status,
+				Changes:      changes,
+			})
+			if auditErr != nil {
+				configs.Logger.Errorw("failed to record audit entry",
+					"error", auditErr.Error(),
+					"action", action,
+					"file_id", fileID,
+					"collection_id", collectionID,
+				)
+				return nil
+			}
+
+			configs.Logger.Infow("audit entry recorded",
+				"seq", entry.Sequence,
+				"hash", entry.Hash,
+				"action", action,
+			)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// Authenticate accepts the bearer tokens of users created with "user create"
+// and attributes the request to the user by setting ActorHeader, replacing
+// any value the client sent. Requests without an archive token are left to
+// the authenticating proxy, including those carrying the proxy's own bearer
+// tokens.
+func Authenticate(uc *usecases.AuthenticateUserUseCase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scheme, token, _ := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+			if !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(token, domain.TokenPrefix) {
+				return next(c)
+			}
+			user, err := uc.Execute(c.Request().Context(), token)
+			if err != nil {
+				return err
+			}
+			c.Request().Header.Set(ActorHeader, user.Name)
+			return next(c)
+		}
+	}
+}
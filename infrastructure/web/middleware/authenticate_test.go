@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/problem"
+)
+
+// memoryUserRepository holds users by token hash.
+type memoryUserRepository map[string]*domain.User
+
+func (r memoryUserRepository) Save(ctx context.Context, user *domain.User) error {
+	r[user.TokenHash] = user
+	return nil
+}
+
+func (r memoryUserRepository) FindByTokenHash(ctx context.Context, hash string) (*domain.User, error) {
+	if user, ok := r[hash]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r memoryUserRepository) DeleteByName(ctx context.Context, name string) error {
+	return nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	repo := memoryUserRepository{}
+	_, token, err := usecases.NewCreateUserUseCase(repo).Execute(context.Background(), "scanner-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := i18n.NewCatalog("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = problem.NewHTTPErrorHandler(catalog)
+	e.Use(Authenticate(usecases.NewAuthenticateUserUseCase(repo)))
+	e.GET("/actor", func(c echo.Context) error {
+		return c.String(http.StatusOK, Actor(c))
+	})
+
+	for _, tc := range []struct {
+		name, authorization, userID string
+		status                      int
+		actor                       string
+	}{
+		{"proxy user", "", "budi", http.StatusOK, "budi"},
+		{"archive token", "Bearer " + token, "", http.StatusOK, "scanner-01"},
+		{"archive token overrides the header", "bearer " + token, "admin", http.StatusOK, "scanner-01"},
+		{"unknown archive token", "Bearer " + domain.TokenPrefix + "unknown", "budi", http.StatusUnauthorized, ""},
+		{"proxy token", "Bearer eyJhbGciOiJSUzI1NiJ9.e30.sig", "budi", http.StatusOK, "budi"},
+		{"basic auth", "Basic YnVkaTpzZWNyZXQ=", "", http.StatusOK, anonymousActor},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/actor", nil)
+			if tc.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+			}
+			if tc.userID != "" {
+				req.Header.Set(ActorHeader, tc.userID)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.status == http.StatusOK && rec.Body.String() != tc.actor {
+				t.Errorf("actor %q, want %q", rec.Body, tc.actor)
+			}
+		})
+	}
+}
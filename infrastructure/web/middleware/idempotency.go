@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentResponseSize = 1 << 20
+	// Request bodies up to this size are kept in memory while the request
+	// is fingerprinted, larger ones in a temporary file.
+	maxSpooledInMemory = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed.
+var replayedHeaders = []string{
+	echo.HeaderContentType,
+	echo.HeaderContentDisposition,
+	echo.HeaderLocation,
+	"Content-Language",
+}
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry. The first request with a key is processed and its response stored;
+// retries with the same key and the same method, path, query and body get the
+// stored response, while a different request with that key is rejected.
+// Server errors and responses too large to store are not kept, so those
+// requests can be retried.
+func Idempotency(uc *usecases.IdempotencyUseCase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := strings.TrimSpace(c.Request().Header.Get(IdempotencyKeyHeader))
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return domain.ErrInvalidRequest.WithFields(domain.FieldError{
+					Field:   IdempotencyKeyHeader,
+					Code:    "max_length",
+					Message: fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+					Params:  []string{IdempotencyKeyHeader, strconv.Itoa(maxIdempotencyKeyLength)},
+				})
+			}
+			// Keys are only unique per client
+			key = Actor(c) + "\n" + key
+
+			fingerprint, cleanup, err := fingerprintRequest(c.Request())
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx := c.Request().Context()
+			stored, err := uc.Begin(ctx, key, fingerprint)
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				return replay(c, stored)
+			}
+
+			// The key is released unless the response gets stored, also
+			// when the handler panics
+			settled := false
+			defer func() {
+				if settled {
+					return
+				}
+				if err := uc.Release(context.WithoutCancel(ctx), key); err != nil {
+					configs.Logger.Errorw("failed to release idempotency key", "error", err.Error())
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			err = next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			c.Response().Writer = recorder.ResponseWriter
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError || recorder.overflow {
+				return nil
+			}
+			response := usecases.IdempotentResponse{Status: status, Header: map[string]string{}, Body: recorder.body.Bytes()}
+			for _, name := range replayedHeaders {
+				if value := c.Response().Header().Get(name); value != "" {
+					response.Header[name] = value
+				}
+			}
+			if err := uc.Complete(context.WithoutCancel(ctx), key, fingerprint, response); err != nil {
+				configs.Logger.Errorw("failed to store idempotent response", "error", err.Error())
+				// A lost reservation belongs to another request now
+				settled = errors.Is(err, domain.ErrIdempotencyInProgress)
+				return nil
+			}
+			settled = true
+			return nil
+		}
+	}
+}
+
+func replay(c echo.Context, stored *usecases.IdempotentResponse) error {
+	header := c.Response().Header()
+	for name, value := range stored.Header {
+		header.Set(name, value)
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	c.Response().WriteHeader(stored.Status)
+	_, err := c.Response().Write(stored.Body)
+	return err
+}
+
+// fingerprintRequest hashes the method, path, query and body of req. The
+// body is read in full and put back for the handler; the returned function
+// removes what was buffered.
+func fingerprintRequest(req *http.Request) (string, func(), error) {
+	body, err := spoolBody(req.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := hashBody(req, body)
+	if err == nil {
+		err = body.rewind()
+	}
+	if err != nil {
+		body.Close()
+		return "", nil, err
+	}
+	req.Body = body
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s", req.Method, req.URL.Path, req.URL.RawQuery, content)
+	return hex.EncodeToString(h.Sum(nil)), func() { body.Close() }, nil
+}
+
+// hashBody hashes multipart bodies part by part, so that a retry sent with a
+// new boundary still matches, and any other body as is.
+func hashBody(req *http.Request, body *spooledBody) (string, error) {
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		if sum, err := hashMultipart(body, params["boundary"]); err == nil {
+			return sum, nil
+		}
+	}
+
+	if err := body.rewind(); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", errors.Wrap(err, "failed to read request body")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashMultipart(body *spooledBody, boundary string) (string, error) {
+	if err := body.rewind(); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return hex.EncodeToString(h.Sum(nil)), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%q %q %q %x\n", part.FormName(), part.FileName(), part.Header.Get(echo.HeaderContentType), content.Sum(nil))
+	}
+}
+
+// spooledBody is a request body read ahead of the handler, kept in memory
+// or, when large, in a temporary file.
+type spooledBody struct {
+	io.ReadSeeker
+	file *os.File
+}
+
+func spoolBody(r io.ReadCloser) (*spooledBody, error) {
+	defer r.Close()
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, maxSpooledInMemory+1)
+	if err != nil && err != io.EOF {
+		return nil, readError(err)
+	}
+	if n <= maxSpooledInMemory {
+		return &spooledBody{ReadSeeker: bytes.NewReader(buf.Bytes())}, nil
+	}
+
+	file, err := os.CreateTemp("", "idempotency-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to buffer request body")
+	}
+	body := &spooledBody{ReadSeeker: file, file: file}
+	if _, err := io.Copy(file, io.MultiReader(&buf, r)); err != nil {
+		body.Close()
+		return nil, readError(err)
+	}
+	return body, nil
+}
+
+// readError reports bodies cut off by BodyLimit as too large.
+func readError(err error) error {
+	if IsBodyTooLarge(err) {
+		return echo.ErrStatusRequestEntityTooLarge
+	}
+	return errors.Wrap(err, "failed to read request body")
+}
+
+func (b *spooledBody) rewind() error {
+	_, err := b.Seek(0, io.SeekStart)
+	return errors.Wrap(err, "failed to rewind request body")
+}
+
+// Close removes the temporary file, if any.
+func (b *spooledBody) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
+
+// responseRecorder keeps a copy of the response body as it is written, up to
+// maxIdempotentResponseSize.
+type responseRecorder struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxIdempotentResponseSize {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
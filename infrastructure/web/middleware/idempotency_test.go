@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/problem"
+	"go.uber.org/zap"
+)
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+// memoryIdempotencyRepository follows the semantics of the MongoDB
+// repository.
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: map[string]domain.IdempotencyRecord{}}
+}
+
+func (r *memoryIdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return &existing, nil
+	}
+	r.records[record.Key] = *record
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.records[record.Key]
+	if !ok || existing.Completed || existing.Fingerprint != record.Fingerprint {
+		return domain.ErrIdempotencyInProgress
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.records[key].Completed {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+// idempotentServer counts the requests its upload handler carries out.
+type idempotentServer struct {
+	e       *echo.Echo
+	handled int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newIdempotentServer(lease time.Duration) *idempotentServer {
+	s := &idempotentServer{e: echo.New()}
+	catalog, err := i18n.NewCatalog("en")
+	if err != nil {
+		panic(err)
+	}
+	s.e.HTTPErrorHandler = problem.NewHTTPErrorHandler(catalog)
+	uc := usecases.NewIdempotencyUseCase(newMemoryIdempotencyRepository(), time.Hour, lease)
+	s.e.POST("/upload", func(c echo.Context) error {
+		s.handled++
+		if s.release != nil {
+			s.started <- struct{}{}
+			<-s.release
+		}
+		return c.JSON(http.StatusCreated, map[string]int{"id": s.handled})
+	}, BodyLimit(64), Idempotency(uc))
+	return s
+}
+
+func (s *idempotentServer) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysCompletedRequests(t *testing.T) {
+	s := newIdempotentServer(time.Minute)
+
+	first := s.post("k1", "document")
+	retry := s.post("k1", "document")
+	if s.handled != 1 {
+		t.Fatalf("handler ran %d times", s.handled)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("retry got %d %q, want replay of %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+
+	if other := s.post("k1", "another document"); other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key: got %d", other.Code)
+	}
+	if fresh := s.post("k2", "document"); fresh.Code != http.StatusCreated || s.handled != 2 {
+		t.Errorf("new key: got %d after %d requests", fresh.Code, s.handled)
+	}
+}
+
+func TestIdempotencyRejectsRetriesWhileInProgress(t *testing.T) {
+	s := newIdempotentServer(time.Minute)
+	s.started = make(chan struct{})
+	s.release = make(chan struct{})
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.post("k1", "document") }()
+
+	// The first request holds the key until it is released
+	<-s.started
+	retry := s.post("k1", "document")
+	close(s.release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first request: got %d", first.Code)
+	}
+	if retry.Code != http.StatusConflict {
+		t.Fatalf("retry during the request: got %d", retry.Code)
+	}
+}
+
+func TestIdempotencyLeaseFreesAbandonedKeys(t *testing.T) {
+	repo := newMemoryIdempotencyRepository()
+	uc := usecases.NewIdempotencyUseCase(repo, time.Hour, 10*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := uc.Begin(ctx, "k1", "fp"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	// The first request never completed; a retry takes the key over
+	if stored, err := uc.Begin(ctx, "k1", "fp"); err != nil || stored != nil {
+		t.Fatalf("after the lease: got %v, %v", stored, err)
+	}
+	if err := uc.Complete(ctx, "k1", "fp", usecases.IdempotentResponse{Status: http.StatusCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Completed responses are kept for the TTL, beyond the lease
+	time.Sleep(20 * time.Millisecond)
+	if stored, err := uc.Begin(ctx, "k1", "fp"); err != nil || stored == nil || stored.Status != http.StatusCreated {
+		t.Fatalf("after completion: got %v, %v", stored, err)
+	}
+	if expires := repo.records["k1"].ExpiresAt; time.Until(expires) < 59*time.Minute {
+		t.Fatalf("completed response expires at %s", expires)
+	}
+}
+
+func TestIdempotencyLimitsBodiesBeforeSpooling(t *testing.T) {
+	s := newIdempotentServer(time.Minute)
+	if rec := s.post("k1", strings.Repeat("x", 65)); rec.Code != http.StatusRequestEntityTooLarge || s.handled != 0 {
+		t.Fatalf("oversized body: got %d after %d requests", rec.Code, s.handled)
+	}
+
+	// Without Content-Length the limit applies while the body is read
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("x", 65)))
+	req.ContentLength = -1
+	req.Header.Set(IdempotencyKeyHeader, "k2")
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || s.handled != 0 {
+		t.Fatalf("oversized streamed body: got %d after %d requests", rec.Code, s.handled)
+	}
+}
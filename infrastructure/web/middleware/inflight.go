@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// InFlight tracks requests that move file content, so shutdown can wait for
+// them to finish or clean up.
+type InFlight struct {
+	wg     sync.WaitGroup
+	active atomic.Int64
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+func (f *InFlight) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		f.wg.Add(1)
+		f.active.Add(1)
+		defer func() {
+			f.active.Add(-1)
+			f.wg.Done()
+		}()
+		return next(c)
+	}
+}
+
+func (f *InFlight) Active() int64 {
+	return f.active.Load()
+}
+
+// Wait blocks until all tracked requests have returned or ctx is done, and
+// reports whether they all returned.
+func (f *InFlight) Wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
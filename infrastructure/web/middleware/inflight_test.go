@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestInFlightWaitsForTransfers(t *testing.T) {
+	inFlight := NewInFlight()
+	started, release := make(chan struct{}), make(chan struct{})
+	e := echo.New()
+	e.GET("/download", func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.NoContent(http.StatusOK)
+	}, inFlight.Middleware)
+
+	served := make(chan struct{})
+	go func() {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/download", nil))
+		close(served)
+	}()
+	<-started
+	if inFlight.Active() != 1 {
+		t.Fatalf("%d active transfers, want 1", inFlight.Active())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if inFlight.Wait(ctx) {
+		t.Fatal("wait returned during a transfer")
+	}
+
+	close(release)
+	<-served
+	if !inFlight.Wait(context.Background()) || inFlight.Active() != 0 {
+		t.Fatalf("%d active transfers after they returned", inFlight.Active())
+	}
+}
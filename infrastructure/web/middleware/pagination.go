@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
 )
 
 func Pagination(next echo.HandlerFunc) echo.HandlerFunc {
@@ -16,8 +17,12 @@ func Pagination(next echo.HandlerFunc) echo.HandlerFunc {
 		// Parse and validate the "page" query parameter
 		if p := c.QueryParam("page"); p != "" {
 			if val, err := strconv.Atoi(p); err != nil {
-				// Log or handle invalid "page" parameter
-				return echo.NewHTTPError(400, "Invalid 'page' parameter")
+				return domain.ErrInvalidRequest.WithFields(domain.FieldError{
+					Field:   "page",
+					Code:    "integer",
+					Message: "page must be an integer",
+					Params:  []string{"page"},
+				})
 			} else if val > 0 {
 				page = val
 			}
@@ -26,8 +31,12 @@ func Pagination(next echo.HandlerFunc) echo.HandlerFunc {
 		// Parse and validate the "per_page" query parameter
 		if pp := c.QueryParam("per_page"); pp != "" {
 			if val, err := strconv.Atoi(pp); err != nil {
-				// Log or handle invalid "per_page" parameter
-				return echo.NewHTTPError(400, "Invalid 'per_page' parameter")
+				return domain.ErrInvalidRequest.WithFields(domain.FieldError{
+					Field:   "per_page",
+					Code:    "integer",
+					Message: "per_page must be an integer",
+					Params:  []string{"per_page"},
+				})
 			} else if val > 0 && val <= 100 {
 				perPage = val
 			}
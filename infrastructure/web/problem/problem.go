@@ -0,0 +1,145 @@
+// Package problem renders errors as RFC 7807 application/problem+json
+// documents.
+package problem
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+)
+
+const (
+	ContentType = "application/problem+json"
+	typePrefix  = "urn:archiven:problem:"
+
+	headerAcceptLanguage = "Accept-Language"
+)
+
+// Problem is an RFC 7807 problem document with the extension members code,
+// request_id and errors.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	params []string
+}
+
+var kindStatus = map[domain.ErrorKind]int{
+	domain.KindInvalid:            http.StatusBadRequest,
+	domain.KindNotFound:           http.StatusNotFound,
+	domain.KindConflict:           http.StatusConflict,
+	domain.KindPreconditionFailed: http.StatusPreconditionFailed,
+	domain.KindUnauthorized:       http.StatusUnauthorized,
+	domain.KindForbidden:          http.StatusForbidden,
+	domain.KindGone:               http.StatusGone,
+	domain.KindTooLarge:           http.StatusRequestEntityTooLarge,
+	domain.KindUnsupported:        http.StatusUnsupportedMediaType,
+	domain.KindUnprocessable:      http.StatusUnprocessableEntity,
+	domain.KindQuotaExceeded:      http.StatusInsufficientStorage,
+}
+
+// New builds the problem for err. Errors that are neither domain errors nor
+// Echo HTTP errors become a generic internal error so that no internals
+// reach the client.
+func New(err error) Problem {
+	if domainErr, ok := domain.AsError(err); ok {
+		status, known := kindStatus[domainErr.Kind]
+		if !known {
+			status = http.StatusInternalServerError
+		}
+		p := newProblem(status, domainErr.Code, domainErr.Message)
+		for _, f := range domainErr.Fields {
+			p.Errors = append(p.Errors, FieldError{Field: f.Field, Code: f.Code, Message: f.Message, params: f.Params})
+		}
+		return p
+	}
+
+	if he, ok := err.(*echo.HTTPError); ok {
+		detail := http.StatusText(he.Code)
+		if message, ok := he.Message.(string); ok && he.Code < http.StatusInternalServerError {
+			detail = message
+		}
+		return newProblem(he.Code, codeForStatus(he.Code), detail)
+	}
+
+	return newProblem(http.StatusInternalServerError, "internal_error", "internal server error")
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   typePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// codeForStatus derives a code such as "method_not_allowed" from a status.
+func codeForStatus(status int) string {
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// Localize translates the detail and field messages of p, keeping the English
+// text where the catalog has no entry.
+func (p *Problem) Localize(l *i18n.Localizer) {
+	p.Detail = l.Message(p.Code, p.Detail)
+	for i, f := range p.Errors {
+		p.Errors[i].Message = l.Message("field."+f.Code, f.Message, f.params...)
+	}
+}
+
+// Write sends err to the client as a problem document in the language the
+// client accepts.
+func Write(c echo.Context, catalog *i18n.Catalog, err error) error {
+	p := New(err)
+	localizer := catalog.For(c.Request().Header.Get(headerAcceptLanguage))
+	p.Localize(localizer)
+	p.Instance = c.Request().URL.Path
+	p.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if p.Status >= http.StatusInternalServerError {
+		configs.Logger.Errorw("request failed",
+			"error", err.Error(),
+			"method", c.Request().Method,
+			"uri", c.Request().URL.Path,
+			"request_id", p.RequestID,
+		)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, ContentType)
+	c.Response().Header().Set("Content-Language", localizer.Language())
+	c.Response().Header().Add(echo.HeaderVary, headerAcceptLanguage)
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(p.Status)
+	}
+	return c.JSON(p.Status, p)
+}
+
+// NewHTTPErrorHandler returns the Echo error handler for the whole API.
+func NewHTTPErrorHandler(catalog *i18n.Catalog) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		if writeErr := Write(c, catalog, err); writeErr != nil {
+			configs.Logger.Errorw("failed to write error response", "error", writeErr.Error())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"go.uber.org/zap"
+)
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+// write renders err for a GET of path with the given Accept-Language.
+func write(t *testing.T, err error, acceptLanguage string) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	catalog, catalogErr := i18n.NewCatalog("en")
+	if catalogErr != nil {
+		t.Fatal(catalogErr)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/42", nil)
+	if acceptLanguage != "" {
+		req.Header.Set(headerAcceptLanguage, acceptLanguage)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+	if writeErr := Write(c, catalog, err); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	var p Problem
+	if decodeErr := json.Unmarshal(rec.Body.Bytes(), &p); decodeErr != nil {
+		t.Fatalf("response is not a problem document: %v\n%s", decodeErr, rec.Body)
+	}
+	return rec, p
+}
+
+func TestWriteMapsDomainErrors(t *testing.T) {
+	for _, test := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{domain.ErrFileNotFound, http.StatusNotFound, "file_not_found"},
+		{errors.Wrap(domain.ErrFileModified, "failed to update"), http.StatusPreconditionFailed, "file_modified"},
+		{domain.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+		{domain.ErrQuotaExceeded, http.StatusInsufficientStorage, "storage_quota_exceeded"},
+		{echo.NewHTTPError(http.StatusMethodNotAllowed, "method not allowed"), http.StatusMethodNotAllowed, "method_not_allowed"},
+		{fmt.Errorf("dial tcp: connection refused"), http.StatusInternalServerError, "internal_error"},
+	} {
+		rec, p := write(t, test.err, "")
+		if rec.Code != test.status || p.Status != test.status || p.Code != test.code {
+			t.Errorf("%v: got %d %+v, want %d %s", test.err, rec.Code, p, test.status, test.code)
+		}
+		if got := rec.Header().Get(echo.HeaderContentType); got != ContentType {
+			t.Errorf("%v: content type %q", test.err, got)
+		}
+		if p.Type != typePrefix+test.code || p.Instance != "/api/v1/files/42" || p.RequestID != "req-1" {
+			t.Errorf("%v: got type %q, instance %q, request ID %q", test.err, p.Type, p.Instance, p.RequestID)
+		}
+	}
+}
+
+func TestWriteHidesInternalErrors(t *testing.T) {
+	_, p := write(t, errors.New("mongo: password=hunter2 rejected"), "")
+	if p.Detail != "internal server error" {
+		t.Fatalf("internal detail leaked: %q", p.Detail)
+	}
+}
+
+func TestWriteLocalizesFieldErrors(t *testing.T) {
+	defer func(previous int) { domain.MaxFileSize = previous }(domain.MaxFileSize)
+	domain.MaxFileSize = 1024
+	err := domain.ValidateFileSize(2048)
+
+	rec, p := write(t, err, "id-ID,id;q=0.9")
+	if rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get("Content-Language") != "id" {
+		t.Fatalf("got %d in %q", rec.Code, rec.Header().Get("Content-Language"))
+	}
+	if p.Detail != "berkas terlalu besar" {
+		t.Errorf("detail %q", p.Detail)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "file" || p.Errors[0].Code != "max_size" ||
+		p.Errors[0].Message != "ukuran berkas melebihi batas maksimum: 1.0 KiB" {
+		t.Errorf("field errors %+v", p.Errors)
+	}
+}
+
+func TestWriteLocalizesQuotaErrors(t *testing.T) {
+	defer func(previous int64) { domain.StorageQuota = previous }(domain.StorageQuota)
+	domain.StorageQuota = 1024
+	err := domain.ValidateQuota(1000, 100)
+
+	for _, test := range []struct {
+		acceptLanguage, detail, message string
+	}{
+		{"en", "storage quota exceeded", "storage quota of 1.0 KiB reached, 24 B left"},
+		{"id", "kuota penyimpanan terlampaui", "kuota penyimpanan 1.0 KiB telah tercapai, tersisa 24 B"},
+	} {
+		rec, p := write(t, err, test.acceptLanguage)
+		if rec.Code != http.StatusInsufficientStorage || p.Detail != test.detail {
+			t.Errorf("%s: got %d %q", test.acceptLanguage, rec.Code, p.Detail)
+		}
+		if len(p.Errors) != 1 || p.Errors[0].Code != "quota" || p.Errors[0].Message != test.message {
+			t.Errorf("%s: field errors %+v", test.acceptLanguage, p.Errors)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+type AuditEntryResponse struct {
+	Sequence     int64                 `json:"seq"`
+	Actor        string                `json:"actor"`
+	Action       string                `json:"action"`
+	FileID       string                `json:"file_id,omitempty"`
+	CollectionID string                `json:"collection_id,omitempty"`
+	IP           string                `json:"ip"`
+	Timestamp    string                `json:"timestamp"`
+	Outcome      string                `json:"outcome"`
+	Status       int                   `json:"status"`
+	Changes      []AuditChangeResponse `json:"changes,omitempty"`
+	PrevHash     string                `json:"prev_hash"`
+	Hash         string                `json:"hash"`
+}
+
+type AuditChangeResponse struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+func BuildAuditEntriesResponse(entries []*domain.AuditEntry) []AuditEntryResponse {
+	response := make([]AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = AuditEntryResponse{
+			Sequence:     entry.Sequence,
+			Actor:        entry.Actor,
+			Action:       string(entry.Action),
+			FileID:       entry.FileID,
+			CollectionID: entry.CollectionID,
+			IP:           entry.IP,
+			Timestamp:    entry.Timestamp.Format(time.RFC3339Nano),
+			Outcome:      string(entry.Outcome),
+			Status:       entry.Status,
+			PrevHash:     entry.PrevHash,
+			Hash:         entry.Hash,
+		}
+		for _, change := range entry.Changes {
+			response[i].Changes = append(response[i].Changes, AuditChangeResponse{
+				Field: change.Field,
+				From:  change.From,
+				To:    change.To,
+			})
+		}
+	}
+	return response
+}
@@ -0,0 +1,50 @@
+package responses
+
+import (
+	"net/http"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/problem"
+)
+
+type BatchUploadResponse struct {
+	Total     int                 `json:"total"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BatchUploadResult `json:"results"`
+}
+
+// BatchUploadResult carries either the stored file or a problem document
+// explaining why the file was rejected.
+type BatchUploadResult struct {
+	Name   string           `json:"name"`
+	Status int              `json:"status"`
+	File   *FileResponse    `json:"file,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+func BuildBatchUploadResponse(results []usecases.BatchUploadResult, l *links.Links, localizer *i18n.Localizer) BatchUploadResponse {
+	response := BatchUploadResponse{
+		Total:   len(results),
+		Results: make([]BatchUploadResult, len(results)),
+	}
+	for i, result := range results {
+		item := BatchUploadResult{Name: result.Name}
+		if result.Err != nil {
+			p := problem.New(result.Err)
+			p.Localize(localizer)
+			item.Error = &p
+			item.Status = p.Status
+			response.Failed++
+		} else {
+			file := BuildFileResponse(result.File, l)
+			item.File = &file
+			item.Status = http.StatusCreated
+			response.Succeeded++
+		}
+		response.Results[i] = item
+	}
+	return response
+}
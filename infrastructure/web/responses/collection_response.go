@@ -0,0 +1,65 @@
+package responses
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type BreadcrumbResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CollectionResponse struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	ParentID  string               `json:"parent_id,omitempty"`
+	Path      []BreadcrumbResponse `json:"path"`
+	CreatedAt string               `json:"created_at"`
+	UpdatedAt string               `json:"updated_at"`
+	Links     links.Set            `json:"_links"`
+}
+
+func BuildCollectionResponse(collection *domain.Collection, l *links.Links) CollectionResponse {
+	response := CollectionResponse{
+		ID:        collection.ID,
+		Name:      collection.Name,
+		ParentID:  collection.ParentID,
+		Path:      BuildBreadcrumbsResponse(collection.Path),
+		CreatedAt: collection.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: collection.UpdatedAt.Format(time.RFC3339),
+		Links:     links.Set{}.Add("self", l, links.RouteCollection, collection.ID),
+	}
+	if collection.ParentID != "" {
+		response.Links.Add("parent", l, links.RouteCollection, collection.ParentID)
+	}
+	if href := l.URLWithQuery(links.RouteCollections, url.Values{"parent_id": {collection.ID}}); href != "" {
+		response.Links["children"] = href
+	}
+	if href := l.URLWithQuery(links.RouteFiles, url.Values{"collection_id": {collection.ID}}); href != "" {
+		response.Links["files"] = href
+	}
+	return response
+}
+
+func BuildCollectionsResponse(collections []*domain.Collection, l *links.Links) []CollectionResponse {
+	response := make([]CollectionResponse, len(collections))
+	for i, collection := range collections {
+		response[i] = BuildCollectionResponse(collection, l)
+	}
+	return response
+}
+
+func BuildBreadcrumbsResponse(path []domain.Breadcrumb) []BreadcrumbResponse {
+	if path == nil {
+		return nil
+	}
+	response := make([]BreadcrumbResponse, len(path))
+	for i, step := range path {
+		response[i] = BreadcrumbResponse{ID: step.ID, Name: step.Name}
+	}
+	return response
+}
@@ -0,0 +1,44 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type ExportResponse struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Files      int       `json:"files"`
+	Missing    []string  `json:"missing,omitempty"`
+	Size       int64     `json:"size"`
+	Error      string    `json:"error,omitempty"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  string    `json:"created_at"`
+	FinishedAt string    `json:"finished_at,omitempty"`
+	ExpiresAt  string    `json:"expires_at"`
+	Links      links.Set `json:"_links"`
+}
+
+func BuildExportResponse(export *domain.Export, l *links.Links) ExportResponse {
+	response := ExportResponse{
+		ID:        export.ID,
+		Status:    string(export.Status),
+		Files:     export.Files,
+		Missing:   export.Missing,
+		Size:      export.Size,
+		Error:     export.Error,
+		CreatedBy: export.CreatedBy,
+		CreatedAt: export.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: export.ExpiresAt.Format(time.RFC3339),
+		Links:     links.Set{}.Add("self", l, links.RouteExport, export.ID),
+	}
+	if !export.FinishedAt.IsZero() {
+		response.FinishedAt = export.FinishedAt.Format(time.RFC3339)
+	}
+	if export.Status == domain.ExportCompleted {
+		response.Links.Add("download", l, links.RouteExportDownload, export.ID)
+	}
+	return response
+}
@@ -0,0 +1,77 @@
+package responses
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type CursorPageResponse struct {
+	Data       []FileResponse   `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+	Links      links.Set        `json:"_links"`
+}
+
+type CursorPagination struct {
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// BuildCursorPageResponse builds a cursor page; scope holds the query
+// parameters that narrow the listing, which every link keeps.
+func BuildCursorPageResponse(result *usecases.PaginatedFiles, includeTotal bool, scope url.Values, l *links.Links) CursorPageResponse {
+	response := CursorPageResponse{
+		Data: BuildFilesResponse(result.Files, l),
+		Pagination: CursorPagination{
+			PerPage:    result.PerPage,
+			NextCursor: result.NextCursor,
+			PrevCursor: result.PrevCursor,
+		},
+		Links: links.Set{},
+	}
+	if includeTotal {
+		response.Pagination.Total = &result.Total
+	}
+
+	query := func(cursor string) url.Values {
+		q := withScope(scope, url.Values{"cursor": {cursor}, "per_page": {strconv.Itoa(result.PerPage)}})
+		if includeTotal {
+			q.Set("include_total", "true")
+		}
+		return q
+	}
+	response.Links["first"] = l.URLWithQuery(links.RouteFiles, query(""))
+	if result.NextCursor != "" {
+		response.Links["next"] = l.URLWithQuery(links.RouteFiles, query(result.NextCursor))
+	}
+	if result.PrevCursor != "" {
+		response.Links["prev"] = l.URLWithQuery(links.RouteFiles, query(result.PrevCursor))
+	}
+	return response
+}
+
+// PageLinks returns the first, prev and next links of a numbered page.
+func PageLinks(result *usecases.PaginatedFiles, scope url.Values, l *links.Links) links.Set {
+	page := func(n int) url.Values {
+		return withScope(scope, url.Values{"page": {strconv.Itoa(n)}, "per_page": {strconv.Itoa(result.PerPage)}})
+	}
+	set := links.Set{"first": l.URLWithQuery(links.RouteFiles, page(1))}
+	if result.Page > 1 {
+		set["prev"] = l.URLWithQuery(links.RouteFiles, page(result.Page-1))
+	}
+	if int64(result.Page) < result.TotalPages {
+		set["next"] = l.URLWithQuery(links.RouteFiles, page(result.Page+1))
+	}
+	return set
+}
+
+func withScope(scope, query url.Values) url.Values {
+	for key, values := range scope {
+		query[key] = values
+	}
+	return query
+}
@@ -1,28 +1,66 @@
 package responses
 
 import (
-	"github.com/labstack/echo/v4"
+	"time"
+
 	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
 )
 
 type FileResponse struct {
-	Name        string `json:"name"`
-	Size        int64  `json:"size"`
-	ContentType string `json:"content_type"`
-	UploadDate  string `json:"upload_date"`
-	DownloadURL string `json:"download_url"`
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Size         int64                `json:"size"`
+	ContentType  string               `json:"content_type"`
+	UploadDate   string               `json:"upload_date"`
+	Description  string               `json:"description,omitempty"`
+	Tags         []string             `json:"tags,omitempty"`
+	Category     string               `json:"category,omitempty"`
+	Version      int64                `json:"version"`
+	CollectionID string               `json:"collection_id,omitempty"`
+	Path         []BreadcrumbResponse `json:"path,omitempty"`
+	SHA256       string               `json:"sha256,omitempty"`
+	DownloadURL  string               `json:"download_url"`
+	Links        links.Set            `json:"_links"`
+}
+
+func BuildFileResponse(file *domain.File, l *links.Links) FileResponse {
+	response := FileResponse{
+		ID:           file.ID,
+		Name:         file.Name,
+		Size:         file.Size,
+		ContentType:  file.ContentType,
+		UploadDate:   file.UploadDate.Format(time.RFC3339),
+		Description:  file.Description,
+		Tags:         file.Tags,
+		Category:     file.Category,
+		Version:      file.Version,
+		CollectionID: file.CollectionID,
+		Path:         BuildBreadcrumbsResponse(file.Path),
+		SHA256:       file.SHA256,
+		DownloadURL:  l.URL(links.RouteFileDownload, file.ID),
+		Links:        FileLinks(file.ID, l),
+	}
+	if file.CollectionID != "" {
+		response.Links.Add("collection", l, links.RouteCollection, file.CollectionID)
+	}
+	return response
 }
 
-func BuildFilesResponse(files []*domain.File, c echo.Context) []FileResponse {
+func BuildFilesResponse(files []*domain.File, l *links.Links) []FileResponse {
 	response := make([]FileResponse, len(files))
 	for i, file := range files {
-		response[i] = FileResponse{
-			Name:        file.Name,
-			Size:        file.Size,
-			ContentType: file.ContentType,
-			UploadDate:  file.UploadDate.Format("2006-01-02"),
-			DownloadURL: c.Scheme() + "://" + c.Request().Host + "/files/" + file.ID,
-		}
+		response[i] = BuildFileResponse(file, l)
 	}
 	return response
 }
+
+// FileLinks returns the links of a file resource.
+func FileLinks(id string, l *links.Links) links.Set {
+	return links.Set{}.
+		Add("self", l, links.RouteFile, id).
+		Add("download", l, links.RouteFileDownload, id).
+		Add("shares", l, links.RouteFileShares, id).
+		Add("thumbnail", l, links.RouteFileThumbnail, id).
+		Add("versions", l, links.RouteFileVersions, id)
+}
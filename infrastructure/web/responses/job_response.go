@@ -0,0 +1,61 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type JobResponse struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+	Status      string                 `json:"status"`
+	Attempts    int                    `json:"attempts"`
+	MaxAttempts int                    `json:"max_attempts"`
+	RunAt       string                 `json:"run_at,omitempty"`
+	LockedBy    string                 `json:"locked_by,omitempty"`
+	LockedUntil string                 `json:"locked_until,omitempty"`
+	LastError   string                 `json:"last_error,omitempty"`
+	CreatedAt   string                 `json:"created_at"`
+	UpdatedAt   string                 `json:"updated_at"`
+	FinishedAt  string                 `json:"finished_at,omitempty"`
+	Links       links.Set              `json:"_links"`
+}
+
+func BuildJobResponse(job *domain.Job, l *links.Links) JobResponse {
+	response := JobResponse{
+		ID:          job.ID,
+		Type:        job.Type,
+		Payload:     job.Payload,
+		Status:      string(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError,
+		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   job.UpdatedAt.Format(time.RFC3339),
+		Links:       links.Set{}.Add("self", l, links.RouteJob, job.ID),
+	}
+	switch job.Status {
+	case domain.JobPending:
+		response.RunAt = job.RunAt.Format(time.RFC3339)
+	case domain.JobRunning:
+		response.LockedBy = job.LockedBy
+		response.LockedUntil = job.LockedUntil.Format(time.RFC3339)
+	case domain.JobDead:
+		response.Links.Add("retry", l, links.RouteJobRetry, job.ID)
+	}
+	if !job.FinishedAt.IsZero() {
+		response.FinishedAt = job.FinishedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+func BuildJobsResponse(jobs []*domain.Job, l *links.Links) []JobResponse {
+	response := make([]JobResponse, len(jobs))
+	for i, job := range jobs {
+		response[i] = BuildJobResponse(job, l)
+	}
+	return response
+}
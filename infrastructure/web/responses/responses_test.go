@@ -0,0 +1,126 @@
+package responses
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+	"go.uber.org/zap"
+)
+
+const base = "https://arsip.example.go.id"
+
+func init() {
+	configs.Logger = zap.NewNop().Sugar()
+}
+
+// newLinks resolves links against a router with the file and collection
+// routes only.
+func newLinks() *links.Links {
+	e := echo.New()
+	noop := func(c echo.Context) error { return nil }
+	e.GET("/api/v1/files", noop).Name = links.RouteFiles
+	e.GET("/api/v1/files/:id", noop).Name = links.RouteFile
+	e.GET("/api/v1/files/:id/download", noop).Name = links.RouteFileDownload
+	e.GET("/api/v1/collections/:id", noop).Name = links.RouteCollection
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files", nil)
+	return links.NewBuilder(e, base, false).For(e.NewContext(req, httptest.NewRecorder()))
+}
+
+func TestBuildFileResponseLinks(t *testing.T) {
+	l := newLinks()
+	file := &domain.File{ID: "f001", Name: "report.pdf", UploadDate: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	response := BuildFileResponse(file, l)
+	want := links.Set{
+		"self":     base + "/api/v1/files/f001",
+		"download": base + "/api/v1/files/f001/download",
+	}
+	if len(response.Links) != len(want) || response.Links["self"] != want["self"] || response.Links["download"] != want["download"] {
+		t.Errorf("links: %v", response.Links)
+	}
+	if response.DownloadURL != want["download"] || response.UploadDate != "2024-03-01T12:00:00Z" {
+		t.Errorf("response: %+v", response)
+	}
+
+	file.CollectionID = "c001"
+	if got := BuildFileResponse(file, l).Links["collection"]; got != base+"/api/v1/collections/c001" {
+		t.Errorf("collection link %q", got)
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	l := newLinks()
+	scope := url.Values{"collection_id": {"c001"}}
+	for _, tc := range []struct {
+		page       int
+		prev, next bool
+	}{
+		{page: 1, next: true},
+		{page: 2, prev: true, next: true},
+		{page: 3, prev: true},
+	} {
+		set := PageLinks(&usecases.PaginatedFiles{Page: tc.page, PerPage: 20, TotalPages: 3}, scope, l)
+		if set["first"] != base+"/api/v1/files?collection_id=c001&page=1&per_page=20" {
+			t.Errorf("page %d: first %q", tc.page, set["first"])
+		}
+		_, prev := set["prev"]
+		_, next := set["next"]
+		if prev != tc.prev || next != tc.next {
+			t.Errorf("page %d: links %v", tc.page, set)
+		}
+	}
+}
+
+func TestBuildCursorPageResponseKeepsScope(t *testing.T) {
+	result := &usecases.PaginatedFiles{PerPage: 20, NextCursor: "n1", Total: 42}
+	response := BuildCursorPageResponse(result, true, url.Values{"recursive": {"true"}}, newLinks())
+
+	if want := base + "/api/v1/files?cursor=n1&include_total=true&per_page=20&recursive=true"; response.Links["next"] != want {
+		t.Errorf("next %q, want %q", response.Links["next"], want)
+	}
+	if _, ok := response.Links["prev"]; ok {
+		t.Errorf("prev link on the first page: %v", response.Links)
+	}
+	if response.Pagination.Total == nil || *response.Pagination.Total != 42 {
+		t.Errorf("pagination: %+v", response.Pagination)
+	}
+}
+
+func TestBuildBatchUploadResponseReportsProblems(t *testing.T) {
+	catalog, err := i18n.NewCatalog("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []usecases.BatchUploadResult{
+		{Name: "scan.pdf", File: &domain.File{ID: "f001", Name: "scan.pdf"}},
+		{Name: "notes.txt", Err: domain.ErrFileType},
+		{Name: "broken.pdf", Err: errors.New("unexpected EOF")},
+	}
+
+	response := BuildBatchUploadResponse(results, newLinks(), catalog.For("id"))
+	if response.Total != 3 || response.Succeeded != 1 || response.Failed != 2 {
+		t.Fatalf("counts: %+v", response)
+	}
+	stored, rejected, failed := response.Results[0], response.Results[1], response.Results[2]
+	if stored.Status != http.StatusCreated || stored.File == nil || stored.File.Links["self"] != base+"/api/v1/files/f001" || stored.Error != nil {
+		t.Errorf("stored: %+v", stored)
+	}
+	if rejected.Status != http.StatusUnsupportedMediaType || rejected.Error == nil ||
+		rejected.Error.Code != "file_type_not_allowed" || rejected.Error.Detail != "jenis berkas tidak diizinkan" {
+		t.Errorf("rejected: %+v", rejected.Error)
+	}
+	// Internal errors do not leak their details
+	if failed.Status != http.StatusInternalServerError || failed.Error.Code != "internal_error" || failed.Error.Detail == "unexpected EOF" {
+		t.Errorf("failed: %+v", failed.Error)
+	}
+}
@@ -0,0 +1,48 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type ShareLinkResponse struct {
+	ID                string    `json:"id"`
+	FileID            string    `json:"file_id"`
+	URL               string    `json:"url,omitempty"`
+	CreatedBy         string    `json:"created_by"`
+	CreatedAt         string    `json:"created_at"`
+	ExpiresAt         string    `json:"expires_at"`
+	MaxDownloads      int64     `json:"max_downloads"`
+	Downloads         int64     `json:"downloads"`
+	PasswordProtected bool      `json:"password_protected"`
+	Revoked           bool      `json:"revoked"`
+	Links             links.Set `json:"_links"`
+}
+
+func BuildShareLinkResponse(link *domain.ShareLink, url string, l *links.Links) ShareLinkResponse {
+	return ShareLinkResponse{
+		ID:                link.ID,
+		FileID:            link.FileID,
+		URL:               url,
+		CreatedBy:         link.CreatedBy,
+		CreatedAt:         link.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:         link.ExpiresAt.Format(time.RFC3339),
+		MaxDownloads:      link.MaxDownloads,
+		Downloads:         link.Downloads,
+		PasswordProtected: link.PasswordProtected(),
+		Revoked:           link.Revoked,
+		Links: links.Set{}.
+			Add("self", l, links.RouteShare, link.ID).
+			Add("file", l, links.RouteFile, link.FileID),
+	}
+}
+
+func BuildShareLinksResponse(shareLinks []*domain.ShareLink, l *links.Links) []ShareLinkResponse {
+	response := make([]ShareLinkResponse, len(shareLinks))
+	for i, link := range shareLinks {
+		response[i] = BuildShareLinkResponse(link, "", l)
+	}
+	return response
+}
@@ -0,0 +1,100 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
+)
+
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt string    `json:"created_at"`
+	Links     links.Set `json:"_links"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string    `json:"id"`
+	WebhookID      string    `json:"webhook_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	RedeliveryOf   string    `json:"redelivery_of,omitempty"`
+	Payload        string    `json:"payload"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  string    `json:"next_attempt_at,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	CreatedAt      string    `json:"created_at"`
+	UpdatedAt      string    `json:"updated_at"`
+	Links          links.Set `json:"_links"`
+}
+
+// BuildWebhookResponse renders a webhook; the signing secret is only included
+// when withSecret is set, i.e. right after registration.
+func BuildWebhookResponse(webhook *domain.Webhook, withSecret bool, l *links.Links) WebhookResponse {
+	events := make([]string, len(webhook.Events))
+	for i, e := range webhook.Events {
+		events[i] = string(e)
+	}
+	response := WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    events,
+		Active:    webhook.Active,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt.Format(time.RFC3339),
+		Links: links.Set{}.
+			Add("self", l, links.RouteWebhook, webhook.ID).
+			Add("deliveries", l, links.RouteDeliveries, webhook.ID),
+	}
+	if withSecret {
+		response.Secret = webhook.Secret
+	}
+	return response
+}
+
+func BuildWebhooksResponse(webhooks []*domain.Webhook, l *links.Links) []WebhookResponse {
+	response := make([]WebhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		response[i] = BuildWebhookResponse(webhook, false, l)
+	}
+	return response
+}
+
+func BuildWebhookDeliveryResponse(delivery *domain.WebhookDelivery, l *links.Links) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		RedeliveryOf:   delivery.RedeliveryOf,
+		Payload:        string(delivery.Payload),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		ResponseStatus: delivery.ResponseStatus,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      delivery.UpdatedAt.Format(time.RFC3339),
+		Links: links.Set{}.
+			Add("webhook", l, links.RouteWebhook, delivery.WebhookID).
+			Add("redeliver", l, links.RouteRedeliver, delivery.WebhookID, delivery.ID),
+	}
+	if delivery.Status == domain.DeliveryPending {
+		response.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+func BuildWebhookDeliveriesResponse(deliveries []*domain.WebhookDelivery, l *links.Links) []WebhookDeliveryResponse {
+	response := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = BuildWebhookDeliveryResponse(delivery, l)
+	}
+	return response
+}
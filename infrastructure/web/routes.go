@@ -1,30 +1,265 @@
 package web
 
 import (
+	"context"
+	"crypto/rand"
+	"os"
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
 	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/eventbus"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/health"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/jobs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/maintenance"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/apidocs"
 	handlers "github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/handler"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/links"
 	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/middleware"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/webhook"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupRoutes(e *echo.Echo, db *mongo.Database) { // Repository initialization
-	fileRepo := infrastructure.NewMongoFileRepository(db)
+func SetupRoutes(e *echo.Echo, db *mongo.Database, cfg *configs.Config, catalog *i18n.Catalog, lifecycle *Lifecycle) error {
+	// Upload policy
+	domain.MaxFileSize = int(cfg.Upload.MaxFileSize)
+	domain.AllowedMimeTypes = cfg.Upload.AllowedMimeTypes
+	domain.StorageQuota = int64(cfg.Upload.StorageQuota)
+
+	// Repository initialization
+	fileRepo := infrastructure.NewMongoFileRepository(db, cfg.Storage)
+	exportRepo := infrastructure.NewMongoExportRepository(db, cfg.Export.Bucket)
+	if err := fileRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare file listing", "error", err.Error())
+	}
+	collectionRepo := infrastructure.NewMongoCollectionRepository(db)
+	if err := collectionRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare collections", "error", err.Error())
+	}
+	auditRepo := infrastructure.NewMongoAuditRepository(db)
+	if err := auditRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare audit log", "error", err.Error())
+	}
+
+	shareRepo := infrastructure.NewMongoShareLinkRepository(db)
+	secret, err := shareLinkSecret(cfg.Share)
+	if err != nil {
+		return err
+	}
+	shareSigner := usecases.NewShareLinkSigner(secret)
+	webhookRepo := infrastructure.NewMongoWebhookRepository(db)
+	webhookPolicy, err := webhook.NewAddressPolicy(cfg.Webhooks.AllowedNetworks)
+	if err != nil {
+		return err
+	}
+	deliveryRepo := infrastructure.NewMongoWebhookDeliveryRepository(db)
+	if err := deliveryRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare webhook deliveries", "error", err.Error())
+	}
+	idempotencyRepo := infrastructure.NewMongoIdempotencyRepository(db)
+	if err := idempotencyRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare idempotency keys", "error", err.Error())
+	}
+	jobRepo := infrastructure.NewMongoJobRepository(db)
+	if err := jobRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare job queue", "error", err.Error())
+	}
+	outboxRepo := infrastructure.NewMongoOutboxRepository(db)
+	if err := outboxRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare outbox", "error", err.Error())
+	}
+	userRepo := infrastructure.NewMongoUserRepository(db)
+	if err := userRepo.EnsureIndexes(context.Background()); err != nil {
+		configs.Logger.Errorw("failed to prepare users", "error", err.Error())
+	}
+	transactor := infrastructure.NewMongoTransactor(db)
+
+	// Events
+	bus := eventbus.NewBus()
+	broker := eventbus.NewMemoryBroker()
+	enqueueWebhooksUC := usecases.NewEnqueueWebhookDeliveriesUseCase(webhookRepo, deliveryRepo)
+	enqueueJobUC := usecases.NewEnqueueJobUseCase(jobRepo, cfg.Jobs.MaxAttempts)
+	dispatchUC := usecases.NewDispatchEventsUseCase(outboxRepo,
+		bus,
+		webhook.NewSink(enqueueWebhooksUC),
+		jobs.NewSink(usecases.NewScheduleFileJobsUseCase(enqueueJobUC)),
+		eventbus.NewBrokerSink(broker, "archive."),
+	)
+	recoverEventsUC := usecases.NewRecoverStagedEventsUseCase(outboxRepo, fileRepo, cfg.Storage.StaleUploadAfter)
+	watchChangesUC := usecases.NewWatchChangesUseCase(infrastructure.NewMongoChangeFeed(db, cfg.Storage.Bucket))
 
 	// Use cases initialization
-	uploadUC := usecases.NewUploadFileUseCase(fileRepo)
-	getFileUC := usecases.NewGetFileUseCase(fileRepo)
-	getAllUC := usecases.NewGetAllFilesUseCase(fileRepo)
+	uploadUC := usecases.NewUploadFileUseCase(fileRepo, collectionRepo, outboxRepo)
+	batchUploadUC := usecases.NewBatchUploadUseCase(uploadUC, cfg.Upload.BatchMaxFiles, cfg.Upload.BatchConcurrency)
+	exportArchiveUC := usecases.NewExportArchiveUseCase(fileRepo, collectionRepo)
+	startExportUC := usecases.NewStartExportUseCase(exportRepo, exportArchiveUC, cfg.Export.Retention, lifecycle.Go)
+	getExportUC := usecases.NewGetExportUseCase(exportRepo)
+	downloadExportUC := usecases.NewDownloadExportUseCase(getExportUC, exportRepo)
+	cleanupExportsUC := usecases.NewCleanupExportsUseCase(exportRepo)
+	getFileUC := usecases.NewGetFileUseCase(fileRepo, collectionRepo)
+	getAllUC := usecases.NewGetAllFilesUseCase(fileRepo, collectionRepo)
+	deleteUC := usecases.NewDeleteFileUseCase(fileRepo, outboxRepo)
+	moveFileUC := usecases.NewMoveFileUseCase(fileRepo, collectionRepo, outboxRepo, transactor)
+	updateFileUC := usecases.NewUpdateFileUseCase(fileRepo, collectionRepo, outboxRepo)
+	createCollectionUC := usecases.NewCreateCollectionUseCase(collectionRepo, transactor)
+	getCollectionsUC := usecases.NewGetCollectionsUseCase(collectionRepo)
+	updateCollectionUC := usecases.NewUpdateCollectionUseCase(collectionRepo, transactor)
+	deleteCollectionUC := usecases.NewDeleteCollectionUseCase(collectionRepo, fileRepo, transactor)
+	recordAuditUC := usecases.NewRecordAuditUseCase(auditRepo)
+	idempotencyUC := usecases.NewIdempotencyUseCase(idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
+	getAuditLogUC := usecases.NewGetAuditLogUseCase(auditRepo)
+	verifyAuditUC := usecases.NewVerifyAuditLogUseCase(auditRepo)
+	createShareUC := usecases.NewCreateShareLinkUseCase(fileRepo, shareRepo, shareSigner)
+	resolveShareUC := usecases.NewResolveShareLinkUseCase(shareRepo, fileRepo, shareSigner)
+	revokeShareUC := usecases.NewRevokeShareLinkUseCase(shareRepo)
+	getSharesUC := usecases.NewGetShareLinksUseCase(shareRepo)
+	registerWebhookUC := usecases.NewRegisterWebhookUseCase(webhookRepo, webhookPolicy)
+	getWebhooksUC := usecases.NewGetWebhooksUseCase(webhookRepo)
+	deleteWebhookUC := usecases.NewDeleteWebhookUseCase(webhookRepo)
+	getDeliveriesUC := usecases.NewGetWebhookDeliveriesUseCase(webhookRepo, deliveryRepo)
+	redeliverUC := usecases.NewRedeliverWebhookUseCase(deliveryRepo)
+	deliverWebhooksUC := usecases.NewDeliverWebhooksUseCase(webhookRepo, deliveryRepo, webhook.NewHTTPSender(10*time.Second, webhookPolicy))
+	processJobUC := usecases.NewProcessJobUseCase(jobRepo, cfg.Jobs.Lease, cfg.Jobs.Retention,
+		usecases.NewComputeChecksumUseCase(fileRepo).Handler(),
+	)
+	getJobsUC := usecases.NewGetJobsUseCase(jobRepo)
+	retryJobUC := usecases.NewRetryJobUseCase(jobRepo)
+	authenticateUC := usecases.NewAuthenticateUserUseCase(userRepo)
+	cleanupStorageUC := usecases.NewCleanupStorageUseCase(infrastructure.NewMongoStorageInspector(db, cfg.Storage.Bucket), cfg.Storage.StaleUploadAfter)
+
+	// Background workers
+	lifecycle.Go(eventbus.NewDispatcher(dispatchUC, recoverEventsUC, time.Second).Run)
+	lifecycle.Go(webhook.NewWorker(deliverWebhooksUC, 5*time.Second).Run)
+	lifecycle.Go(maintenance.NewExportCleanupWorker(cleanupExportsUC, time.Hour).Run)
+	lifecycle.Go(maintenance.NewStorageWorker(cleanupStorageUC, cfg.Storage.CheckInterval, usecases.CleanupStorageCommand{
+		DeleteOrphans: cfg.Storage.DeleteOrphans,
+	}).Run)
+	lifecycle.Go(jobs.NewWorkerPool(processJobUC, cfg.Jobs.Concurrency, cfg.Jobs.PollInterval).Run)
 
 	// Handlers initialization
-	fileHandlers := handlers.NewFileHandlers(uploadUC, getFileUC, getAllUC)
+	linkBuilder := links.NewBuilder(e, cfg.Server.PublicURL, cfg.Server.TrustProxy)
+	fileHandlers := handlers.NewFileHandlers(uploadUC, getFileUC, getAllUC, deleteUC, moveFileUC, updateFileUC, linkBuilder)
+	collectionHandlers := handlers.NewCollectionHandlers(createCollectionUC, getCollectionsUC, updateCollectionUC, deleteCollectionUC, linkBuilder)
+	batchUploadHandlers := handlers.NewBatchUploadHandlers(batchUploadUC, recordAuditUC, catalog, linkBuilder)
+	exportHandlers := handlers.NewExportHandlers(exportArchiveUC, startExportUC, getExportUC, downloadExportUC, cfg.Export.MaxFiles, linkBuilder)
+	auditHandlers := handlers.NewAuditHandlers(getAuditLogUC, verifyAuditUC)
+	shareHandlers := handlers.NewShareHandlers(createShareUC, getSharesUC, revokeShareUC, resolveShareUC, linkBuilder)
+	eventHandlers := handlers.NewEventHandlers(watchChangesUC, lifecycle.Stopping())
+	jobHandlers := handlers.NewJobHandlers(getJobsUC, retryJobUC, linkBuilder)
+	webhookHandlers := handlers.NewWebhookHandlers(registerWebhookUC, getWebhooksUC, deleteWebhookUC, getDeliveriesUC, redeliverUC, linkBuilder)
+
+	// Health
+	minFree := uint64(cfg.Health.DiskMinFree)
+	readiness := lifecycle.Readiness
+	readiness.Add(
+		health.MongoPing(db.Client()),
+		health.Check{Name: "gridfs", Run: fileRepo.Ping},
+		health.DiskSpace("disk_logs", cfg.Log.Dir, minFree),
+		health.DiskSpace("disk_temp", os.TempDir(), minFree),
+	)
+	if addr := cfg.Health.ScannerAddr; addr != "" {
+		readiness.Add(health.TCPDial("scanner", addr))
+	}
+	healthHandlers := handlers.NewHealthHandlers(readiness)
+	e.GET("/healthz", healthHandlers.Healthz)
+	e.GET("/readyz", healthHandlers.Readyz)
+
+	// Metrics
+	metrics.Registry.MustRegister(metrics.NewArchiveCollector(fileRepo))
+	e.GET("/metrics", metrics.Handler())
+
+	// Users with archive tokens; everyone else is authenticated by the proxy
+	e.Use(middleware.Authenticate(authenticateUC))
 
 	// Register routes
 	ApiV1 := e.Group("/api/v1")
+	idempotent := middleware.Idempotency(idempotencyUC)
+	// Upload bodies are limited before the idempotency middleware reads them
+	uploadLimit := middleware.BodyLimit(int64(cfg.Upload.MaxFileSize) + multipartOverhead)
+	batchLimit := middleware.BodyLimit(int64(cfg.Upload.BatchMaxSize) + multipartOverhead)
 	// Routes
-	ApiV1.POST("/upload", fileHandlers.UploadFile)
-	ApiV1.GET("/files/:id", fileHandlers.GetFileByID)
-	ApiV1.GET("/files", fileHandlers.GetAllFiles, middleware.Pagination)
-	ApiV1.GET("/files/:id/download", fileHandlers.DownloadFile)
+	ApiV1.POST("/upload", fileHandlers.UploadFile, lifecycle.Transfers.Middleware, uploadLimit, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionUpload))
+	ApiV1.POST("/uploads/batch", batchUploadHandlers.BatchUpload, lifecycle.Transfers.Middleware, batchLimit, idempotent)
+	ApiV1.GET("/files/:id", fileHandlers.GetFileByID, middleware.Audit(recordAuditUC, domain.AuditActionRead)).Name = links.RouteFile
+	ApiV1.GET("/files", fileHandlers.GetAllFiles, middleware.Pagination).Name = links.RouteFiles
+	ApiV1.GET("/files/:id/download", fileHandlers.DownloadFile, lifecycle.Transfers.Middleware, middleware.Audit(recordAuditUC, domain.AuditActionDownload)).Name = links.RouteFileDownload
+	ApiV1.DELETE("/files/:id", fileHandlers.DeleteFile, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionDelete))
+	ApiV1.PATCH("/files/:id", fileHandlers.UpdateFile, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionUpdate))
+	ApiV1.PUT("/files/:id/collection", fileHandlers.MoveFile, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionMove))
+
+	// Collections
+	ApiV1.POST("/collections", collectionHandlers.CreateCollection, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionCollectionCreate))
+	ApiV1.GET("/collections", collectionHandlers.GetCollections).Name = links.RouteCollections
+	ApiV1.GET("/collections/:id", collectionHandlers.GetCollection).Name = links.RouteCollection
+	ApiV1.PATCH("/collections/:id", collectionHandlers.UpdateCollection, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionCollectionUpdate))
+	ApiV1.DELETE("/collections/:id", collectionHandlers.DeleteCollection, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionCollectionDelete))
+
+	// Archive exports
+	ApiV1.POST("/files/archive", exportHandlers.CreateArchive, lifecycle.Transfers.Middleware, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionExport))
+	ApiV1.GET("/exports/:id", exportHandlers.GetExport).Name = links.RouteExport
+	ApiV1.GET("/exports/:id/download", exportHandlers.DownloadExport, lifecycle.Transfers.Middleware).Name = links.RouteExportDownload
+
+	// Share links
+	ApiV1.POST("/files/:id/share", shareHandlers.CreateShareLink, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionShare))
+	ApiV1.GET("/files/:id/shares", shareHandlers.GetShareLinks).Name = links.RouteFileShares
+	ApiV1.DELETE("/shares/:share_id", shareHandlers.RevokeShareLink, idempotent, middleware.Audit(recordAuditUC, domain.AuditActionRevoke)).Name = links.RouteShare
+
+	// Public share downloads, outside /api so that the authenticating proxy
+	// can let them through
+	e.GET("/shares/:token", shareHandlers.DownloadShare, lifecycle.Transfers.Middleware, middleware.Audit(recordAuditUC, domain.AuditActionDownload)).Name = links.RouteShareDownload
+	e.POST("/shares/:token", shareHandlers.DownloadShare, lifecycle.Transfers.Middleware, middleware.Audit(recordAuditUC, domain.AuditActionDownload))
+
+	// Live change feed
+	ApiV1.GET("/events", eventHandlers.StreamEvents)
+
+	// Webhooks
+	ApiV1.POST("/webhooks", webhookHandlers.RegisterWebhook, idempotent)
+	ApiV1.GET("/webhooks", webhookHandlers.GetWebhooks)
+	ApiV1.DELETE("/webhooks/:id", webhookHandlers.DeleteWebhook, idempotent).Name = links.RouteWebhook
+	ApiV1.GET("/webhooks/:id/deliveries", webhookHandlers.GetDeliveries, middleware.Pagination).Name = links.RouteDeliveries
+	ApiV1.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandlers.Redeliver, idempotent).Name = links.RouteRedeliver
+
+	// Audit log
+	ApiV1.GET("/audit", auditHandlers.GetAuditLog, middleware.Pagination)
+	ApiV1.GET("/audit/verify", auditHandlers.VerifyAuditLog)
+
+	// Background jobs
+	ApiV1.GET("/admin/jobs", jobHandlers.GetJobs, middleware.Pagination).Name = links.RouteJobs
+	ApiV1.GET("/admin/jobs/:id", jobHandlers.GetJob).Name = links.RouteJob
+	ApiV1.POST("/admin/jobs/:id/retry", jobHandlers.RetryJob, idempotent).Name = links.RouteJobRetry
+
+	// API documentation
+	if err := apidocs.Register(e, cfg.Server.SwaggerUIDir); err != nil {
+		return err
+	}
+	// routes_test.go fails on this; the log covers builds that skipped it
+	if err := apidocs.CheckRoutes(e); err != nil {
+		configs.Logger.Errorw("API documentation is incomplete", "error", err.Error())
+	}
+	return nil
+}
+
+// multipartOverhead allows for the part headers and form fields sent with
+// uploaded files.
+const multipartOverhead = 1 << 20
+
+// shareLinkSecret returns the configured HMAC key for share links. Without one
+// a random key is generated, so links stop working after a restart.
+func shareLinkSecret(cfg configs.ShareConfig) ([]byte, error) {
+	if cfg.Secret != "" {
+		return []byte(cfg.Secret), nil
+	}
+
+	configs.Logger.Warnw("SHARE_LINK_SECRET not set - share links will not survive a restart")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "failed to generate share link secret")
+	}
+	return secret, nil
 }
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/apidocs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// newRouter builds the full router against a database that is never
+// reached; startup steps that need it fail quickly and are only logged.
+func newRouter(t *testing.T, cfg *configs.Config) *echo.Echo {
+	t.Helper()
+	configs.Logger = zap.NewNop().Sugar()
+
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := i18n.NewCatalog(cfg.Server.DefaultLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	lifecycle := NewLifecycle()
+	t.Cleanup(func() {
+		lifecycle.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		lifecycle.WaitForWorkers(ctx)
+	})
+	if err := SetupRoutes(e, client.Database("archive_test"), cfg, catalog, lifecycle); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func testConfig() *configs.Config {
+	cfg := configs.Default()
+	cfg.Mongo.URI = "mongodb://127.0.0.1:1"
+	cfg.Mongo.Database = "archive_test"
+	cfg.Share.Secret = "test"
+	return cfg
+}
+
+func TestRoutesMatchOpenAPISpecification(t *testing.T) {
+	e := newRouter(t, testConfig())
+	if err := apidocs.CheckRoutes(e); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// AddressPolicy keeps webhooks from reaching the service's own network:
+// loopback, link-local, private, multicast and unspecified addresses are
+// refused unless they fall in one of the allowed networks.
+type AddressPolicy struct {
+	allowed  []netip.Prefix
+	resolver *net.Resolver
+}
+
+// NewAddressPolicy returns a policy that additionally accepts the given
+// networks in CIDR notation.
+func NewAddressPolicy(allowedNetworks []string) (*AddressPolicy, error) {
+	policy := &AddressPolicy{resolver: net.DefaultResolver}
+	for _, network := range allowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid allowed webhook network %q", network)
+		}
+		policy.allowed = append(policy.allowed, prefix.Masked())
+	}
+	return policy, nil
+}
+
+// Allows reports whether webhooks may connect to addr.
+func (p *AddressPolicy) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified())
+}
+
+// CheckHost resolves host and rejects it when any of its addresses is
+// refused. Deliveries check again when they connect, as DNS may change.
+func (p *AddressPolicy) CheckHost(ctx context.Context, host string) error {
+	addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return domain.ErrWebhookInvalidURL
+	}
+	for _, addr := range addrs {
+		if !p.Allows(addr) {
+			return domain.ErrWebhookForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// control is a net.Dialer hook refusing connections to addresses the policy
+// does not allow, after name resolution and on every redirect.
+func (p *AddressPolicy) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errors.Wrap(err, "unexpected webhook address")
+	}
+	if !p.Allows(addrPort.Addr()) {
+		return errors.Errorf("webhook address %s is not allowed", addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func TestAddressPolicyRefusesInternalAddresses(t *testing.T) {
+	policy, err := NewAddressPolicy([]string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34":       true,
+		"2606:4700::1111":     true,
+		"10.1.2.3":            true,
+		"10.2.0.1":            false,
+		"127.0.0.1":           false,
+		"::1":                 false,
+		"::ffff:127.0.0.1":    false,
+		"169.254.169.254":     false,
+		"fe80::1":             false,
+		"192.168.1.10":        false,
+		"172.16.0.1":          false,
+		"fd00::1":             false,
+		"0.0.0.0":             false,
+		"224.0.0.1":           false,
+		"::ffff:192.168.1.10": false,
+	} {
+		if got := policy.Allows(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Allows(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestAddressPolicyChecksLiteralHosts(t *testing.T) {
+	policy, _ := NewAddressPolicy(nil)
+	if err := policy.CheckHost(context.Background(), "127.0.0.1"); err != domain.ErrWebhookForbiddenAddress {
+		t.Errorf("loopback host: got %v", err)
+	}
+	if err := policy.CheckHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("public host: got %v", err)
+	}
+	if err := policy.control("tcp4", "169.254.169.254:80", nil); err == nil {
+		t.Error("connection to link-local address allowed")
+	}
+}
+
+func TestNewAddressPolicyRejectsInvalidNetworks(t *testing.T) {
+	if _, err := NewAddressPolicy([]string{"10.0.0.0"}); err == nil {
+		t.Error("network without prefix length accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// Headers sent with every delivery. The signature header has the form
+// "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">" so
+// receivers can verify the payload and reject replays.
+const (
+	HeaderEvent     = "X-Archive-Event"
+	HeaderDelivery  = "X-Archive-Delivery"
+	HeaderSignature = "X-Archive-Signature"
+)
+
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender returns a sender that only connects to addresses the policy
+// allows. Proxies are not used, as they would connect on its behalf.
+func NewHTTPSender(timeout time.Duration, policy *AddressPolicy) *HTTPSender {
+	dialer := &net.Dialer{Timeout: timeout, Control: policy.control}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &HTTPSender{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *HTTPSender) Send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build webhook request")
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-archiven-webhooks/1")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(webhook.Secret, timestamp, delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "webhook request failed")
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+// Sink turns dispatched events into persisted webhook deliveries.
+type Sink struct {
+	enqueueUseCase *usecases.EnqueueWebhookDeliveriesUseCase
+}
+
+func NewSink(enqueueUC *usecases.EnqueueWebhookDeliveriesUseCase) *Sink {
+	return &Sink{enqueueUseCase: enqueueUC}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Publish(ctx context.Context, event domain.Event) error {
+	return s.enqueueUseCase.Execute(ctx, event)
+}
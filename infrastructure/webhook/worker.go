@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+)
+
+const workerBatchSize = 20
+
+// Worker periodically attempts due webhook deliveries.
+type Worker struct {
+	deliverUseCase *usecases.DeliverWebhooksUseCase
+	interval       time.Duration
+}
+
+func NewWorker(deliverUC *usecases.DeliverWebhooksUseCase, interval time.Duration) *Worker {
+	return &Worker{deliverUseCase: deliverUC, interval: interval}
+}
+
+// Run processes deliveries until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Drain full batches before waiting for the next tick
+		for {
+			n, err := w.deliverUseCase.Execute(ctx, workerBatchSize)
+			if err != nil {
+				configs.Logger.Errorw("webhook delivery run failed", "error", err.Error())
+				break
+			}
+			if n < workerBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
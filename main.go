@@ -2,71 +2,135 @@ package main
 
 import (
 	"context"
-	"time"
-
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
 
-	"github.com/labstack/echo/v4"
-
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
 	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
-	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func main() {
-	// Load environment variables
-	configs.InitializeConfig()
-	e := echo.New()
-	// Initialize logger
-	defer configs.SyncLogger()
+// command is a subcommand of the binary. Each parses its own flags, which
+// include every configuration flag, from the arguments after its name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	// Add request logging middleware
-	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			start := time.Now()
+var commands = []command{
+	{"serve", "run the HTTP API (the default)", serve},
+	{"import", "upload local files and directories", importFiles},
+	{"export", "write files to a ZIP archive", exportFiles},
+	{"verify", "check the audit log chain and storage consistency", verify},
+	{"reindex", "create missing MongoDB indexes", reindex},
+	{"gc", "delete orphan chunks and expired exports", gc},
+	{"stats", "print archive statistics", stats},
+	{"user", "create or delete users with API tokens", userCommand},
+}
+
+func main() {
+	// Without a command, flags go to serve as they did before there were
+	// commands
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return
+	}
 
-			err := next(c)
-			if err != nil {
-				c.Error(err)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				log.Fatal(err)
 			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-			latency := time.Since(start)
-			configs.Logger.Infow("request",
-				"method", c.Request().Method,
-				"uri", c.Request().URL.Path,
-				"status", c.Response().Status,
-				"latency", latency.String(),
-				"ip", c.RealIP(),
-			)
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags] [arguments]\n\ncommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", programName())
+}
 
-			return err
-		}
-	})
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
 
-	mongoURI := configs.GetMongoURI()
-	if mongoURI == "" {
-		log.Fatal("MONGODB_URI environment variable not set")
+// newFlagSet returns the flag set of a command; arguments describes what
+// follows the flags and description is shown above them in the help.
+func newFlagSet(name, arguments, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s [flags] %s\n\n%s\n\nflags:\n", programName(), name, arguments, description)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	// MongoDB setup
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+// connect loads the configuration of a maintenance command from its
+// arguments and opens the database. The returned function disconnects.
+func connect(fs *flag.FlagSet, args []string) (*configs.Config, *mongo.Database, func(), error) {
+	cfg, err := configs.Load(fs, args)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
-	defer client.Disconnect(context.Background())
-
-	dbName := configs.GetDatabaseName()
-	if dbName == "" {
-		log.Fatal("DATABASE_NAME environment variable not set")
+	if err := configs.InitializeLogger(cfg.Log); err != nil {
+		return nil, nil, nil, err
 	}
 
-	db := client.Database(dbName)
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI(cfg.Mongo.URI).
+		SetTimeout(cfg.Mongo.OperationTimeout))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	disconnect := func() {
+		client.Disconnect(context.Background())
+		configs.SyncLogger()
+	}
+	return cfg, client.Database(cfg.Mongo.Database), disconnect, nil
+}
 
-	// Routing Initialization
-	web.SetupRoutes(e, db)
+// actorFlag registers the -actor flag of commands that change the archive.
+// Their changes are audited under "cli:<login>" unless told otherwise.
+func actorFlag(fs *flag.FlagSet) *string {
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = "cli:" + u.Username
+	}
+	return fs.String("actor", actor, "actor recorded in the audit log")
+}
 
-	// Start server
-	port := ":" + configs.GetServerPort()
-	e.Logger.Fatal(e.Start(port))
+// audit records the outcome of a command's change to a file. A failure to
+// record it is reported but does not undo the change.
+func audit(ctx context.Context, uc *usecases.RecordAuditUseCase, actor string, action domain.AuditAction, fileID string, err error) {
+	outcome := domain.AuditOutcomeSuccess
+	if err != nil {
+		outcome = domain.AuditOutcomeFailure
+	}
+	if _, auditErr := uc.Execute(ctx, usecases.RecordAuditCommand{
+		Actor:   actor,
+		Action:  action,
+		FileID:  fileID,
+		Outcome: outcome,
+	}); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to record %s of %q in the audit log: %v\n", action, fileID, auditErr)
+	}
 }
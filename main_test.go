@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+)
+
+func TestCommandsAreDocumented(t *testing.T) {
+	readme, err := os.ReadFile("Readme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range commands {
+		if !bytes.Contains(readme, []byte("- `"+cmd.name)) {
+			t.Errorf("command %s is missing from the Readme", cmd.name)
+		}
+	}
+}
+
+func TestImportItems(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"scan-001.pdf":        "%PDF",
+		"notes.json":          "{}",
+		"batch/scan-002.png":  "png",
+		"batch/.DS_Store":     "",
+		".git/config":         "",
+		"batch/unknown.xyz01": "?",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, paths, err := importItems([]string{dir, filepath.Join(dir, "notes.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i, item := range items {
+		rel, _ := filepath.Rel(dir, paths[i])
+		got = append(got, rel+" "+item.Name+" "+item.ContentType)
+	}
+	want := []string{
+		"batch/scan-002.png scan-002.png image/png",
+		"batch/unknown.xyz01 unknown.xyz01 application/octet-stream",
+		"notes.json notes.json application/json",
+		"scan-001.pdf scan-001.pdf application/pdf",
+		"notes.json notes.json application/json",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got\n  %s\nwant\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+
+	content, err := items[3].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	if items[3].Size != 4 {
+		t.Errorf("size = %d", items[3].Size)
+	}
+
+	if _, _, err := importItems([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("missing path accepted")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"":                          {},
+		"2024-03-01":                time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2024-03-01T08:30:00+07:00": time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC),
+	} {
+		got, err := parseDate("from", value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("%q: got %s, %v", value, got, err)
+		}
+	}
+	if _, err := parseDate("from", "01/03/2024"); err == nil || !strings.Contains(err.Error(), "-from") {
+		t.Errorf("invalid date: got %v", err)
+	}
+}
+
+// memoryUserRepository holds users by name.
+type memoryUserRepository map[string]*domain.User
+
+func (r memoryUserRepository) Save(ctx context.Context, user *domain.User) error {
+	if _, ok := r[user.Name]; ok {
+		return domain.ErrUserNameTaken
+	}
+	r[user.Name] = user
+	return nil
+}
+
+func (r memoryUserRepository) FindByTokenHash(ctx context.Context, hash string) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (r memoryUserRepository) DeleteByName(ctx context.Context, name string) error {
+	if _, ok := r[name]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(r, name)
+	return nil
+}
+
+func TestManageUser(t *testing.T) {
+	repo := memoryUserRepository{}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := manageUser(ctx, repo, "create", "scanner-01", &out); err != nil {
+		t.Fatal(err)
+	}
+	token := strings.TrimSuffix(out.String(), "\n")
+	if !strings.HasPrefix(token, domain.TokenPrefix) || strings.Contains(token, "\n") {
+		t.Fatalf("output %q is not just the token", out.String())
+	}
+	if user := repo["scanner-01"]; user == nil || user.TokenHash != domain.HashToken(token) {
+		t.Fatalf("stored user %+v", user)
+	}
+
+	if err := manageUser(ctx, repo, "create", "scanner-01", &out); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("duplicate user: got %v", err)
+	}
+	if err := manageUser(ctx, repo, "delete", "scanner-01", &out); err != nil || len(repo) != 0 {
+		t.Errorf("delete: %v, %d users left", err, len(repo))
+	}
+	if err := manageUser(ctx, repo, "delete", "scanner-01", &out); err == nil {
+		t.Error("deleting a missing user succeeded")
+	}
+	if err := manageUser(ctx, repo, "list", "", &out); err == nil {
+		t.Error("unknown subcommand succeeded")
+	}
+}
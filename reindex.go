@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+)
+
+// reindex creates the indexes the server creates at startup, so they can be
+// built ahead of a deployment instead of while it starts.
+func reindex(args []string) error {
+	flags := newFlagSet("reindex", "",
+		"Creates any missing MongoDB indexes. Existing indexes are left as they are.")
+	cfg, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	indexes := []struct {
+		name   string
+		ensure func(ctx context.Context) error
+	}{
+		{"files", infrastructure.NewMongoFileRepository(db, cfg.Storage).EnsureIndexes},
+		{"collections", infrastructure.NewMongoCollectionRepository(db).EnsureIndexes},
+		{"audit log", infrastructure.NewMongoAuditRepository(db).EnsureIndexes},
+		{"webhook deliveries", infrastructure.NewMongoWebhookDeliveryRepository(db).EnsureIndexes},
+		{"idempotency keys", infrastructure.NewMongoIdempotencyRepository(db).EnsureIndexes},
+		{"jobs", infrastructure.NewMongoJobRepository(db).EnsureIndexes},
+		{"outbox", infrastructure.NewMongoOutboxRepository(db).EnsureIndexes},
+		{"users", infrastructure.NewMongoUserRepository(db).EnsureIndexes},
+	}
+
+	failed := 0
+	for _, index := range indexes {
+		if err := index.ensure(context.Background()); err != nil {
+			fmt.Printf("failed  %s: %v\n", index.name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("ok      %s\n", index.name)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d index sets could not be created", failed)
+	}
+	return nil
+}
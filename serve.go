@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/configs"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/metrics"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/tracing"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/i18n"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure/web/problem"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serve runs the HTTP API until it receives SIGINT or SIGTERM.
+func serve(args []string) error {
+	// Load configuration
+	cfg, err := configs.Load(newFlagSet("serve", "", "Runs the HTTP API. This is the default command."), args)
+	if err != nil {
+		return err
+	}
+	catalog, err := i18n.NewCatalog(cfg.Server.DefaultLanguage)
+	if err != nil {
+		return err
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = problem.NewHTTPErrorHandler(catalog)
+	// Initialize logger
+	if err := configs.InitializeLogger(cfg.Log); err != nil {
+		return err
+	}
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(cfg.Tracing.Exporter, cfg.Tracing.File)
+	if err != nil {
+		return err
+	}
+	e.Use(middleware.RequestID())
+	e.Use(otelecho.Middleware(tracing.ServiceName))
+
+	// Add request logging middleware
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			latency := time.Since(start)
+			configs.Logger.Infow("request",
+				"method", c.Request().Method,
+				"uri", c.Request().URL.Path,
+				"status", c.Response().Status,
+				"latency", latency.String(),
+				"ip", c.RealIP(),
+				"trace_id", trace.SpanContextFromContext(c.Request().Context()).TraceID().String(),
+				"request_id", c.Response().Header().Get(echo.HeaderXRequestID),
+			)
+
+			return nil
+		}
+	})
+
+	// Add Prometheus instrumentation
+	e.Use(metrics.Middleware)
+
+	// MongoDB setup
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI(cfg.Mongo.URI).
+		SetTimeout(cfg.Mongo.OperationTimeout).
+		SetMonitor(otelmongo.NewMonitor()))
+	if err != nil {
+		return err
+	}
+
+	db := client.Database(cfg.Mongo.Database)
+
+	// Routing Initialization
+	lifecycle := web.NewLifecycle()
+	if err := web.SetupRoutes(e, db, cfg, catalog, lifecycle); err != nil {
+		return err
+	}
+
+	// Requests run under a context we can cancel if they outlive the
+	// shutdown deadline, so unfinished upload streams are aborted.
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	e.Server.BaseContext = func(net.Listener) context.Context { return requestsCtx }
+
+	// Start server
+	port := ":" + cfg.Server.Port
+	go func() {
+		if err := e.Start(port); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	shutdown(e, cfg.Shutdown, lifecycle, cancelRequests)
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		configs.Logger.Errorw("tracing shutdown failed", "error", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Disconnect(ctx); err != nil {
+		configs.Logger.Errorw("mongodb disconnect failed", "error", err.Error())
+	}
+	configs.Logger.Infow("shutdown complete")
+	configs.SyncLogger()
+	return nil
+}
+
+// shutdown stops traffic in stages: readiness first so load balancers move
+// away, then new connections, then whatever is still transferring files.
+func shutdown(e *echo.Echo, cfg configs.ShutdownConfig, lifecycle *web.Lifecycle, cancelRequests context.CancelFunc) {
+	lifecycle.Readiness.SetShuttingDown()
+	configs.Logger.Infow("shutting down", "active_transfers", lifecycle.Transfers.Active())
+	time.Sleep(cfg.ReadinessDelay)
+
+	// End event streams and background workers; they resume from the
+	// outbox and delivery log on the next start.
+	lifecycle.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		configs.Logger.Warnw("shutdown deadline reached, aborting transfers",
+			"error", err.Error(),
+			"active_transfers", lifecycle.Transfers.Active(),
+		)
+		cancelRequests()
+
+		abortCtx, abortCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer abortCancel()
+		if !lifecycle.Transfers.Wait(abortCtx) {
+			configs.Logger.Errorw("transfers did not stop after abort",
+				"active_transfers", lifecycle.Transfers.Active(),
+			)
+		}
+	}
+
+	workersCtx, workersCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer workersCancel()
+	if !lifecycle.WaitForWorkers(workersCtx) {
+		configs.Logger.Warnw("background workers did not stop in time")
+	}
+}
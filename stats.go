@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+)
+
+// stats prints the size of the archive, the audit log and the job queue.
+func stats(args []string) error {
+	flags := newFlagSet("stats", "", "Prints the number and size of stored files, audit log entries and jobs by status.")
+	cfg, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	ctx := context.Background()
+	archive, err := infrastructure.NewMongoFileRepository(db, cfg.Storage).Stats(ctx)
+	if err != nil {
+		return err
+	}
+	entries, err := infrastructure.NewMongoAuditRepository(db).Count(ctx, domain.AuditFilter{})
+	if err != nil {
+		return err
+	}
+
+	jobRepo := infrastructure.NewMongoJobRepository(db)
+	var jobs []string
+	for _, status := range []domain.JobStatus{domain.JobPending, domain.JobRunning, domain.JobSucceeded, domain.JobDead} {
+		count, err := jobRepo.Count(ctx, domain.JobFilter{Status: status})
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, fmt.Sprintf("%d %s", count, status))
+	}
+
+	fmt.Printf("files      %d (%s)\n", archive.Files, humanize.IBytes(uint64(archive.Bytes)))
+	fmt.Printf("audit log  %d entries\n", entries)
+	fmt.Printf("jobs       %s\n", strings.Join(jobs, ", "))
+	return nil
+}
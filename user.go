@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+)
+
+// userCommand manages the users that call the API with their own bearer
+// token rather than through the authenticating proxy.
+func userCommand(args []string) error {
+	flags := newFlagSet("user", "create|delete <name>",
+		"create adds a user and prints its bearer token, which is shown only once; delete removes a user\n"+
+			"and revokes its token. Users send \"Authorization: Bearer <token>\" and are recorded in the\n"+
+			"audit log by name. Requests through the authenticating proxy need no user.")
+	_, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("expected create or delete and a user name")
+	}
+	return manageUser(context.Background(), infrastructure.NewMongoUserRepository(db), flags.Arg(0), flags.Arg(1), os.Stdout)
+}
+
+// manageUser runs a user subcommand. The token of a new user is the only
+// output on out, so scripts can capture it; the rest goes to standard error.
+func manageUser(ctx context.Context, repo domain.UserRepository, action, name string, out io.Writer) error {
+	switch action {
+	case "create":
+		user, token, err := usecases.NewCreateUserUseCase(repo).Execute(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "user create %s", name)
+		}
+		fmt.Fprintf(os.Stderr, "created user %s; store its token now, it cannot be shown again\n", user.Name)
+		fmt.Fprintln(out, token)
+	case "delete":
+		if err := usecases.NewDeleteUserUseCase(repo).Execute(ctx, name); err != nil {
+			return errors.Wrapf(err, "user delete %s", name)
+		}
+		fmt.Fprintf(os.Stderr, "deleted user %s\n", name)
+	default:
+		return errors.Errorf("unknown user command %q, expected create or delete", action)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/yhartanto178dev/api-archiven-v2/application/usecases"
+	"github.com/yhartanto178dev/api-archiven-v2/domain"
+	"github.com/yhartanto178dev/api-archiven-v2/infrastructure"
+)
+
+// verify checks the audit log hash chain and GridFS storage without changing
+// anything. It fails when either has problems.
+func verify(args []string) error {
+	flags := newFlagSet("verify", "",
+		"Checks the audit log hash chain and looks for orphan chunks and files with missing or truncated chunks.\n"+
+			"Nothing is changed; use gc to clean up.")
+	anchor := flags.String("anchor", "", "head of the audit log recorded earlier, as <seq>:<hash>, that the chain must still contain")
+	cfg, db, disconnect, err := connect(flags, args)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	var anchors []domain.AuditAnchor
+	if *anchor != "" {
+		parsed, err := domain.ParseAuditAnchor(*anchor)
+		if err != nil {
+			return errors.Errorf("-anchor: %q is not <seq>:<hash>", *anchor)
+		}
+		anchors = append(anchors, parsed)
+	}
+
+	ctx := context.Background()
+	result, err := usecases.NewVerifyAuditLogUseCase(infrastructure.NewMongoAuditRepository(db)).Execute(ctx, anchors...)
+	if err != nil {
+		return err
+	}
+	if result.Valid {
+		fmt.Printf("audit log OK: %d entries, head %d:%s\n", result.Checked, result.Checked, result.LastHash)
+	} else {
+		fmt.Printf("audit log BROKEN at seq %d: %s (%d entries verified before it)\n",
+			result.BrokenAt, result.Reason, result.Checked)
+	}
+
+	report, err := cleanupStorage(db, cfg).Execute(ctx, usecases.CleanupStorageCommand{})
+	if err != nil {
+		return err
+	}
+	printStorageReport(report)
+
+	if !result.Valid || len(report.Orphans) > 0 || len(report.Incomplete) > 0 {
+		return errors.New("verification failed")
+	}
+	return nil
+}
+
+func printStorageReport(report *domain.StorageReport) {
+	for _, issue := range report.Orphans {
+		fmt.Printf("orphan      %s  %d chunks, %d bytes, started %s\n",
+			issue.FileID, issue.Chunks, issue.Bytes, issue.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	for _, issue := range report.Incomplete {
+		fmt.Printf("incomplete  %s  %q: %d/%d chunks, %d/%d bytes\n",
+			issue.FileID, issue.Name, issue.Chunks, issue.ExpectedChunks, issue.Bytes, issue.Length)
+	}
+	fmt.Printf("storage: %d orphan chunk sets (%d bytes), %d incomplete files, %d uploads in progress\n",
+		len(report.Orphans), report.OrphanBytes, len(report.Incomplete), report.InProgress)
+}